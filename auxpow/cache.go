@@ -0,0 +1,113 @@
+package auxpow
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// DefaultCacheSize is the default number of verified side auxpow results
+// kept by a SideAuxPowCache.
+const DefaultCacheSize = 1000
+
+// cacheKey identifies a side auxpow by the main chain block hash and the side
+// chain block hash it binds.
+type cacheKey struct {
+	mainBlockHash common.Uint256
+	sideBlockHash common.Uint256
+}
+
+// SideAuxPowCache keeps the side auxpows verified recently, so a block
+// processed again (e.g. an orphan or a block of a side chain being
+// reorganized) does not need to verify its side auxpow again. Only results
+// of checks depending on nothing but the side auxpow itself can be cached.
+type SideAuxPowCache struct {
+	mtx        sync.Mutex
+	maxEntries int
+	entries    map[cacheKey]*list.Element
+	order      *list.List
+}
+
+type cacheEntry struct {
+	key    cacheKey
+	digest [sha256.Size]byte
+}
+
+// NewSideAuxPowCache creates a cache keeping at most maxEntries verified side
+// auxpows.
+func NewSideAuxPowCache(maxEntries int) *SideAuxPowCache {
+	return &SideAuxPowCache{
+		maxEntries: maxEntries,
+		entries:    make(map[cacheKey]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Contains returns if the side auxpow has been verified for the side chain
+// block hash. The whole side auxpow is compared, so a different side auxpow
+// binding the same blocks is not treated as verified.
+func (c *SideAuxPowCache) Contains(sap *SideAuxPow, sideBlockHash common.Uint256) bool {
+	key := cacheKey{sap.MainBlockHeader.Hash(), sideBlockHash}
+
+	c.mtx.Lock()
+	elem, ok := c.entries[key]
+	c.mtx.Unlock()
+	if !ok {
+		return false
+	}
+
+	digest, err := sap.digest()
+	if err != nil {
+		return false
+	}
+	return elem.Value.(*cacheEntry).digest == digest
+}
+
+// Add records the side auxpow as verified for the side chain block hash, the
+// oldest entry is evicted if the cache is full.
+func (c *SideAuxPowCache) Add(sap *SideAuxPow, sideBlockHash common.Uint256) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	digest, err := sap.digest()
+	if err != nil {
+		return
+	}
+	key := cacheKey{sap.MainBlockHeader.Hash(), sideBlockHash}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).digest = digest
+		c.order.MoveToBack(elem)
+		return
+	}
+
+	if c.order.Len() >= c.maxEntries {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+	c.entries[key] = c.order.PushBack(&cacheEntry{key: key, digest: digest})
+}
+
+// Len returns the number of side auxpows in the cache.
+func (c *SideAuxPowCache) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.order.Len()
+}
+
+// digest returns the hash of the serialized side auxpow.
+func (sap *SideAuxPow) digest() ([sha256.Size]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := sap.Serialize(buf); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(buf.Bytes()), nil
+}
@@ -0,0 +1,33 @@
+//go:build go1.18
+// +build go1.18
+
+package auxpow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzSideAuxPow(f *testing.F) {
+	buf := new(bytes.Buffer)
+	GenerateSideAuxPow(common.Uint256{1}, common.Uint256{2}).Serialize(buf)
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var sideAuxPow SideAuxPow
+		if err := sideAuxPow.Deserialize(bytes.NewReader(data)); err != nil {
+			return
+		}
+		sideAuxPow.SideAuxPowCheck(common.Uint256{1})
+
+		serialized := new(bytes.Buffer)
+		assert.NoError(t, sideAuxPow.Serialize(serialized))
+		var again SideAuxPow
+		assert.NoError(t, again.Deserialize(bytes.NewReader(serialized.Bytes())))
+		buf := new(bytes.Buffer)
+		assert.NoError(t, again.Serialize(buf))
+		assert.Equal(t, serialized.Bytes(), buf.Bytes())
+	})
+}
@@ -99,6 +99,9 @@ func (sap *SideAuxPow) SideAuxPowCheck(hashAuxBlock common.Uint256) error {
 	}
 
 	payloadData := sap.SideAuxBlockTx.Payload.Data(payload.SideChainPowVersion)
+	if len(payloadData) < common.UINT256SIZE {
+		return errors.New("side aux block tx payload is too short")
+	}
 	payloadHashData := payloadData[0:32]
 	payloadHash, err := common.Uint256FromBytes(payloadHashData)
 	if err != nil {
@@ -0,0 +1,33 @@
+package auxpow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA/auxpow"
+	"github.com/elastos/Elastos.ELA/common"
+	ela "github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSideAuxPowCheck(t *testing.T) {
+	blockHash := common.Uint256{1}
+	sideAuxPow := GenerateSideAuxPow(blockHash, common.Uint256{2})
+	assert.NoError(t, sideAuxPow.SideAuxPowCheck(blockHash))
+	assert.Error(t, sideAuxPow.SideAuxPowCheck(common.Uint256{3}))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, sideAuxPow.Serialize(buf))
+	var decoded SideAuxPow
+	assert.NoError(t, decoded.Deserialize(bytes.NewReader(buf.Bytes())))
+	assert.NoError(t, decoded.SideAuxPowCheck(blockHash))
+
+	// The side aux block tx is not a side chain pow transaction.
+	tx := ela.Transaction{TxType: ela.TransferAsset, Payload: new(payload.TransferAsset)}
+	header := ela.Header{Version: 0x7fffffff, MerkleRoot: tx.Hash()}
+	headerHash := header.Hash()
+	header.AuxPow = *auxpow.GenerateAuxPow(headerHash)
+	sideAuxPow = NewSideAuxPow(nil, 0, tx, header)
+	assert.Error(t, sideAuxPow.SideAuxPowCheck(blockHash))
+}
@@ -34,9 +34,18 @@ type Config struct {
 	Validator      *Validator
 	CheckTxSanity  func(*types.Transaction, uint32, uint32) error
 	CheckTxContext func(*types.Transaction, uint32, uint32) error
-	GetTxFee       func(tx *types.Transaction, assetId common.Uint256) (common.Fixed64, error)
+	GetTxFee       func(tx *types.Transaction, assetId common.Uint256, height uint32) (common.Fixed64, error)
 	GetHeader      func(hash common.Uint256) (interfaces.Header, error)
 	GetBlock       func(hash common.Uint256) (*types.Block, error)
+
+	// CheckTxSignature verifies the signatures of a transaction, if it is
+	// set the transactions of a block extending the best chain are verified
+	// concurrently before the block is connected.
+	CheckTxSignature func(*types.Transaction, uint32, uint32) error
+
+	// DifficultyCalculator overrides the difficulty algorithm selected
+	// from the chain parameters if it is set.
+	DifficultyCalculator DifficultyCalculator
 }
 
 type BlockChain struct {
@@ -49,9 +58,8 @@ type BlockChain struct {
 	// parameters.  They are also set when the instance is created and
 	// can't be changed afterwards, so there is no need to protect them with
 	// a separate mutex.
-	minRetargetTimespan int64  // target timespan / adjustment factor
-	maxRetargetTimespan int64  // target timespan * adjustment factor
-	blocksPerRetarget   uint32 // target timespan / target time per block
+	retargetCalculator DifficultyCalculator
+	lwmaCalculator     DifficultyCalculator
 
 	mutex          sync.RWMutex
 	BestChain      *BlockNode
@@ -66,36 +74,45 @@ type BlockChain struct {
 	TimeSource     MedianTimeSource
 	MedianTimePast time.Time
 	OrphanLock     sync.RWMutex
+
+	deploymentLock   sync.Mutex
+	deploymentCaches map[string]thresholdStateCache
+
+	verifiedTxsLock sync.RWMutex
+	verifiedTxs     map[common.Uint256]*types.Transaction
 }
 
 func New(cfg *Config) (*BlockChain, error) {
+	if err := cfg.ChainParams.CheckRewardSchedule(); err != nil {
+		return nil, err
+	}
+
 	genesisHash, err := cfg.ChainStore.GetBlockHash(0)
 	if err != nil {
 		return nil, fmt.Errorf("query genesis block hash failed, error %s", err)
 	}
 
 	chainParams := cfg.ChainParams
-	targetTimespan := int64(chainParams.TargetTimespan / time.Second)
-	targetTimePerBlock := int64(chainParams.TargetTimePerBlock / time.Second)
-	adjustmentFactor := chainParams.AdjustmentFactor
 	chain := BlockChain{
-		cfg:                 cfg,
-		chainParams:         chainParams,
-		db:                  cfg.ChainStore,
-		GenesisHash:         genesisHash,
-		minRetargetTimespan: targetTimespan / adjustmentFactor,
-		maxRetargetTimespan: targetTimespan * adjustmentFactor,
-		blocksPerRetarget:   uint32(targetTimespan / targetTimePerBlock),
-		Root:                nil,
-		BestChain:           nil,
-		Index:               make(map[common.Uint256]*BlockNode),
-		DepNodes:            make(map[common.Uint256][]*BlockNode),
-		OldestOrphan:        nil,
-		Orphans:             make(map[common.Uint256]*OrphanBlock),
-		PrevOrphans:         make(map[common.Uint256][]*OrphanBlock),
-		BlockCache:          make(map[common.Uint256]*types.Block),
-		TimeSource:          NewMedianTime(),
-	}
+		cfg:                cfg,
+		chainParams:        chainParams,
+		db:                 cfg.ChainStore,
+		GenesisHash:        genesisHash,
+		retargetCalculator: NewRetargetCalculator(chainParams),
+		lwmaCalculator:     NewLWMACalculator(chainParams),
+		Root:               nil,
+		BestChain:          nil,
+		Index:              make(map[common.Uint256]*BlockNode),
+		DepNodes:           make(map[common.Uint256][]*BlockNode),
+		OldestOrphan:       nil,
+		Orphans:            make(map[common.Uint256]*OrphanBlock),
+		PrevOrphans:        make(map[common.Uint256][]*OrphanBlock),
+		BlockCache:         make(map[common.Uint256]*types.Block),
+		TimeSource:         NewMedianTime(),
+		deploymentCaches:   make(map[string]thresholdStateCache),
+		verifiedTxs:        make(map[common.Uint256]*types.Transaction),
+	}
+	cfg.ChainStore.targetDataActive = chain.IsCrossChainTargetDataActive
 
 	endHeight := cfg.ChainStore.GetHeight()
 	startHeight := uint32(0)
@@ -126,9 +143,22 @@ func New(cfg *Config) (*BlockChain, error) {
 		chain.BestChain = node
 	}
 
+	// The stored blocks are read with the rules of the chain to be indexed.
+	if err := chain.db.indexWithdrawals(); err != nil {
+		return nil, err
+	}
+	if err := chain.db.indexStoredDeposits(); err != nil {
+		return nil, err
+	}
+
 	return &chain, nil
 }
 
+// GetParams returns the chain parameters the block chain is created with.
+func (b *BlockChain) GetParams() *config.Params {
+	return b.chainParams
+}
+
 func (b *BlockChain) GetBestHeight() uint32 {
 	return b.db.GetHeight()
 }
@@ -156,7 +186,7 @@ func (b *BlockChain) GetBlockHash(height uint32) (common.Uint256, error) {
 func (b *BlockChain) GetBlockWithHeight(height uint32) (*types.Block, error) {
 	temp, err := b.db.GetBlockHash(height)
 	if err != nil {
-		return nil, errors.New("[Ledger],GetBlockWithHeight failed with height=" + string(height))
+		return nil, fmt.Errorf("[Ledger],GetBlockWithHeight failed with height=%d", height)
 	}
 	var bk *types.Block
 	if b.cfg.GetBlock != nil {
@@ -778,6 +808,7 @@ func (b *BlockChain) connectBlock(node *BlockNode, block *types.Block) error {
 	if err != nil {
 		return err
 	}
+	b.indexRechargedDeposits(block)
 
 	// Add the new node to the memory main chain indices for faster
 	// lookups.
@@ -813,14 +844,15 @@ func (b *BlockChain) CheckBlockContext(block *types.Block) error {
 			continue
 		}
 		// Calculate transaction fee
-		fee, err := b.cfg.GetTxFee(tx, b.chainParams.ElaAssetId)
+		fee, err := b.cfg.GetTxFee(tx, b.chainParams.ElaAssetId, block.GetHeight())
 		if err != nil {
 			continue
 		}
 		totalTxFee += fee
 	}
-	// Reward in coinbase must match total transaction fee
-	if rewardInCoinbase != totalTxFee {
+	// Reward in coinbase must match total transaction fee plus the subsidy
+	subsidy := b.GetRewardPeriod(block.GetHeight()).Subsidy
+	if rewardInCoinbase != totalTxFee+subsidy {
 		return errors.New("reward amount in coinbase not correct")
 	}
 	return nil
@@ -873,6 +905,17 @@ func (b *BlockChain) maybeAcceptBlock(block *types.Block) (bool, error) {
 		return false, err
 	}
 
+	// Verify the transaction signatures concurrently if the block extends
+	// the best chain, the referenced outputs of the transactions in other
+	// chains are not available yet.
+	if b.cfg.CheckTxSignature != nil && prevNode != nil &&
+		b.BestChain != nil && prevNode.Hash.IsEqual(*b.BestChain.Hash) {
+		defer b.clearVerifiedSignatures()
+		if err := b.checkTransactionSignatures(block); err != nil {
+			return false, err
+		}
+	}
+
 	// Prune block nodes which are no longer needed before creating
 	// a new node.
 	err = b.PruneBlockNodes()
@@ -899,6 +942,10 @@ func (b *BlockChain) maybeAcceptBlock(block *types.Block) (bool, error) {
 		return false, err
 	}
 
+	// Find the arbiter signing this block and another block of the same
+	// height.
+	b.checkIllegalBlock(block)
+
 	// Notify the caller that the new block was accepted into the block
 	// chain.  The caller would typically want to react by relaying the
 	// inventory to other peers.
@@ -310,6 +310,12 @@ func (s *ChainStore) persistTransactions(batch database.Batch, b *types.Block) e
 			}
 			s.PersistMainchainTx(batch, *hash)
 		}
+		if txn.TxType == types.TransferCrossChainAsset {
+			record := newWithdrawalRecord(txn, b.Header.GetHeight())
+			if err := s.PersistWithdrawal(batch, record); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -355,6 +361,12 @@ func (s *ChainStore) rollbackTransactions(batch database.Batch, b *types.Block)
 			}
 			s.RollbackMainchainTx(batch, *hash)
 		}
+		if txn.TxType == types.TransferCrossChainAsset {
+			err := s.RollbackWithdrawal(batch, txn.Hash(), b.Header.GetHeight())
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -30,6 +30,12 @@ const (
 	IX_SideChain_Tx   EntryPrefix = 0x92
 	IX_MainChain_Tx   EntryPrefix = 0x93
 	IX_Identification EntryPrefix = 0x94
+	IX_Deposit        EntryPrefix = 0x95
+	IX_Orphan_Deposit EntryPrefix = 0x96
+	IX_Illegal_Data   EntryPrefix = 0x97
+	IX_Withdrawal     EntryPrefix = 0x98
+	IX_Deposit_Height EntryPrefix = 0x99
+	IX_Withdrawn_Tx   EntryPrefix = 0x9a
 
 	// ASSET
 	ST_Info EntryPrefix = 0xc0
@@ -43,6 +49,8 @@ const (
 	//SYSTEM
 	SYS_CurrentBlock      EntryPrefix = 0x40
 	SYS_CurrentBookKeeper EntryPrefix = 0x42
+	SYS_WithdrawalIndex   EntryPrefix = 0x43
+	SYS_DepositIndex      EntryPrefix = 0x44
 
 	//CONFIG
 	CFG_Version EntryPrefix = 0xf0
@@ -98,6 +106,11 @@ type ChainStore struct {
 	persistCallbackFunctions  []*action
 	rollbackFunctions         []*action
 	rollbackCallbackFunctions []*action
+
+	// targetDataActive returns if the target data of the transfer cross
+	// chain asset payloads is active in the block of the height, it is set
+	// by the block chain of the store.
+	targetDataActive func(height uint32) bool
 }
 
 func NewChainStore(path string, genesisBlock *types.Block) (*ChainStore, error) {
@@ -466,7 +479,8 @@ func (s *ChainStore) GetTransaction(txId common.Uint256) (*types.Transaction, ui
 	}
 
 	var txn types.Transaction
-	if err := txn.Deserialize(r); err != nil {
+	targetData := s.targetDataActive == nil || s.targetDataActive(height)
+	if err := txn.DeserializeWithTargetData(r, targetData); err != nil {
 		return nil, height, err
 	}
 
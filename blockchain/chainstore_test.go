@@ -2,8 +2,11 @@ package blockchain
 
 import (
 	"container/list"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/elastos/Elastos.ELA.SideChain/auxpow"
 	"github.com/elastos/Elastos.ELA.SideChain/database"
 	"github.com/elastos/Elastos.ELA.SideChain/types"
 
@@ -145,6 +148,334 @@ func TestChainStore_IsMainchainTxHashDuplicate(t *testing.T) {
 	}
 }
 
+func TestChainStore_Deposit(t *testing.T) {
+	if testChainStore == nil {
+		t.Error("Chainstore init failed")
+	}
+
+	// 1. The deposit should not exist in DB.
+	_, err := testChainStore.GetDeposit(mainchainTxHash)
+	if err == nil {
+		t.Error("Found the deposit which should not exist in DB")
+	}
+
+	// 2. Persist and update the deposit
+	record := &DepositRecord{
+		MainChainTxHash: mainchainTxHash,
+		Status:          DepositPending,
+	}
+	if err := testChainStore.PersistDeposit(record); err != nil {
+		t.Error("Persist the deposit failed")
+	}
+	record.Status = DepositSent
+	record.RechargeTxHash = common.Uint256{1}
+	record.Attempts = 2
+	record.LastError = "error"
+	if err := testChainStore.PersistDeposit(record); err != nil {
+		t.Error("Persist the deposit failed")
+	}
+
+	// 3. Verify the deposit
+	stored, err := testChainStore.GetDeposit(mainchainTxHash)
+	if err != nil {
+		t.Error("Not found the deposit")
+	} else if *stored != *record {
+		t.Error("Deposit matched wrong value")
+	}
+	records, err := testChainStore.GetDeposits()
+	if err != nil || len(records) != 1 || *records[0] != *record {
+		t.Error("Deposits matched wrong value")
+	}
+
+	testChainStore.Delete(append([]byte{byte(IX_Deposit)},
+		mainchainTxHash.Bytes()...))
+}
+
+func TestChainStore_OrphanedDeposit(t *testing.T) {
+	if testChainStore == nil {
+		t.Error("Chainstore init failed")
+	}
+
+	// 1. Only the deposits recharged at or above the height should be found.
+	recharged := []*RechargedDeposit{
+		{MainChainTxHash: common.Uint256{1}, BestMainChainHeight: 100},
+		{MainChainTxHash: mainchainTxHash, BestMainChainHeight: 200},
+	}
+	for _, deposit := range recharged {
+		if err := testChainStore.PersistRechargedDeposit(deposit); err != nil {
+			t.Error("Persist the recharged deposit failed")
+		}
+	}
+	rechargedDeposits, err := testChainStore.GetRechargedDeposits(150)
+	if err != nil || len(rechargedDeposits) != 1 ||
+		*rechargedDeposits[0] != *recharged[1] {
+		t.Error("Recharged deposits matched wrong value")
+	}
+	rechargedDeposits, err = testChainStore.GetRechargedDeposits(100)
+	if err != nil || len(rechargedDeposits) != 2 {
+		t.Error("Recharged deposits matched wrong value")
+	}
+	for _, deposit := range recharged {
+		testChainStore.DeleteRechargedDeposit(deposit)
+	}
+
+	// 2. Persist and verify the orphaned deposit
+	deposit := &OrphanedDeposit{
+		MainChainTxHash: mainchainTxHash,
+		RollbackHeight:  100,
+	}
+	if err := testChainStore.PersistOrphanedDeposit(deposit); err != nil {
+		t.Error("Persist the orphaned deposit failed")
+	}
+	deposits, err := testChainStore.GetOrphanedDeposits()
+	if err != nil || len(deposits) != 1 || *deposits[0] != *deposit {
+		t.Error("Orphaned deposits matched wrong value")
+	}
+
+	testChainStore.Delete(append([]byte{byte(IX_Orphan_Deposit)},
+		mainchainTxHash.Bytes()...))
+}
+
+func TestChainStore_IllegalEvidence(t *testing.T) {
+	if testChainStore == nil {
+		t.Error("Chainstore init failed")
+	}
+
+	// 1. The evidence should be the same regardless of the block order.
+	evidence := newIllegalEvidence(100, []byte{1, 2, 3},
+		common.Uint256{2}, common.Uint256{1}, "address")
+	compare := newIllegalEvidence(100, []byte{1, 2, 3},
+		common.Uint256{1}, common.Uint256{2}, "address")
+	if evidence.Hash() != compare.Hash() ||
+		evidence.Evidence.DataHash != (common.Uint256{1}) {
+		t.Error("Illegal evidence depends on the block order")
+	}
+
+	// 2. Persist and verify the evidence by height
+	if err := testChainStore.PersistIllegalEvidence(evidence); err != nil {
+		t.Error("Persist the illegal evidence failed")
+	}
+	evidences, err := testChainStore.GetIllegalEvidences(100)
+	if err != nil || len(evidences) != 1 ||
+		evidences[0].Hash() != evidence.Hash() {
+		t.Error("Illegal evidences matched wrong value")
+	}
+	evidences, err = testChainStore.GetIllegalEvidences(101)
+	if err != nil || len(evidences) != 0 {
+		t.Error("Found the illegal evidences which should not exist in DB")
+	}
+
+	key := []byte{byte(IX_Illegal_Data), 0x64, 0, 0, 0}
+	hash := evidence.Hash()
+	testChainStore.Delete(append(key, hash.Bytes()...))
+}
+
+func TestChainStore_Withdrawal(t *testing.T) {
+	if testChainStore == nil {
+		t.Error("Chainstore init failed")
+	}
+
+	// 1. The withdrawal to an invalid address should be failed.
+	txn := &types.Transaction{
+		TxType: types.TransferCrossChainAsset,
+		Payload: &types.PayloadTransferCrossChainAsset{
+			CrossChainAddresses: []string{"invalid"},
+		},
+	}
+	if newWithdrawalRecord(txn, 1).Status != WithdrawalFailed {
+		t.Error("Withdrawal to invalid address should be failed")
+	}
+
+	// 2. Persist and verify the withdrawals by height range
+	batch := testChainStore.NewBatch()
+	records := []*WithdrawalRecord{
+		{TxID: common.Uint256{1}, Height: 10, Status: WithdrawalPending},
+		{TxID: common.Uint256{2}, Height: 10, Status: WithdrawalIncluded,
+			MainChainTxHash: common.Uint256{9}},
+		{TxID: common.Uint256{3}, Height: 11, Status: WithdrawalPending},
+		{TxID: common.Uint256{4}, Height: 12, Status: WithdrawalPending},
+	}
+	for _, record := range records {
+		if err := testChainStore.PersistWithdrawal(batch, record); err != nil {
+			t.Error("Persist the withdrawal failed")
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Error("Commit the withdrawals failed")
+	}
+	found, err := testChainStore.GetWithdrawals(10, 11, nil, 0, 100)
+	if err != nil || len(found) != 3 || *found[1] != *records[1] {
+		t.Error("Withdrawals matched wrong value")
+	}
+
+	// 3. Filter and paginate the withdrawals
+	pending := func(record *WithdrawalRecord) bool {
+		return record.Status == WithdrawalPending
+	}
+	found, err = testChainStore.GetWithdrawals(0, 100, pending, 1, 1)
+	if err != nil || len(found) != 1 || *found[0] != *records[2] {
+		t.Error("Pending withdrawals matched wrong value")
+	}
+
+	// 4. Include and revert the withdrawals by main chain height
+	for i, record := range records[2:] {
+		err := testChainStore.IncludeWithdrawal(record, common.Uint256{9},
+			uint32(100+i))
+		if err != nil {
+			t.Error("Include the withdrawal failed")
+		}
+	}
+	included, err := testChainStore.GetIncludedWithdrawals(101)
+	if err != nil || len(included) != 1 || *included[0] != *records[3] ||
+		included[0].MainChainHeight != 101 {
+		t.Error("Included withdrawals matched wrong value")
+	}
+	if err := testChainStore.RevertWithdrawal(included[0]); err != nil {
+		t.Error("Revert the withdrawal failed")
+	}
+	found, err = testChainStore.GetWithdrawals(12, 12, nil, 0, 100)
+	if err != nil || len(found) != 1 || found[0].Status != WithdrawalPending {
+		t.Error("Reverted withdrawal should be pending")
+	}
+	included, err = testChainStore.GetIncludedWithdrawals(0)
+	if err != nil || len(included) != 1 || *included[0] != *records[2] {
+		t.Error("Included withdrawals matched wrong value")
+	}
+
+	// 5. Rollback the withdrawals
+	batch = testChainStore.NewBatch()
+	for _, record := range records {
+		err := testChainStore.RollbackWithdrawal(batch, record.TxID,
+			record.Height)
+		if err != nil {
+			t.Error("Rollback the withdrawal failed")
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Error("Commit the withdrawals rollback failed")
+	}
+	found, err = testChainStore.GetWithdrawals(0, 100, nil, 0, 100)
+	if err != nil || len(found) != 0 {
+		t.Error("Found the withdrawals which should not exist in DB")
+	}
+	included, err = testChainStore.GetIncludedWithdrawals(0)
+	if err != nil || len(included) != 0 {
+		t.Error("Found the included withdrawals which should not exist in DB")
+	}
+}
+
+func TestChainStore_IndexWithdrawals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "withdrawals")
+	if err != nil {
+		t.Fatal("Create the data directory failed")
+	}
+	defer os.RemoveAll(dir)
+
+	// The withdrawals of the stored blocks are indexed with unknown status,
+	// which are not pending, unless they are failed.
+	withdrawal := func(address string) *types.Transaction {
+		return &types.Transaction{
+			TxType: types.TransferCrossChainAsset,
+			Payload: &types.PayloadTransferCrossChainAsset{
+				CrossChainAddresses: []string{address},
+				OutputIndexes:       []uint64{0},
+				CrossChainAmounts:   []common.Fixed64{1},
+			},
+			Outputs: []*types.Output{{Value: 2}},
+		}
+	}
+	genesis := &types.Block{
+		Header: &types.Header{
+			SideAuxPow: *auxpow.GenerateSideAuxPow(common.EmptyHash,
+				common.EmptyHash),
+		},
+		Transactions: []*types.Transaction{
+			{TxType: types.CoinBase, Payload: new(types.PayloadCoinBase)},
+			withdrawal("EH9uVaqWRxHuzJbroqzX18yxmeW8XVJyV9"),
+			withdrawal("invalid"),
+		},
+	}
+	store, err := NewChainStore(dir, genesis)
+	if err != nil {
+		t.Fatal("Create the chainstore failed")
+	}
+	defer store.Close()
+
+	// Drop the records of the genesis block as the stores of the old nodes.
+	batch := store.NewBatch()
+	for _, txn := range genesis.Transactions[1:] {
+		if err := store.RollbackWithdrawal(batch, txn.Hash(), 0); err != nil {
+			t.Fatal("Rollback the withdrawal failed")
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal("Commit the withdrawals rollback failed")
+	}
+
+	if err := store.indexWithdrawals(); err != nil {
+		t.Fatal("Index the withdrawals failed")
+	}
+	found, err := store.GetWithdrawals(0, 0, nil, 0, 100)
+	if err != nil || len(found) != 2 {
+		t.Fatal("Withdrawals of the stored blocks not indexed")
+	}
+	for _, record := range found {
+		status := WithdrawalUnknown
+		if record.TxID == genesis.Transactions[2].Hash() {
+			status = WithdrawalFailed
+		}
+		if record.Status != status {
+			t.Errorf("Withdrawal %s status %s, expected %s", record.TxID,
+				record.Status, status)
+		}
+	}
+}
+
+func TestChainStore_IndexStoredDeposits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deposits")
+	if err != nil {
+		t.Fatal("Create the data directory failed")
+	}
+	defer os.RemoveAll(dir)
+
+	// The deposits of the version 1 recharge transactions in the stored
+	// blocks are indexed at the unknown main chain height.
+	deposit := common.Uint256{0x01}
+	genesis := &types.Block{
+		Header: &types.Header{
+			SideAuxPow: *auxpow.GenerateSideAuxPow(common.EmptyHash,
+				common.EmptyHash),
+		},
+		Transactions: []*types.Transaction{
+			{TxType: types.CoinBase, Payload: new(types.PayloadCoinBase)},
+			{
+				TxType:         types.RechargeToSideChain,
+				PayloadVersion: types.RechargeToSideChainPayloadVersion1,
+				Payload: &types.PayloadRechargeToSideChain{
+					MainChainTransactionHash: deposit,
+				},
+			},
+		},
+	}
+	store, err := NewChainStore(dir, genesis)
+	if err != nil {
+		t.Fatal("Create the chainstore failed")
+	}
+	defer store.Close()
+
+	if err := store.indexStoredDeposits(); err != nil {
+		t.Fatal("Index the deposits failed")
+	}
+	found, err := store.GetRechargedDeposits(unknownMainChainHeight)
+	if err != nil || len(found) != 1 {
+		t.Fatal("Deposits of the stored blocks not indexed")
+	}
+	if found[0].MainChainTxHash != deposit {
+		t.Errorf("Indexed deposit %s, expected %s", found[0].MainChainTxHash,
+			deposit)
+	}
+}
+
 func TestChainStoreDone(t *testing.T) {
 	if testChainStore == nil {
 		t.Error("Chainstore init failed")
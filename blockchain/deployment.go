@@ -0,0 +1,363 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/elastos/Elastos.ELA.SideChain/config"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+const (
+	// vbTopBits defines the bits to set in the block header version to
+	// indicate that the version bits scheme is being used.
+	vbTopBits = 0x20000000
+
+	// vbTopMask is the bitmask to use to determine whether or not the
+	// version bits scheme is in use.
+	vbTopMask = 0xe0000000
+)
+
+// ThresholdState define the various threshold states used when voting on
+// consensus changes.
+type ThresholdState byte
+
+const (
+	// ThresholdDefined is the first state for each deployment and is the
+	// state for the genesis block has by definition for all deployments.
+	ThresholdDefined ThresholdState = iota
+
+	// ThresholdStarted is the state for a deployment once its start time
+	// has been reached.
+	ThresholdStarted
+
+	// ThresholdLockedIn is the state for a deployment during the retarget
+	// period which is after the ThresholdStarted state period and the
+	// number of blocks that have voted for the deployment equal or exceed
+	// the required number of votes for the deployment.
+	ThresholdLockedIn
+
+	// ThresholdActive is the state for a deployment for all blocks after a
+	// retarget period in which the deployment was in the ThresholdLockedIn
+	// state, or from the activation height of a height deployment.
+	ThresholdActive
+
+	// ThresholdFailed is the state for a deployment once its expiration
+	// time has been reached and it did not reach the ThresholdLockedIn
+	// state.
+	ThresholdFailed
+)
+
+var thresholdStateStrings = map[ThresholdState]string{
+	ThresholdDefined:  "defined",
+	ThresholdStarted:  "started",
+	ThresholdLockedIn: "lockedin",
+	ThresholdActive:   "active",
+	ThresholdFailed:   "failed",
+}
+
+func (t ThresholdState) String() string {
+	if s, ok := thresholdStateStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown ThresholdState (%d)", int(t))
+}
+
+// thresholdStateCache caches the threshold state of a version bits
+// deployment by the hash of the last block of each confirmation window.
+type thresholdStateCache map[common.Uint256]ThresholdState
+
+// DeploymentState returns the state of the named deployment for the block
+// after prevNode.
+func (b *BlockChain) DeploymentState(name string, prevNode *BlockNode) (ThresholdState, error) {
+	deployment := b.chainParams.GetDeployment(name)
+	if deployment == nil {
+		return ThresholdFailed, fmt.Errorf("deployment %s not found", name)
+	}
+
+	if !deployment.IsVersionBits() {
+		height := uint32(0)
+		if prevNode != nil {
+			height = prevNode.Height + 1
+		}
+		if deployment.IsActiveAtHeight(height) {
+			return ThresholdActive, nil
+		}
+		return ThresholdDefined, nil
+	}
+
+	b.deploymentLock.Lock()
+	defer b.deploymentLock.Unlock()
+
+	cache, ok := b.deploymentCaches[name]
+	if !ok {
+		cache = make(thresholdStateCache)
+		b.deploymentCaches[name] = cache
+	}
+	return b.thresholdState(prevNode, deployment, cache)
+}
+
+// NextDeploymentState returns the state of the named deployment for the block
+// after the end of the best chain.
+func (b *BlockChain) NextDeploymentState(name string) (ThresholdState, error) {
+	b.mutex.RLock()
+	prevNode := b.BestChain
+	b.mutex.RUnlock()
+
+	return b.DeploymentState(name, prevNode)
+}
+
+// IsDeploymentActive returns if the named deployment is active for the block
+// after the end of the best chain.
+func (b *BlockChain) IsDeploymentActive(name string) (bool, error) {
+	state, err := b.NextDeploymentState(name)
+	if err != nil {
+		return false, err
+	}
+	return state == ThresholdActive, nil
+}
+
+// IsDeploymentActiveAfter returns if the named deployment is active for the
+// block after prevNode, the deployments not defined in the parameters are
+// never active.
+func (b *BlockChain) IsDeploymentActiveAfter(name string, prevNode *BlockNode) bool {
+	state, err := b.DeploymentState(name, prevNode)
+	return err == nil && state == ThresholdActive
+}
+
+// IsDeploymentActiveAtHeight returns if the named deployment is active for
+// the block at the height of the best chain. The blocks of the best chain are
+// read from the chain store, which has its own lock, instead of the nodes
+// guarded by the chain lock. So it is used with or without holding the chain
+// lock, by the rules checking the transactions of the blocks being processed
+// as well as by the transaction pool and the RPC service.
+func (b *BlockChain) IsDeploymentActiveAtHeight(name string, height uint32) bool {
+	deployment := b.chainParams.GetDeployment(name)
+	if deployment == nil {
+		return false
+	}
+	if !deployment.IsVersionBits() {
+		return deployment.IsActiveAtHeight(height)
+	}
+	if height == 0 {
+		return b.IsDeploymentActiveAfter(name, nil)
+	}
+
+	prevHeight := height - 1
+	if bestHeight := b.db.GetHeight(); prevHeight > bestHeight {
+		prevHeight = bestHeight
+	}
+	hash, err := b.db.GetBlockHash(prevHeight)
+	if err != nil {
+		return false
+	}
+	header, err := b.GetHeader(hash)
+	if err != nil {
+		return false
+	}
+	return b.IsDeploymentActiveAfter(name, NewBlockNode(header, &hash))
+}
+
+// IsCrossChainTargetDataActive returns if the transfer cross chain asset
+// payloads of the block at the height carry the target data.
+func (b *BlockChain) IsCrossChainTargetDataActive(height uint32) bool {
+	return b.IsDeploymentActiveAtHeight(config.DeploymentCrossChainTargetData, height)
+}
+
+// GetRewardPeriod returns the reward period of the block at the height, the
+// schedule derived from Foundation rewards the miner only once the rule of
+// DeploymentRewardMinerOnly is active.
+func (b *BlockChain) GetRewardPeriod(height uint32) *config.RewardPeriod {
+	if len(b.chainParams.RewardSchedule) > 0 {
+		return b.chainParams.GetRewardPeriod(height)
+	}
+	return b.chainParams.GetDefaultRewardPeriod(
+		b.IsDeploymentActiveAtHeight(config.DeploymentRewardMinerOnly, height))
+}
+
+// CalcNextBlockVersion returns the header version of the block after the end
+// of the best chain, which signals all version bits deployments in started or
+// locked in state. Zero is returned if there is no version bits deployment.
+func (b *BlockChain) CalcNextBlockVersion() (uint32, error) {
+	version := uint32(0)
+	for _, deployment := range b.chainParams.GetDeployments() {
+		if !deployment.IsVersionBits() {
+			continue
+		}
+		version |= vbTopBits
+
+		state, err := b.NextDeploymentState(deployment.Name)
+		if err != nil {
+			return 0, err
+		}
+		if state == ThresholdStarted || state == ThresholdLockedIn {
+			version |= uint32(1) << deployment.BitNumber
+		}
+	}
+	return version, nil
+}
+
+// thresholdState returns the threshold state of the version bits deployment
+// for the block after prevNode. The state only changes at the start of each
+// confirmation window, so it is calculated from the last block of the
+// previous window and cached by its hash.
+func (b *BlockChain) thresholdState(prevNode *BlockNode,
+	deployment *config.Deployment, cache thresholdStateCache) (ThresholdState, error) {
+	window := b.chainParams.MinerConfirmationWindow
+	if window == 0 {
+		return ThresholdFailed, errors.New("miner confirmation window not set")
+	}
+
+	// The genesis block and the blocks of the first window are defined by
+	// definition.
+	if prevNode == nil || (prevNode.Height+1) < window {
+		return ThresholdDefined, nil
+	}
+
+	// Get the last block of the previous confirmation window.
+	prevNode, err := b.ancestorNode(prevNode,
+		prevNode.Height-(prevNode.Height+1)%window)
+	if err != nil {
+		return ThresholdFailed, err
+	}
+
+	// Walk back through the windows until a cached state is found or the
+	// deployment has not started yet.
+	state := ThresholdDefined
+	var neededStates []*BlockNode
+	for prevNode != nil {
+		if cachedState, ok := cache[*prevNode.Hash]; ok {
+			state = cachedState
+			break
+		}
+
+		medianTime, err := b.pastMedianTime(prevNode)
+		if err != nil {
+			return ThresholdFailed, err
+		}
+		if medianTime < deployment.StartTime {
+			cache[*prevNode.Hash] = ThresholdDefined
+			break
+		}
+		neededStates = append(neededStates, prevNode)
+
+		if prevNode.Height < window {
+			prevNode = nil
+			break
+		}
+		prevNode, err = b.ancestorNode(prevNode, prevNode.Height-window)
+		if err != nil {
+			return ThresholdFailed, err
+		}
+	}
+
+	// Calculate the states of the windows from the oldest to the newest.
+	for i := len(neededStates) - 1; i >= 0; i-- {
+		prevNode := neededStates[i]
+
+		switch state {
+		case ThresholdDefined:
+			medianTime, err := b.pastMedianTime(prevNode)
+			if err != nil {
+				return ThresholdFailed, err
+			}
+			if medianTime >= deployment.ExpireTime {
+				state = ThresholdFailed
+			} else if medianTime >= deployment.StartTime {
+				state = ThresholdStarted
+			}
+
+		case ThresholdStarted:
+			medianTime, err := b.pastMedianTime(prevNode)
+			if err != nil {
+				return ThresholdFailed, err
+			}
+			if medianTime >= deployment.ExpireTime {
+				state = ThresholdFailed
+				break
+			}
+
+			// Count the blocks signalling the deployment in the window.
+			count := uint32(0)
+			countNode := prevNode
+			for j := uint32(0); j < window; j++ {
+				if isSignalling(countNode.Version, deployment.BitNumber) {
+					count++
+				}
+				if countNode.Height == 0 {
+					break
+				}
+				countNode, err = b.ancestorNode(countNode, countNode.Height-1)
+				if err != nil {
+					return ThresholdFailed, err
+				}
+			}
+			if count >= b.chainParams.RuleChangeActivationThreshold {
+				state = ThresholdLockedIn
+			}
+
+		case ThresholdLockedIn:
+			state = ThresholdActive
+
+		case ThresholdActive, ThresholdFailed:
+			// Final states.
+		}
+
+		cache[*prevNode.Hash] = state
+	}
+
+	return state, nil
+}
+
+// isSignalling returns if the block header version signals the deployment
+// of the given bit.
+func isSignalling(version uint32, bit uint8) bool {
+	return version&vbTopMask == vbTopBits && version&(uint32(1)<<bit) != 0
+}
+
+// ancestorNode returns the ancestor of node at the given height. Nodes not
+// kept in memory are loaded from the main chain in database.
+func (b *BlockChain) ancestorNode(node *BlockNode, height uint32) (*BlockNode, error) {
+	for node != nil && node.Height > height {
+		if node.Parent == nil {
+			break
+		}
+		node = node.Parent
+	}
+	if node != nil && node.Height == height {
+		return node, nil
+	}
+
+	hash, err := b.db.GetBlockHash(height)
+	if err != nil {
+		return nil, err
+	}
+	header, err := b.GetHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlockNode(header, &hash), nil
+}
+
+// pastMedianTime returns the median time of the node and the previous
+// blocks, like CalcPastMedianTime but with blocks not kept in memory loaded
+// from database.
+func (b *BlockChain) pastMedianTime(node *BlockNode) (uint32, error) {
+	timestamps := make([]int64, 0, medianTimeBlocks)
+	for i := 0; i < medianTimeBlocks; i++ {
+		timestamps = append(timestamps, int64(node.Timestamp))
+		if node.Height == 0 {
+			break
+		}
+
+		var err error
+		node, err = b.ancestorNode(node, node.Height-1)
+		if err != nil {
+			return 0, err
+		}
+	}
+	sort.Sort(timeSorter(timestamps))
+	return uint32(timestamps[len(timestamps)/2]), nil
+}
@@ -0,0 +1,233 @@
+package blockchain
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/auxpow"
+	"github.com/elastos/Elastos.ELA.SideChain/config"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDeploymentTestNodes creates a chain of count block nodes from the genesis
+// block, the block at height h is stamped 1000+10*h and has the version
+// returned by version(h).
+func newDeploymentTestNodes(count uint32, version func(height uint32) uint32) []*BlockNode {
+	nodes := make([]*BlockNode, 0, count)
+	var parent *BlockNode
+	for height := uint32(0); height < count; height++ {
+		hash := common.Uint256{byte(height), byte(height >> 8), 0xbb}
+		node := &BlockNode{
+			Hash:      &hash,
+			Height:    height,
+			Version:   version(height),
+			Timestamp: 1000 + 10*height,
+			Parent:    parent,
+		}
+		nodes = append(nodes, node)
+		parent = node
+	}
+	return nodes
+}
+
+// newDeploymentTestStore creates a chain store of count blocks from the
+// genesis block, the block at height h is stamped 1000+10*h and has the
+// version returned by version(h).
+func newDeploymentTestStore(t *testing.T, count uint32,
+	version func(height uint32) uint32) (*ChainStore, func()) {
+	dir, err := ioutil.TempDir("", "deployment")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	newBlock := func(height uint32, previous common.Uint256) *types.Block {
+		return &types.Block{
+			Header: &types.Header{
+				Base: types.BaseHeader{
+					Version:   version(height),
+					Previous:  previous,
+					Timestamp: 1000 + 10*height,
+					Height:    height,
+				},
+				SideAuxPow: *auxpow.GenerateSideAuxPow(common.EmptyHash,
+					common.EmptyHash),
+			},
+			Transactions: []*types.Transaction{{
+				TxType:   types.CoinBase,
+				Payload:  new(types.PayloadCoinBase),
+				LockTime: height,
+			}},
+		}
+	}
+	block := newBlock(0, common.EmptyHash)
+	store, err := NewChainStore(dir, block)
+	if !assert.NoError(t, err) {
+		os.RemoveAll(dir)
+		t.FailNow()
+	}
+	for height := uint32(1); height < count; height++ {
+		block = newBlock(height, block.Hash())
+		if !assert.NoError(t, store.SaveBlock(block)) {
+			t.FailNow()
+		}
+	}
+	return store, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestBlockChain_DeploymentState(t *testing.T) {
+	params := &config.Params{
+		CheckPowHeaderHeight:          5,
+		RuleChangeActivationThreshold: 3,
+		MinerConfirmationWindow:       4,
+		Deployments: []config.Deployment{
+			{
+				Name:       "signalled",
+				BitNumber:  1,
+				StartTime:  1050,
+				ExpireTime: 2000,
+			},
+			{
+				Name:       "expired",
+				BitNumber:  2,
+				StartTime:  1050,
+				ExpireTime: 1100,
+			},
+		},
+	}
+	chain := &BlockChain{
+		cfg:              &Config{},
+		chainParams:      params,
+		deploymentCaches: make(map[string]thresholdStateCache),
+	}
+
+	// Blocks 12 and 15 signal bit 1, block 13 sets bit 1 without the version
+	// bits top bits.
+	nodes := newDeploymentTestNodes(32, func(height uint32) uint32 {
+		switch height {
+		case 12, 15:
+			return vbTopBits | 1<<1
+		case 13:
+			return 1 << 1
+		}
+		return vbTopBits
+	})
+
+	// Height deployment.
+	state, err := chain.DeploymentState(config.DeploymentCheckPowHeader, nodes[4])
+	assert.NoError(t, err)
+	assert.Equal(t, ThresholdDefined, state)
+	state, err = chain.DeploymentState(config.DeploymentCheckPowHeader, nodes[5])
+	assert.NoError(t, err)
+	assert.Equal(t, ThresholdActive, state)
+
+	// Version bits deployment, blocks 12 and 15 signal but the threshold is
+	// 3 of a window of 4 blocks, so it is not locked in.
+	expected := map[uint32]ThresholdState{
+		0:  ThresholdDefined,
+		10: ThresholdDefined,
+		11: ThresholdStarted,
+		15: ThresholdStarted,
+		19: ThresholdStarted,
+		31: ThresholdStarted,
+	}
+	for height, expectedState := range expected {
+		state, err := chain.DeploymentState("signalled", nodes[height])
+		assert.NoError(t, err)
+		assert.Equal(t, expectedState, state, "height %d", height)
+	}
+
+	// Signal bit 1 in block 13 as well to lock in the deployment.
+	nodes[13].Version = vbTopBits | 1<<1
+	chain.deploymentCaches = make(map[string]thresholdStateCache)
+	expected = map[uint32]ThresholdState{
+		11: ThresholdStarted,
+		14: ThresholdStarted,
+		15: ThresholdLockedIn,
+		18: ThresholdLockedIn,
+		19: ThresholdActive,
+		31: ThresholdActive,
+	}
+	for height, expectedState := range expected {
+		state, err := chain.DeploymentState("signalled", nodes[height])
+		assert.NoError(t, err)
+		assert.Equal(t, expectedState, state, "height %d", height)
+	}
+
+	// Deployment expired before signalled.
+	expected = map[uint32]ThresholdState{
+		11: ThresholdStarted,
+		15: ThresholdFailed,
+		31: ThresholdFailed,
+	}
+	for height, expectedState := range expected {
+		state, err := chain.DeploymentState("expired", nodes[height])
+		assert.NoError(t, err)
+		assert.Equal(t, expectedState, state, "height %d", height)
+	}
+
+	// Unknown deployment.
+	_, err = chain.DeploymentState("unknown", nodes[31])
+	assert.Error(t, err)
+}
+
+func TestBlockChain_IsDeploymentActiveAtHeight(t *testing.T) {
+	params := &config.Params{
+		CheckPowHeaderHeight:          5,
+		CRClaimDPOSNodeStartHeight:    7,
+		NewP2PProtocolVersionHeight:   1 << 40,
+		RewardMinerOnlyStartHeight:    10,
+		SchnorrStartHeight:            1,
+		RuleChangeActivationThreshold: 3,
+		MinerConfirmationWindow:       4,
+		Deployments: []config.Deployment{
+			{
+				Name:       config.DeploymentSchnorr,
+				BitNumber:  1,
+				StartTime:  1050,
+				ExpireTime: 2000,
+			},
+		},
+	}
+	// The blocks of the best chain are read from the store.
+	store, cleanup := newDeploymentTestStore(t, 32, func(height uint32) uint32 {
+		switch height {
+		case 12, 13, 15:
+			return vbTopBits | 1<<1
+		}
+		return vbTopBits
+	})
+	defer cleanup()
+	chain := &BlockChain{
+		cfg:              &Config{},
+		chainParams:      params,
+		db:               store,
+		deploymentCaches: make(map[string]thresholdStateCache),
+	}
+
+	// Height deployments.
+	assert.False(t, chain.IsDeploymentActiveAtHeight(config.DeploymentCheckPowHeader, 5))
+	assert.True(t, chain.IsDeploymentActiveAtHeight(config.DeploymentCheckPowHeader, 6))
+	assert.False(t, chain.IsDeploymentActiveAtHeight("unknown", 6))
+	assert.False(t, chain.IsDeploymentActiveAtHeight(config.DeploymentCRClaimDPOSNode, 6))
+	assert.True(t, chain.IsDeploymentActiveAtHeight(config.DeploymentCRClaimDPOSNode, 7))
+	assert.Equal(t, uint32(math.MaxUint32), params.GetDeployment(
+		config.DeploymentNewP2PProtocolVersion).ActivationHeight)
+
+	// The version bits deployment replaces SchnorrStartHeight.
+	assert.False(t, chain.IsDeploymentActiveAtHeight(config.DeploymentSchnorr, 1))
+	assert.False(t, chain.IsDeploymentActiveAtHeight(config.DeploymentSchnorr, 19))
+	assert.True(t, chain.IsDeploymentActiveAtHeight(config.DeploymentSchnorr, 20))
+	assert.True(t, chain.IsDeploymentActiveAtHeight(config.DeploymentSchnorr, 32))
+
+	// The reward period derived from Foundation.
+	assert.Equal(t, 1, len(chain.GetRewardPeriod(10).Recipients))
+	assert.Equal(t, 0, len(chain.GetRewardPeriod(11).Recipients))
+}
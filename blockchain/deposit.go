@@ -0,0 +1,400 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/elastos/Elastos.ELA.SideChain/events"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// DepositStatus is the status of a deposit transaction of the main chain,
+// which is tracked by the node relaying its recharge transaction.
+type DepositStatus byte
+
+const (
+	// DepositPending is the status of the deposits whose recharge
+	// transactions are waiting to be sent.
+	DepositPending DepositStatus = iota
+
+	// DepositSent is the status of the deposits whose recharge transactions
+	// are sent to the transaction pool.
+	DepositSent
+
+	// DepositConfirmed is the status of the deposits whose recharge
+	// transactions are in the chain.
+	DepositConfirmed
+
+	// DepositFailed is the status of the deposits whose recharge
+	// transactions failed to be sent after all the retries.
+	DepositFailed
+)
+
+var depositStatusStrings = map[DepositStatus]string{
+	DepositPending:   "pending",
+	DepositSent:      "sent",
+	DepositConfirmed: "confirmed",
+	DepositFailed:    "failed",
+}
+
+// String returns the name of the status.
+func (s DepositStatus) String() string {
+	if str, ok := depositStatusStrings[s]; ok {
+		return str
+	}
+	return "unknown"
+}
+
+// DepositRecord is the status of relaying a deposit transaction of the main
+// chain.
+type DepositRecord struct {
+	// MainChainTxHash is the hash of the deposit transaction.
+	MainChainTxHash common.Uint256
+
+	// Status is the status of the deposit.
+	Status DepositStatus
+
+	// RechargeTxHash is the hash of the recharge transaction last sent.
+	RechargeTxHash common.Uint256
+
+	// Attempts is the number of attempts to send the recharge transaction.
+	Attempts uint32
+
+	// LastError is the error of the last failed attempt.
+	LastError string
+}
+
+func (r *DepositRecord) Serialize(w io.Writer) error {
+	err := common.WriteElements(w, &r.MainChainTxHash, uint8(r.Status),
+		&r.RechargeTxHash, r.Attempts)
+	if err != nil {
+		return err
+	}
+	return common.WriteVarString(w, r.LastError)
+}
+
+func (r *DepositRecord) Deserialize(reader io.Reader) error {
+	var status uint8
+	err := common.ReadElements(reader, &r.MainChainTxHash, &status,
+		&r.RechargeTxHash, &r.Attempts)
+	if err != nil {
+		return err
+	}
+	r.Status = DepositStatus(status)
+	r.LastError, err = common.ReadVarString(reader)
+	return err
+}
+
+// PersistDeposit puts the deposit record into the store, replacing the
+// record of the same main chain transaction.
+func (s *ChainStore) PersistDeposit(record *DepositRecord) error {
+	value := new(bytes.Buffer)
+	if err := record.Serialize(value); err != nil {
+		return err
+	}
+	key := append([]byte{byte(IX_Deposit)}, record.MainChainTxHash.Bytes()...)
+	return s.Put(key, value.Bytes())
+}
+
+// GetDeposit returns the deposit record of the main chain transaction.
+func (s *ChainStore) GetDeposit(mainChainTxHash common.Uint256) (*DepositRecord, error) {
+	data, err := s.Get(append([]byte{byte(IX_Deposit)}, mainChainTxHash.Bytes()...))
+	if err != nil {
+		return nil, err
+	}
+	record := new(DepositRecord)
+	if err := record.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetDeposits returns all the deposit records in the store.
+func (s *ChainStore) GetDeposits() ([]*DepositRecord, error) {
+	var records []*DepositRecord
+	iter := s.NewIterator([]byte{byte(IX_Deposit)})
+	defer iter.Release()
+	for iter.Next() {
+		record := new(DepositRecord)
+		if err := record.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// OrphanedDeposit is a deposit recharged in the block chain, whose main chain
+// transaction was rolled back from the main chain.
+type OrphanedDeposit struct {
+	// MainChainTxHash is the hash of the deposit transaction.
+	MainChainTxHash common.Uint256
+
+	// RollbackHeight is the main chain height rolled back when the deposit
+	// transaction was found missing.
+	RollbackHeight uint32
+}
+
+// PersistOrphanedDeposit puts the orphaned deposit into the store.
+func (s *ChainStore) PersistOrphanedDeposit(deposit *OrphanedDeposit) error {
+	key := append([]byte{byte(IX_Orphan_Deposit)}, deposit.MainChainTxHash.Bytes()...)
+	value := new(bytes.Buffer)
+	if err := common.WriteUint32(value, deposit.RollbackHeight); err != nil {
+		return err
+	}
+	return s.Put(key, value.Bytes())
+}
+
+// GetOrphanedDeposits returns all the orphaned deposits in the store.
+func (s *ChainStore) GetOrphanedDeposits() ([]*OrphanedDeposit, error) {
+	var deposits []*OrphanedDeposit
+	iter := s.NewIterator([]byte{byte(IX_Orphan_Deposit)})
+	defer iter.Release()
+	for iter.Next() {
+		deposit := new(OrphanedDeposit)
+		hash, err := common.Uint256FromBytes(iter.Key()[1:])
+		if err != nil {
+			return nil, err
+		}
+		deposit.MainChainTxHash = *hash
+		deposit.RollbackHeight, err = common.ReadUint32(
+			bytes.NewReader(iter.Value()))
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+	return deposits, nil
+}
+
+// RechargedDeposit is a deposit recharged in the block chain, indexed by the
+// best main chain height when the recharge transaction was connected, which
+// is not below the main chain height of the deposit transaction.
+type RechargedDeposit struct {
+	// MainChainTxHash is the hash of the deposit transaction.
+	MainChainTxHash common.Uint256
+
+	// BestMainChainHeight is the best main chain height when the recharge
+	// transaction was connected.
+	BestMainChainHeight uint32
+}
+
+// unknownMainChainHeight is the best main chain height of the deposits
+// recharged in the blocks stored before the deposit index, which is unknown
+// until the first rollback of the main chain checks them.
+const unknownMainChainHeight = math.MaxUint32
+
+func (d *RechargedDeposit) key() []byte {
+	key := make([]byte, 1+4+common.UINT256SIZE)
+	key[0] = byte(IX_Deposit_Height)
+	// The height is big endian to iterate the deposits by height.
+	binary.BigEndian.PutUint32(key[1:], d.BestMainChainHeight)
+	copy(key[5:], d.MainChainTxHash[:])
+	return key
+}
+
+// PersistRechargedDeposit puts the recharged deposit into the store.
+func (s *ChainStore) PersistRechargedDeposit(deposit *RechargedDeposit) error {
+	return s.Put(deposit.key(), []byte{byte(ValueExist)})
+}
+
+// DeleteRechargedDeposit deletes the recharged deposit from the store.
+func (s *ChainStore) DeleteRechargedDeposit(deposit *RechargedDeposit) error {
+	return s.Delete(deposit.key())
+}
+
+// GetRechargedDeposits returns the recharged deposits whose best main chain
+// heights are not below the height.
+func (s *ChainStore) GetRechargedDeposits(height uint32) ([]*RechargedDeposit, error) {
+	var deposits []*RechargedDeposit
+	iter := s.NewIterator([]byte{byte(IX_Deposit_Height)})
+	defer iter.Release()
+
+	start := RechargedDeposit{BestMainChainHeight: height}
+	for ok := iter.Seek(start.key()); ok; ok = iter.Next() {
+		key := iter.Key()
+		if len(key) != 1+4+common.UINT256SIZE {
+			return nil, io.ErrUnexpectedEOF
+		}
+		deposit := &RechargedDeposit{
+			BestMainChainHeight: binary.BigEndian.Uint32(key[1:]),
+		}
+		copy(deposit.MainChainTxHash[:], key[5:])
+		deposits = append(deposits, deposit)
+	}
+	return deposits, nil
+}
+
+// indexRechargedDeposits indexes the deposits of the recharge transactions in
+// the block, to check only the ones which may be at or above the height
+// rolled back from the main chain. The deposits of the version 0 recharge
+// transactions carry the main chain transactions in the payloads and are not
+// tracked by the SPV service, so they are not indexed.
+func (b *BlockChain) indexRechargedDeposits(block *types.Block) {
+	if b.cfg.Validator == nil || b.cfg.Validator.spvService == nil {
+		return
+	}
+
+	bestHeight := b.cfg.Validator.spvService.BestHeight()
+	for _, tx := range block.Transactions {
+		if !tx.IsRechargeToSideChainTx() ||
+			tx.PayloadVersion < types.RechargeToSideChainPayloadVersion1 {
+			continue
+		}
+		payload, ok := tx.Payload.(*types.PayloadRechargeToSideChain)
+		if !ok {
+			continue
+		}
+		deposit := &RechargedDeposit{
+			MainChainTxHash:     payload.MainChainTransactionHash,
+			BestMainChainHeight: bestHeight,
+		}
+		if err := b.db.PersistRechargedDeposit(deposit); err != nil {
+			log.Errorf("index recharged deposit %s failed, %s",
+				deposit.MainChainTxHash, err)
+		}
+	}
+}
+
+// indexStoredDeposits indexes the deposits of the recharge transactions in the
+// blocks stored before the deposit index was introduced. Their best main chain
+// heights are unknown without the SPV service, so they are indexed at
+// unknownMainChainHeight to be checked by the next rollback of the main chain.
+// It runs once when the block chain is created, the blocks connected later are
+// indexed by indexRechargedDeposits.
+func (s *ChainStore) indexStoredDeposits() error {
+	doneKey := []byte{byte(SYS_DepositIndex)}
+	if _, err := s.Get(doneKey); err == nil {
+		return nil
+	}
+
+	log.Info("indexing the recharged deposits of the stored blocks")
+	batch := s.NewBatch()
+	for height := uint32(0); height <= s.currentBlockHeight; height++ {
+		hash, err := s.GetBlockHash(height)
+		if err != nil {
+			return err
+		}
+		block, err := s.GetBlock(hash)
+		if err != nil {
+			return err
+		}
+		for _, tx := range block.Transactions {
+			if !tx.IsRechargeToSideChainTx() ||
+				tx.PayloadVersion < types.RechargeToSideChainPayloadVersion1 {
+				continue
+			}
+			payload, ok := tx.Payload.(*types.PayloadRechargeToSideChain)
+			if !ok {
+				continue
+			}
+			deposit := &RechargedDeposit{
+				MainChainTxHash:     payload.MainChainTransactionHash,
+				BestMainChainHeight: unknownMainChainHeight,
+			}
+			err := batch.Put(deposit.key(), []byte{byte(ValueExist)})
+			if err != nil {
+				return err
+			}
+		}
+		// Commit periodically to bound the size of the batch, the indexed
+		// deposits are put again if the indexing is interrupted.
+		if height%withdrawalIndexBatchBlocks == 0 {
+			if err := batch.Commit(); err != nil {
+				return err
+			}
+			batch = s.NewBatch()
+		}
+	}
+	if err := batch.Put(doneKey, []byte{byte(ValueExist)}); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// OnMainChainRollback is the rollback handler to register to the SPV service.
+// It flags the deposits recharged in the block chain whose main chain
+// transactions were rolled back, and notifies them by ETDepositOrphaned. Only
+// the deposits recharged when the main chain was at or above the height are
+// checked, as the ones below it can not be at the rolled back heights. The
+// withdrawals included in the main chain transactions rolled back are marked
+// as pending again. The deposits indexed at unknownMainChainHeight which are
+// still in the main chain are indexed again at its best height.
+func (b *BlockChain) OnMainChainRollback(height uint32) {
+	if b.cfg.Validator == nil || b.cfg.Validator.spvService == nil {
+		return
+	}
+	b.revertWithdrawals(height)
+
+	deposits, err := b.db.GetRechargedDeposits(height)
+	if err != nil {
+		log.Errorf("get recharged deposits failed, %s", err)
+		return
+	}
+	orphans, err := b.db.GetOrphanedDeposits()
+	if err != nil {
+		log.Errorf("get orphaned deposits failed, %s", err)
+		return
+	}
+	flagged := make(map[common.Uint256]struct{}, len(orphans))
+	for _, orphan := range orphans {
+		flagged[orphan.MainChainTxHash] = struct{}{}
+	}
+
+	for _, recharged := range deposits {
+		hash := recharged.MainChainTxHash
+		// The recharge transaction was disconnected from the block chain.
+		if !b.db.IsDuplicateMainchainTx(hash) {
+			if err := b.db.DeleteRechargedDeposit(recharged); err != nil {
+				log.Errorf("delete recharged deposit %s failed, %s",
+					hash, err)
+			}
+			continue
+		}
+		if _, ok := flagged[hash]; ok {
+			continue
+		}
+		_, err := b.cfg.Validator.spvService.GetTransaction(&hash)
+		if err == nil {
+			if recharged.BestMainChainHeight == unknownMainChainHeight {
+				b.reindexRechargedDeposit(recharged)
+			}
+			continue
+		}
+
+		deposit := &OrphanedDeposit{
+			MainChainTxHash: hash,
+			RollbackHeight:  height,
+		}
+		if err := b.db.PersistOrphanedDeposit(deposit); err != nil {
+			log.Errorf("persist orphaned deposit %s failed, %s", hash, err)
+			continue
+		}
+		log.Warnf("main chain tx %s of deposit lost at main chain height %d",
+			hash, height)
+		events.Notify(events.ETDepositOrphaned, deposit)
+	}
+}
+
+// reindexRechargedDeposit indexes the deposit of the stored blocks again at
+// the best main chain height, which is not below the main chain height of the
+// deposit transaction found in the main chain.
+func (b *BlockChain) reindexRechargedDeposit(deposit *RechargedDeposit) {
+	reindexed := &RechargedDeposit{
+		MainChainTxHash:     deposit.MainChainTxHash,
+		BestMainChainHeight: b.cfg.Validator.spvService.BestHeight(),
+	}
+	if err := b.db.PersistRechargedDeposit(reindexed); err != nil {
+		log.Errorf("index recharged deposit %s failed, %s",
+			deposit.MainChainTxHash, err)
+		return
+	}
+	if err := b.db.DeleteRechargedDeposit(deposit); err != nil {
+		log.Errorf("delete recharged deposit %s failed, %s",
+			deposit.MainChainTxHash, err)
+	}
+}
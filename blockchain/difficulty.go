@@ -5,6 +5,8 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/elastos/Elastos.ELA.SideChain/config"
+
 	"github.com/elastos/Elastos.ELA/common"
 )
 
@@ -14,6 +16,15 @@ func (b *BlockChain) CalcCurrentDifficulty(currentBits uint32) string {
 	return new(big.Int).Div(targetGenesisBlockBig, targetCurrentBig).String()
 }
 
+// DifficultyCalculator calculates the proof of work difficulty required for
+// the block after prevNode.
+type DifficultyCalculator interface {
+	CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTime time.Time) (uint32, error)
+}
+
+// CalcNextRequiredDifficulty calculates the required difficulty for the block
+// after the passed previous block node, using the difficulty algorithm active
+// at the new block height.
 func (b *BlockChain) CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTime time.Time) (uint32, error) {
 	// 1. Genesis block.
 	// 2. when we want to generate block instantly, don't change difficulty
@@ -21,15 +32,56 @@ func (b *BlockChain) CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTim
 		return uint32(b.chainParams.PowLimitBits), nil
 	}
 
+	return b.difficultyCalculator(prevNode).
+		CalcNextRequiredDifficulty(prevNode, newBlockTime)
+}
+
+// difficultyCalculator returns the difficulty calculator used for the block
+// after prevNode.
+func (b *BlockChain) difficultyCalculator(prevNode *BlockNode) DifficultyCalculator {
+	if b.cfg.DifficultyCalculator != nil {
+		return b.cfg.DifficultyCalculator
+	}
+	if b.chainParams.LWMAAveragingWindow > 0 &&
+		b.IsDeploymentActiveAfter(config.DeploymentLWMA, prevNode) {
+		return b.lwmaCalculator
+	}
+	return b.retargetCalculator
+}
+
+// retargetCalculator is the Bitcoin style difficulty algorithm, which
+// retargets once every blocksPerRetarget blocks.
+type retargetCalculator struct {
+	params              *config.Params
+	minRetargetTimespan int64  // target timespan / adjustment factor
+	maxRetargetTimespan int64  // target timespan * adjustment factor
+	blocksPerRetarget   uint32 // target timespan / target time per block
+}
+
+// NewRetargetCalculator creates the Bitcoin style difficulty calculator which
+// retargets every TargetTimespan worth of blocks.
+func NewRetargetCalculator(params *config.Params) DifficultyCalculator {
+	targetTimespan := int64(params.TargetTimespan / time.Second)
+	targetTimePerBlock := int64(params.TargetTimePerBlock / time.Second)
+	adjustmentFactor := params.AdjustmentFactor
+	return &retargetCalculator{
+		params:              params,
+		minRetargetTimespan: targetTimespan / adjustmentFactor,
+		maxRetargetTimespan: targetTimespan * adjustmentFactor,
+		blocksPerRetarget:   uint32(targetTimespan / targetTimePerBlock),
+	}
+}
+
+func (c *retargetCalculator) CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTime time.Time) (uint32, error) {
 	// Return the previous block's difficulty requirements if this block
 	// is not at a difficulty retarget interval.
-	if (prevNode.Height+1)%b.blocksPerRetarget != 0 {
+	if (prevNode.Height+1)%c.blocksPerRetarget != 0 {
 		return prevNode.Bits, nil
 	}
 
 	// Get the block node at the previous retarget (targetTimespan days
 	// worth of blocks).
-	height := prevNode.Height - b.blocksPerRetarget + 1
+	height := prevNode.Height - c.blocksPerRetarget + 1
 	if height < 0 || height > prevNode.Height {
 		return 0, errors.New("unable to obtain previous retarget block")
 	}
@@ -38,14 +90,17 @@ func (b *BlockChain) CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTim
 	for ; firstNode != nil && firstNode.Height != height; firstNode = firstNode.Parent {
 		// Intentionally left blank
 	}
+	if firstNode == nil {
+		return 0, errors.New("unable to obtain previous retarget block")
+	}
 
 	// Limit the amount of adjustment that can occur to the previous difficulty.
-	actualTimespan := int64(prevNode.Timestamp - firstNode.Timestamp)
+	actualTimespan := int64(prevNode.Timestamp) - int64(firstNode.Timestamp)
 	adjustedTimespan := actualTimespan
-	if actualTimespan < b.minRetargetTimespan {
-		adjustedTimespan = b.minRetargetTimespan
-	} else if actualTimespan > b.maxRetargetTimespan {
-		adjustedTimespan = b.maxRetargetTimespan
+	if actualTimespan < c.minRetargetTimespan {
+		adjustedTimespan = c.minRetargetTimespan
+	} else if actualTimespan > c.maxRetargetTimespan {
+		adjustedTimespan = c.maxRetargetTimespan
 	}
 
 	// Calculate new target difficulty as:
@@ -55,12 +110,12 @@ func (b *BlockChain) CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTim
 	// result.
 	oldTarget := CompactToBig(prevNode.Bits)
 	newTarget := new(big.Int).Mul(oldTarget, big.NewInt(adjustedTimespan))
-	targetTimeSpan := int64(b.chainParams.TargetTimespan / time.Second)
+	targetTimeSpan := int64(c.params.TargetTimespan / time.Second)
 	newTarget.Div(newTarget, big.NewInt(targetTimeSpan))
 
 	// Limit new value to the proof of work limit.
-	if newTarget.Cmp(b.chainParams.PowLimit) > 0 {
-		newTarget.Set(b.chainParams.PowLimit)
+	if newTarget.Cmp(c.params.PowLimit) > 0 {
+		newTarget.Set(c.params.PowLimit)
 	}
 
 	// Log new target difficulty and return it.  The new target logging is
@@ -74,7 +129,84 @@ func (b *BlockChain) CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTim
 	log.Debugf("Actual timespan %v, adjusted timespan %v, target timespan %v",
 		time.Duration(actualTimespan)*time.Second,
 		time.Duration(adjustedTimespan)*time.Second,
-		b.chainParams.TargetTimespan)
+		c.params.TargetTimespan)
+
+	return newTargetBits, nil
+}
+
+// lwmaCalculator is the linearly weighted moving average difficulty
+// algorithm (LWMA-1), which retargets on every block by weighting the recent
+// solve times heavier than the older ones.
+type lwmaCalculator struct {
+	params *config.Params
+}
+
+// NewLWMACalculator creates the linearly weighted moving average difficulty
+// calculator averaging over the last LWMAAveragingWindow blocks.
+func NewLWMACalculator(params *config.Params) DifficultyCalculator {
+	return &lwmaCalculator{params: params}
+}
+
+func (c *lwmaCalculator) CalcNextRequiredDifficulty(prevNode *BlockNode, newBlockTime time.Time) (uint32, error) {
+	window := int64(c.params.LWMAAveragingWindow)
+	targetTimePerBlock := int64(c.params.TargetTimePerBlock / time.Second)
+
+	// Keep the previous difficulty until there are enough blocks to fill
+	// the averaging window, the block before the window is needed as well
+	// to get the solve time of the first block.
+	if window == 0 || int64(prevNode.Height) < window {
+		return prevNode.Bits, nil
+	}
+
+	// Collect the averaging window from the oldest block to the newest.
+	nodes := make([]*BlockNode, window+1)
+	node := prevNode
+	for i := window; i >= 0; i-- {
+		if node == nil {
+			return 0, errors.New("unable to obtain block in averaging window")
+		}
+		nodes[i] = node
+		node = node.Parent
+	}
+
+	// Solve times are limited to [1, 6*T] with T the target time per block,
+	// out of order timestamps are treated as one second after the previous
+	// one, so a miner can not lower the difficulty by faking timestamps.
+	maxSolveTime := 6 * targetTimePerBlock
+	sumTargets := new(big.Int)
+	weightedSolveTimes := int64(0)
+	prevTimestamp := int64(nodes[0].Timestamp)
+	for j := int64(1); j <= window; j++ {
+		timestamp := int64(nodes[j].Timestamp)
+		if timestamp <= prevTimestamp {
+			timestamp = prevTimestamp + 1
+		}
+		solveTime := timestamp - prevTimestamp
+		if solveTime > maxSolveTime {
+			solveTime = maxSolveTime
+		}
+		prevTimestamp = timestamp
+
+		weightedSolveTimes += solveTime * j
+		sumTargets.Add(sumTargets, CompactToBig(nodes[j].Bits))
+	}
+
+	// Calculate new target difficulty as:
+	//  averageTarget * weightedSolveTimes / k
+	// where k = N*(N+1)*T/2 is the weighted solve times if every block
+	// had been found exactly on target.
+	k := window * (window + 1) * targetTimePerBlock / 2
+	newTarget := new(big.Int).Mul(sumTargets, big.NewInt(weightedSolveTimes))
+	newTarget.Div(newTarget, big.NewInt(window*k))
+
+	// Limit new value to the proof of work limit.
+	if newTarget.Cmp(c.params.PowLimit) > 0 {
+		newTarget.Set(c.params.PowLimit)
+	}
+
+	newTargetBits := BigToCompact(newTarget)
+	log.Debugf("LWMA difficulty at block height %d, old target %08x,"+
+		" new target %08x", prevNode.Height+1, prevNode.Bits, newTargetBits)
 
 	return newTargetBits, nil
 }
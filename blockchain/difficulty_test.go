@@ -0,0 +1,164 @@
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.SideChain/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testWindow     = 10
+	testBlockTime  = 120
+	testBits       = uint32(0x1d00ffff)
+	testStartStamp = uint32(1600000000)
+)
+
+func newDifficultyTestParams() *config.Params {
+	return &config.Params{
+		PowLimit:            CompactToBig(0x1f0008ff),
+		PowLimitBits:        0x1f0008ff,
+		TargetTimespan:      24 * time.Hour,
+		TargetTimePerBlock:  testBlockTime * time.Second,
+		AdjustmentFactor:    4,
+		LWMAStartHeight:     1000,
+		LWMAAveragingWindow: testWindow,
+	}
+}
+
+// newTestNodes creates a chain of block nodes starting at startHeight, the
+// first node is stamped with testStartStamp and each following node is
+// stamped solveTimes[i] seconds after the previous one.
+func newTestNodes(startHeight uint32, bits uint32, solveTimes []int64) *BlockNode {
+	node := &BlockNode{
+		Height:    startHeight,
+		Bits:      bits,
+		Timestamp: testStartStamp,
+	}
+	for _, solveTime := range solveTimes {
+		node = &BlockNode{
+			Height:    node.Height + 1,
+			Bits:      bits,
+			Timestamp: uint32(int64(node.Timestamp) + solveTime),
+			Parent:    node,
+		}
+	}
+	return node
+}
+
+func repeatSolveTime(solveTime int64, count int) []int64 {
+	solveTimes := make([]int64, count)
+	for i := range solveTimes {
+		solveTimes[i] = solveTime
+	}
+	return solveTimes
+}
+
+func TestLWMACalculator_CalcNextRequiredDifficulty(t *testing.T) {
+	calculator := NewLWMACalculator(newDifficultyTestParams())
+
+	tests := []struct {
+		name       string
+		bits       uint32
+		solveTimes []int64
+		expected   uint32
+	}{
+		{
+			name:       "on target",
+			bits:       testBits,
+			solveTimes: repeatSolveTime(testBlockTime, testWindow),
+			expected:   0x1d00ffff,
+		},
+		{
+			name:       "twice as fast",
+			bits:       testBits,
+			solveTimes: repeatSolveTime(testBlockTime/2, testWindow),
+			expected:   0x1c7fff80,
+		},
+		{
+			name:       "solve time limited to six times target",
+			bits:       testBits,
+			solveTimes: repeatSolveTime(3600, testWindow),
+			expected:   0x1d05fffa,
+		},
+		{
+			name: "out of order timestamp",
+			bits: testBits,
+			solveTimes: []int64{testBlockTime, testBlockTime, testBlockTime,
+				testBlockTime, testBlockTime, -600, testBlockTime,
+				testBlockTime, testBlockTime, testBlockTime},
+			expected: 0x1c475e5d,
+		},
+		{
+			name:       "varying solve times",
+			bits:       testBits,
+			solveTimes: []int64{60, 90, 120, 150, 180, 200, 240, 30, 10, 300},
+			expected:   0x1d014252,
+		},
+		{
+			name:       "limited to pow limit",
+			bits:       0x1f0008ff,
+			solveTimes: repeatSolveTime(3600, testWindow),
+			expected:   0x1e08ff00,
+		},
+	}
+
+	for _, test := range tests {
+		prevNode := newTestNodes(2000, test.bits, test.solveTimes)
+		bits, err := calculator.CalcNextRequiredDifficulty(prevNode, time.Now())
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, bits, test.name)
+	}
+
+	// Not enough blocks to fill the averaging window.
+	prevNode := newTestNodes(0, testBits, repeatSolveTime(1, testWindow-1))
+	bits, err := calculator.CalcNextRequiredDifficulty(prevNode, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, testBits, bits)
+
+	// Missing block in the averaging window.
+	prevNode = newTestNodes(2000, testBits, repeatSolveTime(1, testWindow-1))
+	_, err = calculator.CalcNextRequiredDifficulty(prevNode, time.Now())
+	assert.Error(t, err)
+}
+
+func TestBlockChain_CalcNextRequiredDifficulty(t *testing.T) {
+	params := newDifficultyTestParams()
+	chain := &BlockChain{
+		cfg:                &Config{},
+		chainParams:        params,
+		retargetCalculator: NewRetargetCalculator(params),
+		lwmaCalculator:     NewLWMACalculator(params),
+	}
+	fastSolveTimes := repeatSolveTime(testBlockTime/2, testWindow)
+
+	// Before LWMAStartHeight the difficulty is only changed at the retarget
+	// interval.
+	prevNode := newTestNodes(params.LWMAStartHeight-testWindow-2,
+		testBits, fastSolveTimes)
+	bits, err := chain.CalcNextRequiredDifficulty(prevNode, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, testBits, bits)
+
+	// From LWMAStartHeight on the difficulty is changed every block.
+	prevNode = newTestNodes(params.LWMAStartHeight-testWindow-1,
+		testBits, fastSolveTimes)
+	bits, err = chain.CalcNextRequiredDifficulty(prevNode, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x1c7fff80), bits)
+
+	// LWMA is disabled by a zero averaging window.
+	params.LWMAAveragingWindow = 0
+	bits, err = chain.CalcNextRequiredDifficulty(prevNode, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, testBits, bits)
+
+	// The calculator set in config overrides the chain parameters.
+	params.LWMAAveragingWindow = testWindow
+	chain.cfg.DifficultyCalculator = NewRetargetCalculator(params)
+	bits, err = chain.CalcNextRequiredDifficulty(prevNode, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, testBits, bits)
+}
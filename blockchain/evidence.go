@@ -0,0 +1,200 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.SideChain/events"
+	"github.com/elastos/Elastos.ELA.SideChain/interfaces"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+)
+
+// PersistIllegalEvidence puts the illegal evidence into the store, the
+// evidences are indexed by height.
+func (s *ChainStore) PersistIllegalEvidence(evidence *payload.SidechainIllegalData) error {
+	value := new(bytes.Buffer)
+	if err := evidence.Serialize(value, payload.SidechainIllegalDataVersion); err != nil {
+		return err
+	}
+	key := new(bytes.Buffer)
+	key.WriteByte(byte(IX_Illegal_Data))
+	if err := common.WriteUint32(key, evidence.Height); err != nil {
+		return err
+	}
+	hash := evidence.Hash()
+	key.Write(hash.Bytes())
+	return s.Put(key.Bytes(), value.Bytes())
+}
+
+// GetIllegalEvidences returns the illegal evidences of the height.
+func (s *ChainStore) GetIllegalEvidences(height uint32) ([]*payload.SidechainIllegalData, error) {
+	prefix := new(bytes.Buffer)
+	prefix.WriteByte(byte(IX_Illegal_Data))
+	if err := common.WriteUint32(prefix, height); err != nil {
+		return nil, err
+	}
+
+	var evidences []*payload.SidechainIllegalData
+	iter := s.NewIterator(prefix.Bytes())
+	defer iter.Release()
+	for iter.Next() {
+		evidence := new(payload.SidechainIllegalData)
+		err := evidence.Deserialize(bytes.NewReader(iter.Value()),
+			payload.SidechainIllegalDataVersion)
+		if err != nil {
+			return nil, err
+		}
+		evidences = append(evidences, evidence)
+	}
+	return evidences, nil
+}
+
+// newIllegalEvidence creates the evidence of the signer signing the blocks,
+// the block hashes are sorted so the evidence is the same regardless of the
+// order the blocks are received.
+func newIllegalEvidence(height uint32, signer []byte, hash,
+	compareHash common.Uint256, genesisAddress string) *payload.SidechainIllegalData {
+	if bytes.Compare(hash[:], compareHash[:]) > 0 {
+		hash, compareHash = compareHash, hash
+	}
+	return &payload.SidechainIllegalData{
+		IllegalType:         payload.SidechainIllegalProposal,
+		Height:              height,
+		IllegalSigner:       signer,
+		Evidence:            payload.SidechainIllegalEvidence{DataHash: hash},
+		CompareEvidence:     payload.SidechainIllegalEvidence{DataHash: compareHash},
+		GenesisBlockAddress: genesisAddress,
+	}
+}
+
+// getSigner returns the public key of the arbiter signed the side chain pow
+// transaction in the side aux pow of the header.
+func (b *BlockChain) getSigner(header interfaces.Header) ([]byte, error) {
+	if b.cfg.Validator == nil || b.cfg.Validator.spvService == nil {
+		return nil, errors.New("SPV service not available")
+	}
+	sideAuxPow := header.GetAuxPow()
+	return b.cfg.Validator.spvService.GetSideChainPowSigner(
+		sideAuxPow.MainBlockHeader.Height, &sideAuxPow.SideAuxBlockTx)
+}
+
+// getHeaderOfHeight returns the header of the block in the main chain or the
+// side chain cache, and checks it is of the height.
+func (b *BlockChain) getHeaderOfHeight(hash common.Uint256, height uint32) (interfaces.Header, error) {
+	var header interfaces.Header
+	if block, ok := b.BlockCache[hash]; ok {
+		header = block.Header
+	} else {
+		var err error
+		header, err = b.db.GetHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if header.GetHeight() != height {
+		return nil, errors.New("block height not matched")
+	}
+	return header, nil
+}
+
+// checkIllegalBlock compares the signer of the block accepted with the ones
+// of the other blocks of the same height in the main chain and the side chain
+// cache, and persists and notifies the evidence if they are the same.
+func (b *BlockChain) checkIllegalBlock(block *types.Block) {
+	if b.cfg.Validator == nil || b.cfg.Validator.spvService == nil {
+		return
+	}
+
+	height := block.GetHeight()
+	hash := block.Hash()
+	var others []common.Uint256
+	if mainHash, err := b.db.GetBlockHash(height); err == nil &&
+		!mainHash.IsEqual(hash) {
+		others = append(others, mainHash)
+	}
+	for otherHash, other := range b.BlockCache {
+		if other.GetHeight() == height && !otherHash.IsEqual(hash) {
+			others = append(others, otherHash)
+		}
+	}
+	if len(others) == 0 {
+		return
+	}
+
+	signer, err := b.getSigner(block.Header)
+	if err != nil {
+		return
+	}
+	for _, otherHash := range others {
+		header, err := b.getHeaderOfHeight(otherHash, height)
+		if err != nil {
+			continue
+		}
+		otherSigner, err := b.getSigner(header)
+		if err != nil || !bytes.Equal(signer, otherSigner) {
+			continue
+		}
+
+		evidence := newIllegalEvidence(height, signer, hash, otherHash,
+			b.cfg.Validator.spvService.GenesisAddress())
+		if err := b.db.PersistIllegalEvidence(evidence); err != nil {
+			log.Errorf("persist illegal evidence of height %d failed, %s",
+				height, err)
+			continue
+		}
+		log.Warnf("arbiter %s signed blocks %s and %s of height %d",
+			common.BytesToHexString(signer), hash, otherHash, height)
+		events.Notify(events.ETIllegalEvidence, evidence)
+	}
+}
+
+// GetIllegalEvidences returns the illegal evidences of the height found by
+// the block chain.
+func (b *BlockChain) GetIllegalEvidences(height uint32) ([]*payload.SidechainIllegalData, error) {
+	return b.db.GetIllegalEvidences(height)
+}
+
+// CheckIllegalEvidence checks the evidence is found by the block chain, or
+// the blocks of the evidence are known and signed by the illegal signer.
+func (b *BlockChain) CheckIllegalEvidence(evidence *payload.SidechainIllegalData) error {
+	if evidence.IllegalType != payload.SidechainIllegalProposal {
+		return errors.New("invalid illegal type")
+	}
+	hash := evidence.Evidence.DataHash
+	compareHash := evidence.CompareEvidence.DataHash
+	if hash.IsEqual(compareHash) {
+		return errors.New("evidence and compare evidence are the same")
+	}
+
+	expected := newIllegalEvidence(evidence.Height, evidence.IllegalSigner,
+		hash, compareHash, evidence.GenesisBlockAddress)
+	evidences, err := b.db.GetIllegalEvidences(evidence.Height)
+	if err != nil {
+		return err
+	}
+	for _, e := range evidences {
+		if e.Hash().IsEqual(expected.Hash()) {
+			return nil
+		}
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, h := range []common.Uint256{hash, compareHash} {
+		header, err := b.getHeaderOfHeight(h, evidence.Height)
+		if err != nil {
+			return err
+		}
+		signer, err := b.getSigner(header)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(signer, evidence.IllegalSigner) {
+			return errors.New("illegal signer not matched")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// BlockChain provides the chain state to the VM chain interop services.
+var _ interfaces.IChain = (*BlockChain)(nil)
+
+// GetBlockTimestamp returns the timestamp of the best chain block at the
+// height.
+func (b *BlockChain) GetBlockTimestamp(height uint32) (uint32, error) {
+	hash, err := b.db.GetBlockHash(height)
+	if err != nil {
+		return 0, err
+	}
+	header, err := b.db.GetHeader(hash)
+	if err != nil {
+		return 0, err
+	}
+	return header.GetTimeStamp(), nil
+}
+
+// GetTxInputs returns the serialized inputs of the transaction container.
+func (b *BlockChain) GetTxInputs(container interfaces.IDataContainer) ([][]byte, error) {
+	tx, err := containerTransaction(container)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]common.Serializable, 0, len(tx.Inputs))
+	for _, input := range tx.Inputs {
+		items = append(items, input)
+	}
+	return serializeItems(items)
+}
+
+// GetTxOutputs returns the serialized outputs of the transaction container.
+func (b *BlockChain) GetTxOutputs(container interfaces.IDataContainer) ([][]byte, error) {
+	tx, err := containerTransaction(container)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]common.Serializable, 0, len(tx.Outputs))
+	for _, output := range tx.Outputs {
+		items = append(items, output)
+	}
+	return serializeItems(items)
+}
+
+// GetTxAttributes returns the serialized attributes of the transaction
+// container.
+func (b *BlockChain) GetTxAttributes(container interfaces.IDataContainer) ([][]byte, error) {
+	tx, err := containerTransaction(container)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]common.Serializable, 0, len(tx.Attributes))
+	for _, attr := range tx.Attributes {
+		items = append(items, attr)
+	}
+	return serializeItems(items)
+}
+
+func containerTransaction(container interfaces.IDataContainer) (*types.Transaction, error) {
+	tx, ok := container.(*types.Transaction)
+	if !ok || tx == nil {
+		return nil, errors.New("data container is not a transaction")
+	}
+	return tx, nil
+}
+
+func serializeItems(items []common.Serializable) ([][]byte, error) {
+	result := make([][]byte, 0, len(items))
+	for _, item := range items {
+		buf := new(bytes.Buffer)
+		if err := item.Serialize(buf); err != nil {
+			return nil, err
+		}
+		result = append(result, buf.Bytes())
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package blockchain
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// checkTransactionSignatures verifies the signatures of the transactions in
+// the block concurrently by a pool of workers. If more than one transaction
+// is invalid, the error of the first one in block order is returned, so the
+// result does not depend on the scheduling of the workers.
+//
+// The verified transactions are recorded until clearVerifiedSignatures is
+// called, so they are not verified again when the block is connected.
+func (b *BlockChain) checkTransactionSignatures(block *types.Block) error {
+	// The coinbase transaction has no signature.
+	txs := block.Transactions
+	if len(txs) <= 1 {
+		return nil
+	}
+	txs = txs[1:]
+
+	workers := runtime.NumCPU()
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+
+	errs := make([]error, len(txs))
+	indexes := make(chan int, len(txs))
+	for i := range txs {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = b.cfg.CheckTxSignature(txs[i],
+					block.GetHeight(), block.GetMainChainHeight())
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("transaction %s signature check failed: %s",
+				common.ToReversedString(txs[i].Hash()), err)
+		}
+	}
+
+	b.verifiedTxsLock.Lock()
+	for _, tx := range txs {
+		b.verifiedTxs[tx.Hash()] = tx
+	}
+	b.verifiedTxsLock.Unlock()
+
+	return nil
+}
+
+// clearVerifiedSignatures forgets the transactions recorded by
+// checkTransactionSignatures.
+func (b *BlockChain) clearVerifiedSignatures() {
+	b.verifiedTxsLock.Lock()
+	b.verifiedTxs = make(map[common.Uint256]*types.Transaction)
+	b.verifiedTxsLock.Unlock()
+}
+
+// IsSignatureVerified returns if the signature of the transaction has been
+// verified with the block being accepted. The transaction hash does not
+// cover the programs, so only the very transaction instance in the block is
+// treated as verified.
+func (b *BlockChain) IsSignatureVerified(tx *types.Transaction) bool {
+	b.verifiedTxsLock.RLock()
+	defer b.verifiedTxsLock.RUnlock()
+
+	verified, ok := b.verifiedTxs[tx.Hash()]
+	return ok && verified == tx
+}
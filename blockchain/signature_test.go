@@ -0,0 +1,72 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockChain_CheckTransactionSignatures(t *testing.T) {
+	block := &types.Block{
+		Header: &types.Header{},
+	}
+	for i := 0; i < 20; i++ {
+		block.Transactions = append(block.Transactions, &types.Transaction{
+			TxType:   types.TransferAsset,
+			Payload:  &types.PayloadTransferAsset{},
+			LockTime: uint32(i),
+		})
+	}
+
+	// Transactions 5, 9 and 17 are invalid, the later ones fail faster.
+	invalid := map[uint32]time.Duration{
+		5:  30 * time.Millisecond,
+		9:  10 * time.Millisecond,
+		17: 0,
+	}
+	chain := &BlockChain{
+		cfg: &Config{
+			CheckTxSignature: func(tx *types.Transaction, height uint32,
+				mainChainHeight uint32) error {
+				if delay, ok := invalid[tx.LockTime]; ok {
+					time.Sleep(delay)
+					return errors.New("invalid signature")
+				}
+				return nil
+			},
+		},
+		verifiedTxs: make(map[common.Uint256]*types.Transaction),
+	}
+
+	// The first invalid transaction in block order is reported.
+	for i := 0; i < 5; i++ {
+		err := chain.checkTransactionSignatures(block)
+		assert.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(),
+			common.ToReversedString(block.Transactions[5].Hash())))
+		assert.False(t, chain.IsSignatureVerified(block.Transactions[1]))
+	}
+
+	// Valid transactions are recorded until cleared.
+	invalid = map[uint32]time.Duration{}
+	assert.NoError(t, chain.checkTransactionSignatures(block))
+	for _, tx := range block.Transactions[1:] {
+		assert.True(t, chain.IsSignatureVerified(tx))
+	}
+
+	// A copy of a verified transaction is not treated as verified, the
+	// programs are not covered by the transaction hash.
+	txCopy := *block.Transactions[1]
+	txCopy.Programs = []*types.Program{{Code: []byte{0xac}}}
+	assert.Equal(t, block.Transactions[1].Hash(), txCopy.Hash())
+	assert.False(t, chain.IsSignatureVerified(&txCopy))
+
+	chain.clearVerifiedSignatures()
+	assert.False(t, chain.IsSignatureVerified(block.Transactions[1]))
+}
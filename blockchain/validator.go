@@ -6,6 +6,8 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/elastos/Elastos.ELA.SideChain/auxpow"
+	"github.com/elastos/Elastos.ELA.SideChain/config"
 	"github.com/elastos/Elastos.ELA.SideChain/interfaces"
 	"github.com/elastos/Elastos.ELA.SideChain/spv"
 	"github.com/elastos/Elastos.ELA.SideChain/types"
@@ -25,15 +27,22 @@ type BlockValidateAction struct {
 
 type Validator struct {
 	chain                 *BlockChain
-	spvService            *spv.Service
+	spvService            spv.MainChain
 	checkSanityFunctions  []*BlockValidateAction
 	checkContextFunctions []*BlockValidateAction
+
+	// auxPowCache caches the side auxpows passed SideAuxPowCheck, and
+	// arbiterCache caches the ones signed by the CRC arbiters in params.
+	auxPowCache  *auxpow.SideAuxPowCache
+	arbiterCache *auxpow.SideAuxPowCache
 }
 
-func NewValidator(chain *BlockChain, spv *spv.Service) *Validator {
+func NewValidator(chain *BlockChain, spv spv.MainChain) *Validator {
 	v := &Validator{
-		chain:      chain,
-		spvService: spv,
+		chain:        chain,
+		spvService:   spv,
+		auxPowCache:  auxpow.NewSideAuxPowCache(auxpow.DefaultCacheSize),
+		arbiterCache: auxpow.NewSideAuxPowCache(auxpow.DefaultCacheSize),
 	}
 	v.RegisterFunc(ValidateFuncNames.CheckHeader, v.checkHeader)
 	v.RegisterFunc(ValidateFuncNames.CheckTransactionsCount, v.checkTransactionsCount)
@@ -140,8 +149,12 @@ func (v *Validator) checkHeader(params ...interface{}) error {
 	if headerSize > int(types.MaxBlockHeaderSize) {
 		return errors.New("[checkHeader] checkHeader header is too big")
 	}
-	if err := header.GetAuxPow().SideAuxPowCheck(header.Hash()); err != nil {
-		return errors.New("[powCheckHeader] block check side AuxPow is failed," + err.Error())
+	sideAuxPow := header.GetAuxPow()
+	if !v.auxPowCache.Contains(sideAuxPow, header.Hash()) {
+		if err := sideAuxPow.SideAuxPowCheck(header.Hash()); err != nil {
+			return errors.New("[powCheckHeader] block check side AuxPow is failed," + err.Error())
+		}
+		v.auxPowCache.Add(sideAuxPow, header.Hash())
 	}
 	if err := v.checkProofOfWork(header, powLimit); err != nil {
 		return errors.New("[powCheckHeader] block check proof is failed," + err.Error())
@@ -165,29 +178,34 @@ func (v *Validator) checkHeader(params ...interface{}) error {
 func (v *Validator) checkHeaderContext(params ...interface{}) error {
 	block := AssertBlock(params[0])
 	header := block.Header
-	height := block.GetHeight()
 
 	headerSize := block.Header.GetHeaderSize()
 	if headerSize > int(types.MaxBlockHeaderSize) {
 		return errors.New("[checkHeader] checkHeader header is too big")
 	}
-	if height > v.chain.chainParams.CheckPowHeaderHeight {
+	prevNode, err := v.chain.GetPrevNodeFromBlock(block)
+	if err != nil {
+		return err
+	}
+	if v.chain.IsDeploymentActiveAfter(config.DeploymentCheckPowHeader, prevNode) {
 		validateHeight := header.GetAuxPow().MainBlockHeader.Height
-		if validateHeight >= v.chain.chainParams.CRClaimDPOSNodeStartHeight {
+		if v.chain.chainParams.GetDeployment(config.DeploymentCRClaimDPOSNode).
+			IsActiveAtHeight(validateHeight) {
 			//if err := v.spvService.CheckCRCArbiterSignatureV1(validateHeight, &header.GetAuxPow().SideAuxBlockTx); err != nil {
 			//	return err
 			//}
-			spvHeader, err := v.spvService.HeaderStore().GetByHeight(validateHeight)
+			bits, err := v.spvService.GetHeaderBits(validateHeight)
 			if err != nil {
 				return err
 			}
-			if spvHeader.Bits() != header.GetAuxPow().MainBlockHeader.Bits {
+			if bits != header.GetAuxPow().MainBlockHeader.Bits {
 				return errors.New("[powCheckHeader] bits not matched")
 			}
-		} else {
+		} else if !v.arbiterCache.Contains(header.GetAuxPow(), header.Hash()) {
 			if err := v.spvService.CheckCRCArbiterSignatureV0(&header.GetAuxPow().SideAuxBlockTx); err != nil {
 				return err
 			}
+			v.arbiterCache.Add(header.GetAuxPow(), header.Hash())
 		}
 	}
 	return nil
@@ -0,0 +1,405 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	ela "github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/outputpayload"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+)
+
+// WithdrawalStatus is the status of a withdrawal transaction of the block
+// chain, which is processed by the WithdrawFromSideChain transactions of the
+// main chain.
+type WithdrawalStatus byte
+
+const (
+	// WithdrawalPending is the status of the withdrawals not included in the
+	// main chain yet.
+	WithdrawalPending WithdrawalStatus = iota
+
+	// WithdrawalIncluded is the status of the withdrawals included in a
+	// WithdrawFromSideChain transaction of the main chain.
+	WithdrawalIncluded
+
+	// WithdrawalFailed is the status of the withdrawals which can not be
+	// processed by the main chain, such as the ones to invalid addresses.
+	WithdrawalFailed
+
+	// WithdrawalUnknown is the status of the withdrawals of the blocks stored
+	// before the withdrawal index, which may be included in the main chain
+	// already. They are marked as included when the SPV service notifies
+	// their WithdrawFromSideChain transactions.
+	WithdrawalUnknown
+)
+
+var withdrawalStatusStrings = map[WithdrawalStatus]string{
+	WithdrawalPending:  "pending",
+	WithdrawalIncluded: "included",
+	WithdrawalFailed:   "failed",
+	WithdrawalUnknown:  "unknown",
+}
+
+// String returns the name of the status.
+func (s WithdrawalStatus) String() string {
+	if str, ok := withdrawalStatusStrings[s]; ok {
+		return str
+	}
+	return "unknown"
+}
+
+// WithdrawalRecord is the status of a withdrawal transaction.
+type WithdrawalRecord struct {
+	// TxID is the hash of the withdrawal transaction.
+	TxID common.Uint256
+
+	// Height is the height of the block containing the transaction.
+	Height uint32
+
+	// Status is the status of the withdrawal.
+	Status WithdrawalStatus
+
+	// MainChainTxHash is the hash of the WithdrawFromSideChain transaction
+	// including the withdrawal.
+	MainChainTxHash common.Uint256
+
+	// MainChainHeight is the height of the main chain block containing the
+	// WithdrawFromSideChain transaction.
+	MainChainHeight uint32
+}
+
+func (r *WithdrawalRecord) key() []byte {
+	key := make([]byte, 1+4+common.UINT256SIZE)
+	key[0] = byte(IX_Withdrawal)
+	// The height is big endian to iterate the withdrawals by height.
+	binary.BigEndian.PutUint32(key[1:], r.Height)
+	copy(key[5:], r.TxID[:])
+	return key
+}
+
+// includedKey returns the key of the included withdrawal in the index by the
+// main chain height.
+func (r *WithdrawalRecord) includedKey() []byte {
+	key := make([]byte, 1+4+4+common.UINT256SIZE)
+	key[0] = byte(IX_Withdrawn_Tx)
+	binary.BigEndian.PutUint32(key[1:], r.MainChainHeight)
+	binary.BigEndian.PutUint32(key[5:], r.Height)
+	copy(key[9:], r.TxID[:])
+	return key
+}
+
+func (r *WithdrawalRecord) serializeValue(w io.Writer) error {
+	return common.WriteElements(w, uint8(r.Status), &r.MainChainTxHash,
+		r.MainChainHeight)
+}
+
+func (r *WithdrawalRecord) deserialize(key []byte, value io.Reader) error {
+	if len(key) != 1+4+common.UINT256SIZE {
+		return io.ErrUnexpectedEOF
+	}
+	r.Height = binary.BigEndian.Uint32(key[1:])
+	copy(r.TxID[:], key[5:])
+
+	var status uint8
+	if err := common.ReadElements(value, &status,
+		&r.MainChainTxHash, &r.MainChainHeight); err != nil {
+		return err
+	}
+	r.Status = WithdrawalStatus(status)
+	return nil
+}
+
+// withdrawalIndexBatchBlocks is the number of blocks whose withdrawals are
+// committed together when indexing the stored blocks.
+const withdrawalIndexBatchBlocks = 1000
+
+// newWithdrawalRecord returns the record of the withdrawal transaction, which
+// is failed if any of the cross chain addresses is invalid.
+func newWithdrawalRecord(txn *types.Transaction, height uint32) *WithdrawalRecord {
+	record := &WithdrawalRecord{
+		TxID:   txn.Hash(),
+		Height: height,
+		Status: WithdrawalPending,
+	}
+	p, ok := txn.Payload.(*types.PayloadTransferCrossChainAsset)
+	if !ok {
+		record.Status = WithdrawalFailed
+		return record
+	}
+	for _, address := range p.CrossChainAddresses {
+		if _, err := common.Uint168FromAddress(address); err != nil {
+			record.Status = WithdrawalFailed
+			break
+		}
+	}
+	return record
+}
+
+// PersistWithdrawal puts the withdrawal record into the batch.
+func (s *ChainStore) PersistWithdrawal(batch database.Batch, record *WithdrawalRecord) error {
+	value := new(bytes.Buffer)
+	if err := record.serializeValue(value); err != nil {
+		return err
+	}
+	return batch.Put(record.key(), value.Bytes())
+}
+
+// RollbackWithdrawal deletes the withdrawal record of the transaction in the
+// block of the height from the batch.
+func (s *ChainStore) RollbackWithdrawal(batch database.Batch, txId common.Uint256, height uint32) error {
+	record := &WithdrawalRecord{TxID: txId, Height: height}
+	if stored, err := s.getWithdrawal(txId, height); err == nil &&
+		stored.Status == WithdrawalIncluded {
+		if err := batch.Delete(stored.includedKey()); err != nil {
+			return err
+		}
+	}
+	return batch.Delete(record.key())
+}
+
+// IncludeWithdrawal marks the withdrawal as included in the
+// WithdrawFromSideChain transaction of the main chain block of the height.
+func (s *ChainStore) IncludeWithdrawal(record *WithdrawalRecord,
+	mainChainTxHash common.Uint256, mainChainHeight uint32) error {
+	batch := s.NewBatch()
+	if record.Status == WithdrawalIncluded {
+		if err := batch.Delete(record.includedKey()); err != nil {
+			return err
+		}
+	}
+	record.Status = WithdrawalIncluded
+	record.MainChainTxHash = mainChainTxHash
+	record.MainChainHeight = mainChainHeight
+	if err := s.PersistWithdrawal(batch, record); err != nil {
+		return err
+	}
+	if err := batch.Put(record.includedKey(),
+		[]byte{byte(ValueExist)}); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// RevertWithdrawal marks the included withdrawal as pending again.
+func (s *ChainStore) RevertWithdrawal(record *WithdrawalRecord) error {
+	batch := s.NewBatch()
+	if err := batch.Delete(record.includedKey()); err != nil {
+		return err
+	}
+	record.Status = WithdrawalPending
+	record.MainChainTxHash = common.Uint256{}
+	record.MainChainHeight = 0
+	if err := s.PersistWithdrawal(batch, record); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// GetIncludedWithdrawals returns the withdrawals included in the main chain
+// blocks at or above the height.
+func (s *ChainStore) GetIncludedWithdrawals(mainChainHeight uint32) ([]*WithdrawalRecord, error) {
+	var records []*WithdrawalRecord
+	iter := s.NewIterator([]byte{byte(IX_Withdrawn_Tx)})
+	defer iter.Release()
+
+	start := WithdrawalRecord{MainChainHeight: mainChainHeight}
+	for ok := iter.Seek(start.includedKey()); ok; ok = iter.Next() {
+		key := iter.Key()
+		if len(key) != 1+4+4+common.UINT256SIZE {
+			return nil, io.ErrUnexpectedEOF
+		}
+		var txId common.Uint256
+		copy(txId[:], key[9:])
+		record, err := s.getWithdrawal(txId, binary.BigEndian.Uint32(key[5:]))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// getWithdrawal returns the withdrawal record of the transaction in the block
+// of the height.
+func (s *ChainStore) getWithdrawal(txId common.Uint256, height uint32) (*WithdrawalRecord, error) {
+	record := &WithdrawalRecord{TxID: txId, Height: height}
+	key := record.key()
+	value, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := record.deserialize(key, bytes.NewReader(value)); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// indexWithdrawals adds the records of the withdrawals in the blocks stored
+// before the withdrawal index was introduced, whose status is unknown unless
+// they are failed. It runs once when the block chain is created, the blocks
+// stored later are indexed by persistTransactions.
+func (s *ChainStore) indexWithdrawals() error {
+	doneKey := []byte{byte(SYS_WithdrawalIndex)}
+	if _, err := s.Get(doneKey); err == nil {
+		return nil
+	}
+
+	log.Info("indexing the withdrawals of the stored blocks")
+	batch := s.NewBatch()
+	for height := uint32(0); height <= s.currentBlockHeight; height++ {
+		hash, err := s.GetBlockHash(height)
+		if err != nil {
+			return err
+		}
+		block, err := s.GetBlock(hash)
+		if err != nil {
+			return err
+		}
+		for _, txn := range block.Transactions {
+			if txn.TxType != types.TransferCrossChainAsset {
+				continue
+			}
+			// The existing records keep their status.
+			record := newWithdrawalRecord(txn, height)
+			if _, err := s.Get(record.key()); err == nil {
+				continue
+			}
+			if record.Status == WithdrawalPending {
+				record.Status = WithdrawalUnknown
+			}
+			if err := s.PersistWithdrawal(batch, record); err != nil {
+				return err
+			}
+		}
+		// Commit periodically to bound the size of the batch, the indexed
+		// records are skipped if the indexing is interrupted.
+		if height%withdrawalIndexBatchBlocks == 0 {
+			if err := batch.Commit(); err != nil {
+				return err
+			}
+			batch = s.NewBatch()
+		}
+	}
+	if err := batch.Put(doneKey, []byte{byte(ValueExist)}); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// GetWithdrawal returns the withdrawal record of the transaction.
+func (s *ChainStore) GetWithdrawal(txId common.Uint256) (*WithdrawalRecord, error) {
+	_, height, err := s.GetTransaction(txId)
+	if err != nil {
+		return nil, err
+	}
+	return s.getWithdrawal(txId, height)
+}
+
+// GetWithdrawals returns the withdrawal records of the blocks from the start
+// height to the end height, which are filtered by the filter if it is not
+// nil. The first offset records are skipped, and at most limit records are
+// returned.
+func (s *ChainStore) GetWithdrawals(startHeight, endHeight uint32,
+	filter func(record *WithdrawalRecord) bool, offset,
+	limit uint32) ([]*WithdrawalRecord, error) {
+	var records []*WithdrawalRecord
+	iter := s.NewIterator([]byte{byte(IX_Withdrawal)})
+	defer iter.Release()
+
+	start := WithdrawalRecord{Height: startHeight}
+	for ok := iter.Seek(start.key()); ok &&
+		uint32(len(records)) < limit; ok = iter.Next() {
+		record := new(WithdrawalRecord)
+		err := record.deserialize(iter.Key(), bytes.NewReader(iter.Value()))
+		if err != nil {
+			return nil, err
+		}
+		if record.Height > endHeight {
+			break
+		}
+		if filter != nil && !filter(record) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// getWithdrawnTxs returns the hashes of the withdrawal transactions of the
+// genesis address included in the WithdrawFromSideChain transaction.
+func getWithdrawnTxs(tx *ela.Transaction, genesisAddress string) []common.Uint256 {
+	if tx.TxType != ela.WithdrawFromSideChain {
+		return nil
+	}
+
+	var hashes []common.Uint256
+	if p, ok := tx.Payload.(*payload.WithdrawFromSideChain); ok &&
+		tx.PayloadVersion < payload.WithdrawFromSideChainVersionV1 {
+		if p.GenesisBlockAddress == genesisAddress {
+			hashes = append(hashes, p.SideChainTransactionHashes...)
+		}
+		return hashes
+	}
+	for _, output := range tx.Outputs {
+		if output.Type != ela.OTWithdrawFromSideChain {
+			continue
+		}
+		op, ok := output.Payload.(*outputpayload.Withdraw)
+		if ok && op.GenesisBlockAddress == genesisAddress {
+			hashes = append(hashes, op.SideChainTransactionHash)
+		}
+	}
+	return hashes
+}
+
+// OnMainChainWithdraw is the withdraw handler to register to the SPV service.
+// It marks the withdrawals included in the WithdrawFromSideChain transaction
+// of the main chain block of the height.
+func (b *BlockChain) OnMainChainWithdraw(tx *ela.Transaction, height uint32) {
+	if b.cfg.Validator == nil || b.cfg.Validator.spvService == nil {
+		return
+	}
+
+	mainChainTxHash := tx.Hash()
+	genesisAddress := b.cfg.Validator.spvService.GenesisAddress()
+	for _, hash := range getWithdrawnTxs(tx, genesisAddress) {
+		record, err := b.db.GetWithdrawal(hash)
+		if err != nil {
+			log.Warnf("withdrawal %s of main chain tx %s not found",
+				hash, mainChainTxHash)
+			continue
+		}
+		err = b.db.IncludeWithdrawal(record, mainChainTxHash, height)
+		if err != nil {
+			log.Errorf("update withdrawal %s failed, %s", hash, err)
+		}
+	}
+}
+
+// revertWithdrawals marks the withdrawals included in the main chain blocks
+// rolled back from the height as pending again. They are marked as included
+// again when the SPV service notifies their WithdrawFromSideChain
+// transactions in the new main chain blocks.
+func (b *BlockChain) revertWithdrawals(height uint32) {
+	records, err := b.db.GetIncludedWithdrawals(height)
+	if err != nil {
+		log.Errorf("get included withdrawals failed, %s", err)
+		return
+	}
+
+	for _, record := range records {
+		if err := b.db.RevertWithdrawal(record); err != nil {
+			log.Errorf("update withdrawal %s failed, %s", record.TxID, err)
+		}
+	}
+}
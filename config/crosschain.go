@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// ExchangeRate defines the exact rational rate converting the main chain
+// amounts to the side chain ones, so all nodes get the same amounts without
+// depending on the rounding of the float multiplication.
+type ExchangeRate struct {
+	Numerator   int64
+	Denominator int64
+}
+
+// IsValid returns if the rate is positive.
+func (r ExchangeRate) IsValid() bool {
+	return r.Numerator > 0 && r.Denominator > 0
+}
+
+// Convert returns the amount multiplied by the rate, which is rounded toward
+// zero.
+func (r ExchangeRate) Convert(amount common.Fixed64) (common.Fixed64, error) {
+	if !r.IsValid() {
+		return 0, errors.New("invalid exchange rate")
+	}
+	result := new(big.Int).Mul(big.NewInt(int64(amount)),
+		big.NewInt(r.Numerator))
+	result.Quo(result, big.NewInt(r.Denominator))
+	if !result.IsInt64() {
+		return 0, errors.New("converted amount overflow")
+	}
+	return common.Fixed64(result.Int64()), nil
+}
+
+// CrossChainAsset defines an asset of the side chain which can be recharged
+// from and withdrawn to the main chain.
+type CrossChainAsset struct {
+	// AssetID is the id of the asset on the side chain.
+	AssetID common.Uint256
+
+	// MainChainAssetID is the id of the asset on the main chain recharged
+	// as the side chain asset.
+	MainChainAssetID common.Uint256
+
+	// ExchangeRate converts the main chain amounts of the asset to the side
+	// chain ones.
+	ExchangeRate ExchangeRate
+
+	// MinCrossChainTxFee defines the minimum fee of the cross chain
+	// transfers of the asset.
+	MinCrossChainTxFee common.Fixed64
+
+	// legacy is true for the ELA asset of Params.ExchangeRate, whose amounts
+	// are converted by multiplying the float legacyRate as before
+	// CrossChainAssetsStartHeight.
+	legacy     bool
+	legacyRate float64
+}
+
+// Convert returns the side chain amount of the main chain amount of the
+// asset.
+func (a *CrossChainAsset) Convert(amount common.Fixed64) (common.Fixed64, error) {
+	if !a.legacy {
+		return a.ExchangeRate.Convert(amount)
+	}
+	if a.legacyRate <= 0 {
+		return 0, errors.New("invalid exchange rate")
+	}
+	return common.Fixed64(float64(amount) * a.legacyRate), nil
+}
+
+// GetCrossChainAssets returns the ELA asset of ExchangeRate and
+// MinCrossChainTxFee, followed by the assets in CrossChainAssets if active,
+// which is if DeploymentCrossChainAssets is active. A declared ELA asset
+// replaces the one of ExchangeRate.
+func (p *Params) GetCrossChainAssets(active bool) []CrossChainAsset {
+	if !active {
+		return []CrossChainAsset{p.legacyCrossChainAsset()}
+	}
+	for _, asset := range p.CrossChainAssets {
+		if asset.AssetID.IsEqual(p.ElaAssetId) {
+			return p.CrossChainAssets
+		}
+	}
+	return append([]CrossChainAsset{p.legacyCrossChainAsset()},
+		p.CrossChainAssets...)
+}
+
+// legacyCrossChainAsset returns the ELA asset of ExchangeRate and
+// MinCrossChainTxFee.
+func (p *Params) legacyCrossChainAsset() CrossChainAsset {
+	return CrossChainAsset{
+		AssetID:            p.ElaAssetId,
+		MainChainAssetID:   p.ElaAssetId,
+		MinCrossChainTxFee: common.Fixed64(p.MinCrossChainTxFee),
+		legacy:             true,
+		legacyRate:         p.ExchangeRate,
+	}
+}
+
+// GetCrossChainAsset returns the cross chain asset of the side chain asset
+// id, or nil if the asset is not cross chain.
+func (p *Params) GetCrossChainAsset(assetID common.Uint256, active bool) *CrossChainAsset {
+	assets := p.GetCrossChainAssets(active)
+	for i := range assets {
+		if assets[i].AssetID.IsEqual(assetID) {
+			return &assets[i]
+		}
+	}
+	return nil
+}
+
+// GetCrossChainAssetByMainChainAsset returns the cross chain asset recharged
+// from the main chain asset id, or nil if the asset is not cross chain.
+func (p *Params) GetCrossChainAssetByMainChainAsset(assetID common.Uint256,
+	active bool) *CrossChainAsset {
+	assets := p.GetCrossChainAssets(active)
+	for i := range assets {
+		if assets[i].MainChainAssetID.IsEqual(assetID) {
+			return &assets[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"math"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRate_Convert(t *testing.T) {
+	rate := ExchangeRate{Numerator: 1, Denominator: 3}
+	amount, err := rate.Convert(100)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Fixed64(33), amount)
+	amount, err = rate.Convert(-100)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Fixed64(-33), amount)
+
+	// The product is not limited to int64.
+	rate = ExchangeRate{Numerator: 3, Denominator: 3}
+	amount, err = rate.Convert(math.MaxInt64)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Fixed64(math.MaxInt64), amount)
+
+	rate = ExchangeRate{Numerator: 2, Denominator: 1}
+	_, err = rate.Convert(math.MaxInt64)
+	assert.Error(t, err)
+	_, err = ExchangeRate{Numerator: 1}.Convert(1)
+	assert.Error(t, err)
+}
+
+func TestCrossChainAsset_Convert(t *testing.T) {
+	// The ELA asset of the float rate is converted as before the cross chain
+	// assets, and the declared ones by their exact rates.
+	params := &Params{ExchangeRate: 0.3}
+	legacy := params.legacyCrossChainAsset()
+	amount, err := legacy.Convert(10)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Fixed64(3), amount)
+
+	exact := CrossChainAsset{ExchangeRate: ExchangeRate{Numerator: 3, Denominator: 10}}
+	amount, err = exact.Convert(10)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Fixed64(3), amount)
+	amount, err = exact.Convert(9)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Fixed64(2), amount)
+
+	params.ExchangeRate = 0
+	legacy = params.legacyCrossChainAsset()
+	_, err = legacy.Convert(10)
+	assert.Error(t, err)
+}
+
+func TestParams_GetCrossChainAssets(t *testing.T) {
+	ela := common.Uint256{1}
+	token := common.Uint256{2}
+	params := &Params{
+		ElaAssetId:         ela,
+		ExchangeRate:       1,
+		MinCrossChainTxFee: 10000,
+		CrossChainAssets: []CrossChainAsset{{
+			AssetID:            token,
+			MainChainAssetID:   ela,
+			ExchangeRate:       ExchangeRate{Numerator: 1, Denominator: 2},
+			MinCrossChainTxFee: 100,
+		}},
+	}
+	legacy := CrossChainAsset{
+		AssetID:            ela,
+		MainChainAssetID:   ela,
+		MinCrossChainTxFee: 10000,
+		legacy:             true,
+		legacyRate:         1,
+	}
+
+	// Only the ELA asset of ExchangeRate and MinCrossChainTxFee before the
+	// cross chain assets are active.
+	assert.Equal(t, []CrossChainAsset{legacy}, params.GetCrossChainAssets(false))
+	assert.Nil(t, params.GetCrossChainAsset(token, false))
+	assert.Equal(t, ela,
+		params.GetCrossChainAssetByMainChainAsset(ela, false).AssetID)
+
+	// Declared assets.
+	assert.Equal(t, 2, len(params.GetCrossChainAssets(true)))
+	assert.Equal(t, ela, params.GetCrossChainAsset(ela, true).AssetID)
+	assert.Equal(t, common.Fixed64(100),
+		params.GetCrossChainAsset(token, true).MinCrossChainTxFee)
+	assert.Equal(t, ela,
+		params.GetCrossChainAssetByMainChainAsset(ela, true).AssetID)
+	assert.Nil(t, params.GetCrossChainAsset(common.Uint256{3}, true))
+	assert.Nil(t, params.GetCrossChainAssetByMainChainAsset(token, true))
+
+	// The declared ELA asset replaces the one of ExchangeRate.
+	params.CrossChainAssets[0].AssetID = ela
+	assert.Equal(t, params.CrossChainAssets, params.GetCrossChainAssets(true))
+	assert.Equal(t, []CrossChainAsset{legacy}, params.GetCrossChainAssets(false))
+}
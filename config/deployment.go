@@ -0,0 +1,230 @@
+package config
+
+import (
+	"math"
+)
+
+const (
+	// DeploymentCheckPowHeader is the name of the rule checking if the
+	// side chain proof of work is coming from the main chain.
+	DeploymentCheckPowHeader = "checkpowheader"
+
+	// DeploymentCRClaimDPOSNode is the name of the rule checking the bits of
+	// the main chain header in the aux pow. It is activated at the main chain
+	// height of CRClaimDPOSNodeStartHeight, so it is never signalled by
+	// version bits.
+	DeploymentCRClaimDPOSNode = "crclaimdposnode"
+
+	// DeploymentNewP2PProtocolVersion is the name of the rule switching to
+	// the new version message of the p2p protocol. It is activated at the
+	// height of NewP2PProtocolVersionHeight, which is used by the p2p server,
+	// so it is never signalled by version bits.
+	DeploymentNewP2PProtocolVersion = "newp2pprotocolversion"
+
+	// DeploymentRewardMinerOnly is the name of the rule rewarding the
+	// miner only in the coinbase transaction.
+	DeploymentRewardMinerOnly = "rewardmineronly"
+
+	// DeploymentLWMA is the name of the rule switching to the linearly
+	// weighted moving average difficulty algorithm.
+	DeploymentLWMA = "lwma"
+
+	// DeploymentTimeLock is the name of the rule enabling the time lock
+	// opcodes and the relative lock of input sequences.
+	DeploymentTimeLock = "timelock"
+
+	// DeploymentHTLC is the name of the rule enabling the RIPEMD160 opcode
+	// and the hash time locked contracts.
+	DeploymentHTLC = "htlc"
+
+	// DeploymentSchnorr is the name of the rule enabling the Schnorr
+	// signature opcodes.
+	DeploymentSchnorr = "schnorr"
+
+	// DeploymentVMLimits is the name of the rule enforcing the limits of the
+	// stacks of the engines executing the transaction programs.
+	DeploymentVMLimits = "vmlimits"
+
+	// DeploymentCrossChainPayloadV1 is the name of the rule accepting only
+	// the known payload versions of the transfer cross chain asset
+	// transactions.
+	DeploymentCrossChainPayloadV1 = "crosschainpayloadv1"
+
+	// DeploymentCrossChainTargetData is the name of the rule accepting the
+	// target data of the cross chain outputs in the version 2 payload of the
+	// transfer cross chain asset transactions.
+	DeploymentCrossChainTargetData = "crosschaintargetdata"
+
+	// DeploymentDepositConfirmations is the name of the rule requiring the
+	// main chain confirmations of the deposits recharged in the blocks.
+	DeploymentDepositConfirmations = "depositconfirmations"
+
+	// DeploymentCrossChainAssets is the name of the rule accepting the
+	// assets of CrossChainAssets in the cross chain transfers.
+	DeploymentCrossChainAssets = "crosschainassets"
+)
+
+// Deployment defines a named consensus rule change, which is either activated
+// at a fixed height, or signalled by miners through a bit of the block header
+// version.
+type Deployment struct {
+	// Name defines the unique name of the deployment.
+	Name string
+
+	// ActivationHeight defines the height where the deployment is active,
+	// it is used only if the deployment is not signalled by version bits.
+	ActivationHeight uint32
+
+	// BitNumber defines the bit of the block header version miners set to
+	// signal the deployment.
+	BitNumber uint8
+
+	// StartTime defines the median block time after which the version bit
+	// signalling starts.
+	StartTime uint32
+
+	// ExpireTime defines the median block time after which the deployment
+	// fails if it has not been locked in yet. Zero means the deployment is
+	// activated at ActivationHeight instead of by version bits.
+	ExpireTime uint32
+}
+
+// IsVersionBits returns if the deployment is signalled by version bits.
+func (d *Deployment) IsVersionBits() bool {
+	return d.ExpireTime != 0
+}
+
+// IsActiveAtHeight returns if the deployment activated at ActivationHeight is
+// active for the block at the height. It is false for the version bits
+// deployments, whose state depends on the blocks of the chain.
+func (d *Deployment) IsActiveAtHeight(height uint32) bool {
+	return !d.IsVersionBits() && height >= d.ActivationHeight
+}
+
+// GetDeployments returns the deployments derived from the activation height
+// fields of the parameters followed by the ones in Deployments. A deployment
+// in Deployments replaces the derived one of the same name, so the rules of
+// the activation height fields can be signalled by version bits instead.
+func (p *Params) GetDeployments() []Deployment {
+	deployments := []Deployment{
+		{
+			Name:             DeploymentCheckPowHeader,
+			ActivationHeight: heightAfter(p.CheckPowHeaderHeight),
+		},
+		{
+			Name:             DeploymentCRClaimDPOSNode,
+			ActivationHeight: p.CRClaimDPOSNodeStartHeight,
+		},
+		{
+			Name:             DeploymentNewP2PProtocolVersion,
+			ActivationHeight: heightOf(p.NewP2PProtocolVersionHeight),
+		},
+	}
+	if len(p.RewardSchedule) == 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentRewardMinerOnly,
+			ActivationHeight: heightAfter(p.RewardMinerOnlyStartHeight),
+		})
+	}
+	if p.LWMAAveragingWindow > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentLWMA,
+			ActivationHeight: p.LWMAStartHeight,
+		})
+	}
+	if p.TimeLockStartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentTimeLock,
+			ActivationHeight: p.TimeLockStartHeight,
+		})
+	}
+	if p.HTLCStartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentHTLC,
+			ActivationHeight: p.HTLCStartHeight,
+		})
+	}
+	if p.SchnorrStartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentSchnorr,
+			ActivationHeight: p.SchnorrStartHeight,
+		})
+	}
+	if p.VMLimitsStartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentVMLimits,
+			ActivationHeight: p.VMLimitsStartHeight,
+		})
+	}
+	if p.CrossChainPayloadV1StartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentCrossChainPayloadV1,
+			ActivationHeight: p.CrossChainPayloadV1StartHeight,
+		})
+	}
+	if p.CrossChainTargetDataStartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentCrossChainTargetData,
+			ActivationHeight: p.CrossChainTargetDataStartHeight,
+		})
+	}
+	if p.DepositConfirmationsStartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentDepositConfirmations,
+			ActivationHeight: p.DepositConfirmationsStartHeight,
+		})
+	}
+	if p.CrossChainAssetsStartHeight > 0 {
+		deployments = append(deployments, Deployment{
+			Name:             DeploymentCrossChainAssets,
+			ActivationHeight: p.CrossChainAssetsStartHeight,
+		})
+	}
+
+	derived := deployments[:0]
+	for _, deployment := range deployments {
+		if !p.isDeclaredDeployment(deployment.Name) {
+			derived = append(derived, deployment)
+		}
+	}
+	return append(derived, p.Deployments...)
+}
+
+// isDeclaredDeployment returns if the named deployment is in Deployments.
+func (p *Params) isDeclaredDeployment(name string) bool {
+	for _, deployment := range p.Deployments {
+		if deployment.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDeployment returns the deployment with the given name, or nil if there
+// is no such deployment.
+func (p *Params) GetDeployment(name string) *Deployment {
+	deployments := p.GetDeployments()
+	for i := range deployments {
+		if deployments[i].Name == name {
+			return &deployments[i]
+		}
+	}
+	return nil
+}
+
+// heightOf returns the height of the 64 bits height, the heights above the
+// max height are never reached.
+func heightOf(height uint64) uint32 {
+	if height > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(height)
+}
+
+// heightAfter returns the height after the given one without overflowing.
+func heightAfter(height uint32) uint32 {
+	if height == math.MaxUint32 {
+		return height
+	}
+	return height + 1
+}
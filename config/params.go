@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
 
 	"github.com/elastos/Elastos.ELA/common"
 )
@@ -78,6 +79,12 @@ type Params struct {
 	// transaction.
 	MinCrossChainTxFee int64
 
+	// CrossChainAssets defines the assets which can be recharged from and
+	// withdrawn to the main chain from CrossChainAssetsStartHeight, in
+	// addition to the ELA asset of ExchangeRate and MinCrossChainTxFee unless
+	// it is one of them.
+	CrossChainAssets []CrossChainAsset
+
 	// CheckPowHeaderHeight defines the height where stating check if pow is coming
 	// from main chain.
 	CheckPowHeaderHeight uint32
@@ -92,6 +99,90 @@ type Params struct {
 	// in coin base transaction.
 	RewardMinerOnlyStartHeight uint32
 
+	// RewardSchedule defines the block subsidy and how the coinbase reward
+	// is split between the miner and other addresses by height ranges.
+	// Foundation and RewardMinerOnlyStartHeight are used instead if it is
+	// not set.
+	RewardSchedule []RewardPeriod
+
+	// LWMAStartHeight defines the height where starting use the linearly
+	// weighted moving average difficulty algorithm, which retargets on every
+	// block instead of every TargetTimespan.
+	LWMAStartHeight uint32
+
+	// LWMAAveragingWindow defines how many previous blocks the linearly
+	// weighted moving average difficulty algorithm looks back, zero means
+	// the algorithm is disabled.
+	LWMAAveragingWindow uint32
+
+	// TimeLockStartHeight defines the height where starting enable the time
+	// lock opcodes and the relative lock of input sequences, zero means they
+	// are disabled.
+	TimeLockStartHeight uint32
+
+	// HTLCStartHeight defines the height where starting enable the RIPEMD160
+	// opcode and the hash time locked contracts, zero means they are
+	// disabled. The contracts also require the time lock opcodes enabled by
+	// TimeLockStartHeight.
+	HTLCStartHeight uint32
+
+	// SchnorrStartHeight defines the height where starting enable the
+	// Schnorr signature opcodes, zero means they are disabled.
+	SchnorrStartHeight uint32
+
+	// VMLimitsStartHeight defines the height where starting enforce VMLimits
+	// on the engines executing the transaction programs, zero means the
+	// limits are not enforced.
+	VMLimitsStartHeight uint32
+
+	// VMLimits defines the limits of the stacks of the engines executing the
+	// transaction programs from VMLimitsStartHeight, vm.DefaultLimits is used
+	// if it is not set.
+	VMLimits *vm.Limits
+
+	// CrossChainPayloadV1StartHeight defines the height where starting
+	// check the payload versions of the transfer cross chain asset
+	// transactions, only the known versions are accepted from it. Zero means
+	// the versions are not checked.
+	CrossChainPayloadV1StartHeight uint32
+
+	// CrossChainTargetDataStartHeight defines the height where starting
+	// accept the version 2 payload of the transfer cross chain asset
+	// transactions, which carries the target data of the cross chain
+	// outputs, zero means it is disabled.
+	CrossChainTargetDataStartHeight uint32
+
+	// DepositConfirmationsStartHeight defines the height where starting
+	// require DepositConfirmations of the deposits recharged by the
+	// transactions in the blocks, zero means it is disabled.
+	DepositConfirmationsStartHeight uint32
+
+	// DepositConfirmations defines the minimum main chain confirmations of
+	// the deposits recharged by the transactions in the blocks from
+	// DepositConfirmationsStartHeight. The main chain transaction being found
+	// by the SPV module is one confirmation.
+	DepositConfirmations uint32
+
+	// CrossChainAssetsStartHeight defines the height where starting accept
+	// the assets of CrossChainAssets, which are converted by their exact
+	// exchange rates. Zero means it is disabled, only the ELA asset is cross
+	// chain and its amounts are multiplied by the float ExchangeRate.
+	CrossChainAssetsStartHeight uint32
+
+	// Deployments defines the soft fork deployments of the network in
+	// addition to the ones derived from the activation height fields above,
+	// a deployment of the same name replaces the derived one.
+	Deployments []Deployment
+
+	// RuleChangeActivationThreshold defines the number of blocks in a
+	// MinerConfirmationWindow which must signal a version bits deployment
+	// for it to be locked in.
+	RuleChangeActivationThreshold uint32
+
+	// MinerConfirmationWindow defines the number of blocks in each window
+	// of the version bits deployment state changes.
+	MinerConfirmationWindow uint32
+
 	// RPCServiceLevel defines level of service provide to client.
 	RPCServiceLevel string
 }
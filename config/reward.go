@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// foundationRewardPercentage is the percentage of the reward the foundation
+// receives before RewardMinerOnlyStartHeight when no reward schedule is set.
+const foundationRewardPercentage = 30
+
+// RewardRecipient defines an address receiving a fixed percentage of the
+// coinbase reward.
+type RewardRecipient struct {
+	// ProgramHash defines the address receiving the reward.
+	ProgramHash common.Uint168
+
+	// Percentage defines the percentage (0-100) of the reward the address
+	// receives.
+	Percentage uint32
+}
+
+// RewardPeriod defines how the coinbase reward is created and split from
+// StartHeight on, until the StartHeight of the next period.
+type RewardPeriod struct {
+	// StartHeight defines the first block height of the period.
+	StartHeight uint32
+
+	// Subsidy defines the amount newly issued in each coinbase of the
+	// period, the reward of a block is the subsidy plus the transaction fees.
+	Subsidy common.Fixed64
+
+	// Recipients defines the addresses receiving a fixed share of the
+	// reward, the miner receives the rest. The sum of the percentages must
+	// not exceed 100.
+	Recipients []RewardRecipient
+}
+
+// Share returns the amount of the given reward the recipient receives.
+func (r *RewardRecipient) Share(reward common.Fixed64) common.Fixed64 {
+	return common.Fixed64(float64(reward) * (float64(r.Percentage) / 100))
+}
+
+// GetRewardPeriod returns the reward period the block at the given height
+// belongs to. If RewardSchedule is not set, the schedule is derived from
+// Foundation and RewardMinerOnlyStartHeight.
+func (p *Params) GetRewardPeriod(height uint32) *RewardPeriod {
+	schedule := p.RewardSchedule
+	if len(schedule) == 0 {
+		schedule = p.defaultRewardSchedule()
+	}
+
+	period := &RewardPeriod{}
+	for i := range schedule {
+		if schedule[i].StartHeight <= height &&
+			schedule[i].StartHeight >= period.StartHeight {
+			period = &schedule[i]
+		}
+	}
+	return period
+}
+
+// CheckRewardSchedule checks the percentages of the recipients of every
+// reward period do not exceed 100 in total, so the share of the miner can not
+// be negative.
+func (p *Params) CheckRewardSchedule() error {
+	for _, period := range p.RewardSchedule {
+		var total uint64
+		for _, recipient := range period.Recipients {
+			total += uint64(recipient.Percentage)
+		}
+		if total > 100 {
+			return fmt.Errorf("reward percentages of period at height %d"+
+				" sum to %d%%, exceeding 100%%", period.StartHeight, total)
+		}
+	}
+	return nil
+}
+
+// GetDefaultRewardPeriod returns the reward period of the schedule derived
+// from Foundation, which rewards the miner only once the rule of
+// DeploymentRewardMinerOnly is active.
+func (p *Params) GetDefaultRewardPeriod(minerOnly bool) *RewardPeriod {
+	schedule := p.defaultRewardSchedule()
+	if minerOnly {
+		return &RewardPeriod{
+			StartHeight: heightAfter(p.RewardMinerOnlyStartHeight),
+		}
+	}
+	return &schedule[0]
+}
+
+// defaultRewardSchedule returns the schedule of rewarding the foundation 30%
+// until RewardMinerOnlyStartHeight and the miner only after that.
+func (p *Params) defaultRewardSchedule() []RewardPeriod {
+	schedule := []RewardPeriod{
+		{
+			StartHeight: 0,
+			Recipients: []RewardRecipient{
+				{
+					ProgramHash: p.Foundation,
+					Percentage:  foundationRewardPercentage,
+				},
+			},
+		},
+	}
+	if p.RewardMinerOnlyStartHeight < math.MaxUint32 {
+		schedule = append(schedule, RewardPeriod{
+			StartHeight: p.RewardMinerOnlyStartHeight + 1,
+		})
+	}
+	return schedule
+}
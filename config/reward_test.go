@@ -0,0 +1,91 @@
+package config
+
+import (
+	"math"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParams_GetRewardPeriod(t *testing.T) {
+	foundation := common.Uint168{0x12, 0x01}
+	params := &Params{
+		Foundation:                 foundation,
+		RewardMinerOnlyStartHeight: 100,
+	}
+
+	// Derived from Foundation and RewardMinerOnlyStartHeight.
+	period := params.GetRewardPeriod(100)
+	assert.Equal(t, 1, len(period.Recipients))
+	assert.Equal(t, foundation, period.Recipients[0].ProgramHash)
+	assert.Equal(t, common.Fixed64(30), period.Recipients[0].Share(100))
+	assert.Equal(t, 0, len(params.GetRewardPeriod(101).Recipients))
+
+	params.RewardMinerOnlyStartHeight = math.MaxUint32
+	assert.Equal(t, 1, len(params.GetRewardPeriod(math.MaxUint32).Recipients))
+
+	// Declared schedule.
+	other := common.Uint168{0x21, 0x02}
+	params.RewardSchedule = []RewardPeriod{
+		{
+			StartHeight: 0,
+			Recipients: []RewardRecipient{
+				{ProgramHash: foundation, Percentage: 30},
+			},
+		},
+		{
+			StartHeight: 50,
+			Subsidy:     1000,
+			Recipients: []RewardRecipient{
+				{ProgramHash: foundation, Percentage: 10},
+				{ProgramHash: other, Percentage: 15},
+			},
+		},
+		{
+			StartHeight: 200,
+		},
+	}
+
+	period = params.GetRewardPeriod(49)
+	assert.Equal(t, uint32(0), period.StartHeight)
+	assert.Equal(t, common.Fixed64(0), period.Subsidy)
+
+	period = params.GetRewardPeriod(50)
+	assert.Equal(t, uint32(50), period.StartHeight)
+	assert.Equal(t, common.Fixed64(1000), period.Subsidy)
+	assert.Equal(t, common.Fixed64(100), period.Recipients[0].Share(1000))
+	assert.Equal(t, common.Fixed64(150), period.Recipients[1].Share(1000))
+
+	period = params.GetRewardPeriod(199)
+	assert.Equal(t, uint32(50), period.StartHeight)
+
+	period = params.GetRewardPeriod(math.MaxUint32)
+	assert.Equal(t, uint32(200), period.StartHeight)
+	assert.Equal(t, 0, len(period.Recipients))
+}
+
+func TestParams_CheckRewardSchedule(t *testing.T) {
+	params := &Params{}
+	assert.NoError(t, params.CheckRewardSchedule())
+
+	params.RewardSchedule = []RewardPeriod{
+		{
+			StartHeight: 0,
+			Recipients: []RewardRecipient{
+				{ProgramHash: common.Uint168{1}, Percentage: 60},
+				{ProgramHash: common.Uint168{2}, Percentage: 40},
+			},
+		},
+	}
+	assert.NoError(t, params.CheckRewardSchedule())
+
+	params.RewardSchedule = append(params.RewardSchedule, RewardPeriod{
+		StartHeight: 100,
+		Recipients: []RewardRecipient{
+			{ProgramHash: common.Uint168{1}, Percentage: 60},
+			{ProgramHash: common.Uint168{2}, Percentage: 41},
+		},
+	})
+	assert.Error(t, params.CheckRewardSchedule())
+}
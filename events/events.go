@@ -30,6 +30,14 @@ const (
 	// ETTransactionAccepted indicates the associated transaction was accepted
 	// into transaction mem pool.
 	ETTransactionAccepted
+
+	// ETDepositOrphaned indicates the main chain transaction of a deposit
+	// recharged in the block chain was rolled back from the main chain.
+	ETDepositOrphaned
+
+	// ETIllegalEvidence indicates an arbiter was found signing different
+	// blocks of the same height.
+	ETIllegalEvidence
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
@@ -39,6 +47,8 @@ var notificationTypeStrings = map[EventType]string{
 	ETBlockConnected:      "ETBlockConnected",
 	ETBlockDisconnected:   "ETBlockDisconnected",
 	ETTransactionAccepted: "ETTransactionAccepted",
+	ETDepositOrphaned:     "ETDepositOrphaned",
+	ETIllegalEvidence:     "ETIllegalEvidence",
 }
 
 // String returns the EventType in human-readable form.
@@ -56,6 +66,8 @@ func (n EventType) String() string {
 // 	- ETBlockConnected:    *types.Block
 // 	- ETBlockDisconnected: *types.Block
 // 	- ETTransactionAccepted: *types.Transaction
+// 	- ETDepositOrphaned: *blockchain.OrphanedDeposit
+// 	- ETIllegalEvidence: *payload.SidechainIllegalData
 type Event struct {
 	Type EventType
 	Data interface{}
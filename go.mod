@@ -5,7 +5,9 @@ go 1.13
 require (
 	github.com/elastos/Elastos.ELA v0.8.2
 	github.com/elastos/Elastos.ELA.SPV v0.0.9
+	github.com/gorilla/websocket v1.4.1
 	github.com/itchyny/base58-go v0.1.0 // indirect
 	github.com/stretchr/testify v1.4.0
 	github.com/syndtr/goleveldb v1.0.0
+	golang.org/x/crypto v0.0.0-20200604202706-70a84ac30bf9
 )
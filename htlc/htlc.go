@@ -0,0 +1,208 @@
+/*
+Package htlc implements the hash time locked contracts used by atomic swaps
+between the side chain and other chains.
+
+The output locked by a contract can be claimed by the receiver with the secret
+whose hash is in the contract, or refunded to the sender after the refund
+height. The VM has no working branches, so the redeem script checks both
+paths and a selector pushed by the parameter chooses the one used:
+
+	claim:  <receiver signature> <secret> 0
+	refund: <sender signature> <any> 1
+
+The refund path requires the lock time of the transaction to be no less than
+the refund height by CHECKLOCKTIMEVERIFY, which requires at least one input of
+the transaction with a sequence other than math.MaxUint16.
+*/
+package htlc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/contract"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// PrefixHTLC is the program hash prefix of the hash time locked contracts.
+const PrefixHTLC contract.PrefixType = 0x1C
+
+const (
+	// SHA256HashLength is the length of the hash of a contract whose secret
+	// is hashed by SHA256.
+	SHA256HashLength = 32
+
+	// RIPEMD160HashLength is the length of the hash of a contract whose
+	// secret is hashed by RIPEMD160.
+	RIPEMD160HashLength = 20
+
+	// publicKeyLength is the length of the compressed public keys.
+	publicKeyLength = 33
+
+	// scriptLength is the length of the redeem script without the hash.
+	scriptLength = 31 + 2*publicKeyLength
+)
+
+// Contract is a hash time locked contract.
+type Contract struct {
+	// Hash is the hash of the secret, SHA256 if it is 32 bytes and
+	// RIPEMD160 if it is 20 bytes.
+	Hash []byte
+
+	// Receiver is the public key which can claim the output with the secret.
+	Receiver *crypto.PublicKey
+
+	// Sender is the public key which can refund the output after
+	// RefundHeight.
+	Sender *crypto.PublicKey
+
+	// RefundHeight is the lock time the refund transaction must have.
+	RefundHeight uint32
+}
+
+// RedeemScript returns the redeem script of the contract.
+func (c *Contract) RedeemScript() ([]byte, error) {
+	var hashOp byte
+	switch len(c.Hash) {
+	case SHA256HashLength:
+		hashOp = vm.SHA256
+	case RIPEMD160HashLength:
+		hashOp = vm.RIPEMD160
+	default:
+		return nil, errors.New("invalid hash length")
+	}
+	if c.Receiver == nil || c.Sender == nil {
+		return nil, errors.New("public key is nil")
+	}
+	receiver, err := c.Receiver.EncodePoint(true)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := c.Sender.EncodePoint(true)
+	if err != nil {
+		return nil, err
+	}
+	var refundHeight [4]byte
+	binary.BigEndian.PutUint32(refundHeight[:], c.RefundHeight)
+
+	buf := new(bytes.Buffer)
+	// Selector times RefundHeight is the lock time required.
+	buf.Write([]byte{vm.DUP, 4})
+	buf.Write(refundHeight[:])
+	buf.Write([]byte{vm.MUL, vm.CHECKLOCKTIMEVERIFY, vm.DROP, vm.TOALTSTACK})
+	// Claim path, the secret hash and the receiver signature.
+	buf.Write([]byte{hashOp, byte(len(c.Hash))})
+	buf.Write(c.Hash)
+	buf.Write([]byte{vm.EQUAL, vm.SWAP, vm.DUP, publicKeyLength})
+	buf.Write(receiver)
+	buf.Write([]byte{vm.CHECKSIG, vm.ROT, vm.BOOLAND, vm.SWAP, publicKeyLength})
+	// Refund path, the sender signature.
+	buf.Write(sender)
+	buf.Write([]byte{vm.CHECKSIG})
+	// Select the path by the selector in the alt stack.
+	buf.Write([]byte{vm.FROMALTSTACK, vm.DUP, vm.TOALTSTACK, vm.BOOLAND,
+		vm.SWAP, vm.FROMALTSTACK, vm.NOT, vm.BOOLAND, vm.BOOLOR})
+	return buf.Bytes(), nil
+}
+
+// ProgramHash returns the program hash of the contract, which is the address
+// the locked output is sent to.
+func (c *Contract) ProgramHash() (*common.Uint168, error) {
+	script, err := c.RedeemScript()
+	if err != nil {
+		return nil, err
+	}
+	return common.ToProgramHash(byte(PrefixHTLC), script), nil
+}
+
+// ParseRedeemScript returns the contract of the redeem script, an error is
+// returned if the script is not created by Contract.RedeemScript.
+func ParseRedeemScript(script []byte) (*Contract, error) {
+	if len(script) <= 10 || script[0] != vm.DUP || script[1] != 4 {
+		return nil, errors.New("invalid redeem script")
+	}
+	c := &Contract{RefundHeight: binary.BigEndian.Uint32(script[2:6])}
+
+	var hashLength int
+	switch script[10] {
+	case vm.SHA256:
+		hashLength = SHA256HashLength
+	case vm.RIPEMD160:
+		hashLength = RIPEMD160HashLength
+	default:
+		return nil, errors.New("invalid hash opcode in redeem script")
+	}
+	if len(script) != scriptLength+hashLength {
+		return nil, errors.New("invalid redeem script length")
+	}
+	c.Hash = script[12 : 12+hashLength]
+
+	receiver := script[16+hashLength : 16+hashLength+publicKeyLength]
+	sender := script[21+hashLength+publicKeyLength : 21+hashLength+2*publicKeyLength]
+	var err error
+	if c.Receiver, err = crypto.DecodePoint(receiver); err != nil {
+		return nil, err
+	}
+	if c.Sender, err = crypto.DecodePoint(sender); err != nil {
+		return nil, err
+	}
+
+	expected, err := c.RedeemScript()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(script, expected) {
+		return nil, errors.New("invalid redeem script")
+	}
+	return c, nil
+}
+
+// IsHTLCProgramHash returns if the program hash is of a hash time locked
+// contract.
+func IsHTLCProgramHash(programHash common.Uint168) bool {
+	return contract.PrefixType(programHash[0]) == PrefixHTLC
+}
+
+// ClaimParameter returns the program parameter claiming the output with the
+// receiver signature and the secret.
+func ClaimParameter(signature, secret []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := pushData(buf, signature); err != nil {
+		return nil, err
+	}
+	if err := pushData(buf, secret); err != nil {
+		return nil, err
+	}
+	buf.WriteByte(vm.PUSH0)
+	return buf.Bytes(), nil
+}
+
+// RefundParameter returns the program parameter refunding the output with
+// the sender signature.
+func RefundParameter(signature []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := pushData(buf, signature); err != nil {
+		return nil, err
+	}
+	buf.Write([]byte{vm.PUSH0, vm.PUSH1})
+	return buf.Bytes(), nil
+}
+
+// pushData writes the push opcode of data, which is at most
+// vm.PUSHBYTES75 bytes.
+func pushData(buf *bytes.Buffer, data []byte) error {
+	if len(data) == 0 {
+		buf.WriteByte(vm.PUSH0)
+		return nil
+	}
+	if len(data) > vm.PUSHBYTES75 {
+		return errors.New("data is too long to push")
+	}
+	buf.WriteByte(byte(len(data)))
+	buf.Write(data)
+	return nil
+}
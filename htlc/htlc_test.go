@@ -0,0 +1,148 @@
+package htlc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCrypto accepts the SHA256 hash of the public key and the signed data
+// as the signature, so the tests do not depend on ECDSA signing.
+type testCrypto struct {
+	vm.CryptoECDsa
+}
+
+func testSignature(pubkey, data []byte) []byte {
+	sig := sha256.Sum256(append(pubkey[:len(pubkey):len(pubkey)], data...))
+	return sig[:]
+}
+
+func (c *testCrypto) VerifySignature(data []byte, signature []byte, pubkey []byte) error {
+	if !bytes.Equal(signature, testSignature(pubkey, data)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func sign(t *testing.T, publicKey *crypto.PublicKey, tx *types.Transaction) []byte {
+	pubkey, err := publicKey.EncodePoint(true)
+	assert.NoError(t, err)
+	return testSignature(pubkey, tx.GetData())
+}
+
+func executeContract(t *testing.T, tx *types.Transaction, script, parameter []byte) bool {
+	e := vm.NewExecutionEngine(tx, new(testCrypto), vm.MAXSTEPS, nil, nil)
+	e.SetFlags(vm.ScriptEnableTimeLock | vm.ScriptEnableHashLock)
+	e.LoadScript(script, false)
+	e.LoadScript(parameter, true)
+	e.Execute()
+	return e.GetState() == vm.HALT && e.GetEvaluationStack().Count() == 1 &&
+		e.GetExecuteResult()
+}
+
+func TestContract(t *testing.T) {
+	_, receiver, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	_, sender, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	secret := []byte("the secret of the atomic swap")
+	hash := sha256.Sum256(secret)
+
+	contract := &Contract{
+		Hash:         hash[:],
+		Receiver:     receiver,
+		Sender:       sender,
+		RefundHeight: 1000,
+	}
+	script, err := contract.RedeemScript()
+	assert.NoError(t, err)
+	programHash, err := contract.ProgramHash()
+	assert.NoError(t, err)
+	assert.True(t, IsHTLCProgramHash(*programHash))
+	assert.Equal(t, *common.ToProgramHash(byte(PrefixHTLC), script), *programHash)
+
+	parsed, err := ParseRedeemScript(script)
+	assert.NoError(t, err)
+	parsedScript, err := parsed.RedeemScript()
+	assert.NoError(t, err)
+	assert.Equal(t, script, parsedScript)
+	assert.Equal(t, uint32(1000), parsed.RefundHeight)
+
+	_, err = ParseRedeemScript(script[:len(script)-1])
+	assert.Error(t, err)
+	_, err = ParseRedeemScript(append(script[:len(script)-1:len(script)-1], vm.BOOLAND))
+	assert.Error(t, err)
+	_, err = ParseRedeemScript([]byte{0x21, 0xac})
+	assert.Error(t, err)
+
+	tx := &types.Transaction{
+		TxType:  types.TransferAsset,
+		Payload: &types.PayloadTransferAsset{},
+		Inputs: []*types.Input{{
+			Previous: *types.NewOutPoint(common.Uint256{1}, 0),
+		}},
+		Outputs: []*types.Output{{Value: 100}},
+	}
+	receiverSig := sign(t, receiver, tx)
+	senderSig := sign(t, sender, tx)
+
+	// Claim by the receiver with the secret.
+	parameter, err := ClaimParameter(receiverSig, secret)
+	assert.NoError(t, err)
+	assert.True(t, executeContract(t, tx, script, parameter))
+
+	// The contract is not executable before the opcodes are enabled.
+	e := vm.NewExecutionEngine(tx, new(testCrypto), vm.MAXSTEPS, nil, nil)
+	e.LoadScript(script, false)
+	e.LoadScript(parameter, true)
+	e.Execute()
+	assert.Equal(t, vm.FAULT, e.GetState())
+
+	// Claim with a wrong secret or by the sender.
+	parameter, _ = ClaimParameter(receiverSig, []byte("wrong secret"))
+	assert.False(t, executeContract(t, tx, script, parameter))
+	parameter, _ = ClaimParameter(senderSig, secret)
+	assert.False(t, executeContract(t, tx, script, parameter))
+
+	// Refund before the refund height.
+	parameter, err = RefundParameter(senderSig)
+	assert.NoError(t, err)
+	assert.False(t, executeContract(t, tx, script, parameter))
+
+	// Refund by the sender after the refund height, the lock time is not
+	// enforced if all inputs are final.
+	tx.LockTime = 1000
+	senderSig = sign(t, sender, tx)
+	receiverSig = sign(t, receiver, tx)
+	parameter, _ = RefundParameter(senderSig)
+	assert.True(t, executeContract(t, tx, script, parameter))
+	tx.Inputs[0].Sequence = math.MaxUint16
+	senderSig = sign(t, sender, tx)
+	parameter, _ = RefundParameter(senderSig)
+	assert.False(t, executeContract(t, tx, script, parameter))
+	tx.Inputs[0].Sequence = 0
+
+	// Refund by the receiver.
+	parameter, _ = RefundParameter(receiverSig)
+	assert.False(t, executeContract(t, tx, script, parameter))
+
+	// RIPEMD160 hash.
+	contract.Hash = contract.Hash[:RIPEMD160HashLength]
+	script, err = contract.RedeemScript()
+	assert.NoError(t, err)
+	parsed, err = ParseRedeemScript(script)
+	assert.NoError(t, err)
+	assert.Equal(t, contract.Hash, parsed.Hash)
+	contract.Hash = nil
+	_, err = contract.RedeemScript()
+	assert.Error(t, err)
+}
@@ -28,6 +28,8 @@ const (
 	ErrUTXOLocked           ErrorCode = 45019
 	ErrRechargeToSideChain  ErrorCode = 45020
 	ErrCrossChain           ErrorCode = 45021
+	ErrNonStandard          ErrorCode = 45022
+	ErrDepositImmature      ErrorCode = 45023
 )
 
 var errorCodeStrings = map[ErrorCode]string{
@@ -48,6 +50,8 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrRechargeToSideChain:  "ErrRechargeToSideChain",
 	ErrCrossChain:           "ErrCrossChain",
 	ErrTransactionSize:      "ErrTransactionSize",
+	ErrNonStandard:          "ErrNonStandard",
+	ErrDepositImmature:      "ErrDepositImmature",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -64,8 +68,9 @@ func (e ErrorCode) String() string {
 // specifically due to a rule violation and access the ErrorCode field to
 // ascertain the specific reason for the rule violation.
 type RuleError struct {
-	ErrorCode   ErrorCode // Describes the kind of error
-	Description string    // Human readable description of the issue
+	ErrorCode   ErrorCode        // Describes the kind of error
+	Description string           // Human readable description of the issue
+	Report      *ExecutionReport // Execution report of the failed programs
 }
 
 // Error satisfies the error interface and prints human-readable errors.
@@ -78,6 +83,20 @@ func ruleError(c ErrorCode, desc string) RuleError {
 	return RuleError{ErrorCode: c, Description: desc}
 }
 
+// programsRuleError creates an RuleError of ErrTransactionSignature given the
+// error and the execution report of the transaction programs.
+func programsRuleError(err error, report *ExecutionReport) RuleError {
+	desc := err.Error()
+	if failed := report.Failed(); failed != nil {
+		desc = failed.String()
+	}
+	return RuleError{
+		ErrorCode:   ErrTransactionSignature,
+		Description: desc,
+		Report:      report,
+	}
+}
+
 // extractRejectCode attempts to return a relevant reject code for a given error
 // by examining the error for known types.  It will return true if a code
 // was successfully extracted.
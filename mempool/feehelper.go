@@ -16,20 +16,23 @@ import (
 
 type FeeHelper struct {
 	chainParams *config.Params
+	chain       *blockchain.BlockChain
 	chainStore  *blockchain.ChainStore
-	spvService  *spv.Service
+	spvService  spv.MainChain
 }
 
 func NewFeeHelper(cfg *Config) *FeeHelper {
 	return &FeeHelper{
 		chainStore:  cfg.ChainStore,
 		chainParams: cfg.ChainParams,
+		chain:       cfg.Chain,
 		spvService:  cfg.SpvService,
 	}
 }
 
-func (h *FeeHelper) GetTxFee(tx *types.Transaction, assetId common.Uint256) (common.Fixed64, error) {
-	feeMap, err := h.GetTxFeeMap(tx)
+func (h *FeeHelper) GetTxFee(tx *types.Transaction, assetId common.Uint256,
+	height uint32) (common.Fixed64, error) {
+	feeMap, err := h.GetTxFeeMap(tx, height)
 	if err != nil {
 		return 0, err
 	}
@@ -37,7 +40,9 @@ func (h *FeeHelper) GetTxFee(tx *types.Transaction, assetId common.Uint256) (com
 	return feeMap[assetId], nil
 }
 
-func (h *FeeHelper) GetTxFeeMap(tx *types.Transaction) (map[common.Uint256]common.Fixed64, error) {
+// GetTxFeeMap returns the fees of the assets of the transaction, the amounts
+// recharged are converted by the cross chain assets at the height.
+func (h *FeeHelper) GetTxFeeMap(tx *types.Transaction, height uint32) (map[common.Uint256]common.Fixed64, error) {
 	feeMap := make(map[common.Uint256]common.Fixed64)
 
 	if tx.IsRechargeToSideChainTx() {
@@ -71,16 +76,18 @@ func (h *FeeHelper) GetTxFeeMap(tx *types.Transaction) (map[common.Uint256]commo
 					return nil, err
 				}
 				if targetAddress == crossChainPayload.CrossChainAddresses[i] {
-					mcAmount := mainChainTransaction.Outputs[crossChainPayload.OutputIndexes[i]].Value
-
-					amount, ok := feeMap[v.AssetID]
-					if ok {
-						feeMap[v.AssetID] = amount + common.Fixed64(
-							float64(mcAmount)*h.chainParams.ExchangeRate) - v.Value
-					} else {
-						feeMap[v.AssetID] = common.Fixed64(float64(
-							mcAmount)*h.chainParams.ExchangeRate) - v.Value
+					mcOutput := mainChainTransaction.Outputs[crossChainPayload.OutputIndexes[i]]
+					asset := h.chainParams.GetCrossChainAssetByMainChainAsset(mcOutput.AssetID,
+						isDeploymentActive(h.chain, h.chainParams,
+							config.DeploymentCrossChainAssets, height))
+					if asset == nil {
+						return nil, errors.New("invalid cross chain asset")
+					}
+					amount, err := asset.Convert(mcOutput.Value)
+					if err != nil {
+						return nil, err
 					}
+					feeMap[v.AssetID] += amount - v.Value
 				}
 			}
 		}
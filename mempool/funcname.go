@@ -17,6 +17,7 @@ var (
 		CheckRechargeToSideChainTransaction:     "checkrechargetosidechaintransaction",
 		CheckTransferCrossChainAssetTransaction: "checktransfercrosschainassettransaction",
 		CheckTransactionUTXOLock:                "checktransactionutxolock",
+		CheckTransactionSequenceLock:            "checktransactionsequencelock",
 		CheckTransactionBalance:                 "checktransactionbalance",
 		CheckReferencedOutput:                   "checkreferencedoutput",
 	}
@@ -36,6 +37,7 @@ type funcNames struct {
 	CheckRechargeToSideChainTransaction     FuncName
 	CheckTransferCrossChainAssetTransaction FuncName
 	CheckTransactionUTXOLock                FuncName
+	CheckTransactionSequenceLock            FuncName
 	CheckTransactionBalance                 FuncName
 	CheckReferencedOutput                   FuncName
 }
@@ -0,0 +1,54 @@
+//go:build go1.18
+// +build go1.18
+
+package mempool
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzRunPrograms(f *testing.F) {
+	tx := &types.Transaction{
+		TxType:  types.TransferAsset,
+		Payload: &types.PayloadTransferAsset{},
+		Programs: []*types.Program{
+			{Code: []byte{vm.PUSH2, vm.ADD}, Parameter: []byte{vm.PUSH1}},
+			{Code: []byte{vm.PUSH2, 0xff}, Parameter: []byte{vm.PUSH1}},
+		},
+	}
+	buf := new(bytes.Buffer)
+	tx.Serialize(buf)
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx := new(types.Transaction)
+		if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+			return
+		}
+		hashes := make([]common.Uint168, 0, len(tx.Programs))
+		for _, program := range tx.Programs {
+			hashes = append(hashes, *common.ToProgramHash(0x21, program.Code))
+		}
+
+		// The programs are executed successfully only if all the traces are.
+		report, err := RunProgramsWithReport(tx, hashes, tx.Programs)
+		traces, traceErr := TracePrograms(tx, hashes, tx.Programs)
+		assert.NoError(t, traceErr)
+		if err == nil {
+			assert.Nil(t, report.Failed())
+			for _, trace := range traces {
+				assert.NoError(t, trace.Error)
+			}
+		} else {
+			failed := report.Failed()
+			assert.NotNil(t, failed)
+			assert.Error(t, traces[failed.Index].Error)
+		}
+	})
+}
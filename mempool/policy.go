@@ -0,0 +1,24 @@
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/elastos/Elastos.ELA.SideChain/script"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+)
+
+// checkTransactionStandard checks the programs of the transaction are of the
+// standard templates, which is the policy of the transaction pool only.
+func checkTransactionStandard(tx *types.Transaction) error {
+	// The recharge transactions are verified by SPV instead of programs.
+	if tx.IsRechargeToSideChainTx() {
+		return nil
+	}
+	for i, program := range tx.Programs {
+		if script.Classify(program.Code) == script.NonStandard {
+			str := fmt.Sprintf("[checkTransactionStandard] program %d is non-standard", i)
+			return ruleError(ErrNonStandard, str)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package mempool
+
+import (
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/core/contract"
+	"github.com/elastos/Elastos.ELA/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransactionStandard(t *testing.T) {
+	_, publicKey, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	code, err := contract.CreateStandardRedeemScript(publicKey)
+	assert.NoError(t, err)
+
+	tx := &types.Transaction{
+		TxType:   types.TransferAsset,
+		Payload:  &types.PayloadTransferAsset{},
+		Programs: []*types.Program{{Code: code}},
+	}
+	assert.NoError(t, checkTransactionStandard(tx))
+
+	tx.Programs = append(tx.Programs, &types.Program{Code: []byte{vm.PUSH1}})
+	err = checkTransactionStandard(tx)
+	assert.Error(t, err)
+	assert.Equal(t, ErrNonStandard, err.(RuleError).ErrorCode)
+
+	// The programs of recharge transactions are not checked.
+	tx.TxType = types.RechargeToSideChain
+	tx.Payload = &types.PayloadRechargeToSideChain{}
+	assert.NoError(t, checkTransactionStandard(tx))
+}
@@ -0,0 +1,117 @@
+package mempool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// ProgramReport is the execution result of a transaction program.
+type ProgramReport struct {
+	// Index is the index of the program in the sorted programs.
+	Index int
+
+	// ProgramHash is the program hash the program is verified against.
+	ProgramHash common.Uint168
+
+	// Cached tells the program is found in the signature cache and not
+	// executed.
+	Cached bool
+
+	// Steps is the number of opcodes counted against vm.MAXSTEPS.
+	Steps int
+
+	// State is the final state of the execution engine.
+	State vm.VMState
+
+	// Position and OpCode are the last executed opcode, which is the
+	// failing opcode if the execution faults, Position is -1 if no opcode
+	// is executed.
+	Position int
+	OpCode   vm.OpCode
+
+	// Fault is the reason of the execution fault given by the engine.
+	Fault error
+
+	// Error is the reason the program is rejected, nil if the program is
+	// executed successfully.
+	Error error
+
+	// SysCall tells the program calls the interop services by SYSCALL,
+	// whose results may depend on the height the program is executed at.
+	SysCall bool
+}
+
+// trace is the vm.Tracer recording the last executed opcode.
+func (r *ProgramReport) trace(trace *vm.OpTrace) {
+	r.Position = trace.Position
+	r.OpCode = trace.OpCode
+	if trace.OpCode == vm.SYSCALL {
+		r.SysCall = true
+	}
+	if trace.State == vm.FAULT {
+		r.Fault = trace.Error
+	}
+}
+
+// String returns a human-readable description of the report.
+func (r *ProgramReport) String() string {
+	address, err := r.ProgramHash.ToAddress()
+	if err != nil {
+		address = common.BytesToHexString(r.ProgramHash.Bytes())
+	}
+	if r.Cached {
+		return fmt.Sprintf("program %d (%s): cached", r.Index, address)
+	}
+
+	str := fmt.Sprintf("program %d (%s): state %s, steps %d", r.Index,
+		address, r.State, r.Steps)
+	if r.Position >= 0 {
+		str += fmt.Sprintf(", opcode %s(0x%02x) at %d",
+			vm.OpName(r.OpCode), byte(r.OpCode), r.Position)
+	}
+	if r.Fault != nil {
+		str += ", fault: " + r.Fault.Error()
+	}
+	if r.Error != nil {
+		str += ", error: " + r.Error.Error()
+	}
+	return str
+}
+
+// ExecutionReport is the execution result of the programs of a transaction.
+type ExecutionReport struct {
+	Programs []*ProgramReport
+}
+
+// Failed returns the report of the failed program, nil if all programs are
+// executed successfully.
+func (r *ExecutionReport) Failed() *ProgramReport {
+	for _, p := range r.Programs {
+		if p.Error != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// Steps returns the number of opcodes executed by all programs.
+func (r *ExecutionReport) Steps() int {
+	var steps int
+	for _, p := range r.Programs {
+		steps += p.Steps
+	}
+	return steps
+}
+
+// String returns a human-readable description of the report.
+func (r *ExecutionReport) String() string {
+	programs := make([]string, 0, len(r.Programs))
+	for _, p := range r.Programs {
+		programs = append(programs, p.String())
+	}
+	return strings.Join(programs, "; ")
+}
@@ -0,0 +1,116 @@
+package mempool
+
+import (
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/config"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+	vmtypes "github.com/elastos/Elastos.ELA.SideChain/vm/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunProgramsWithReport(t *testing.T) {
+	tx := &types.Transaction{
+		TxType:  types.TransferAsset,
+		Payload: &types.PayloadTransferAsset{},
+	}
+	newProgram := func(code, parameter []byte) (common.Uint168, *types.Program) {
+		return *common.ToProgramHash(0x21, code),
+			&types.Program{Code: code, Parameter: parameter}
+	}
+
+	// Code adds 2 to the number pushed by the parameter.
+	hash, program := newProgram([]byte{vm.PUSH2, vm.ADD}, []byte{vm.PUSH1})
+	report, err := RunProgramsWithReport(tx, []common.Uint168{hash},
+		[]*types.Program{program})
+	assert.NoError(t, err)
+	assert.Nil(t, report.Failed())
+	assert.Equal(t, 1, len(report.Programs))
+	assert.Equal(t, vm.HALT, report.Programs[0].State)
+	assert.Equal(t, 3, report.Steps())
+	assert.Equal(t, vm.OpCode(vm.ADD), report.Programs[0].OpCode)
+	assert.Equal(t, 1, report.Programs[0].Position)
+
+	// Unknown opcode faults the execution.
+	failedHash, failedProgram := newProgram([]byte{vm.PUSH2, 0xff}, []byte{vm.PUSH1})
+	report, err = RunProgramsWithReport(tx, []common.Uint168{hash, failedHash},
+		[]*types.Program{program, failedProgram})
+	assert.EqualError(t, err, "[VM] Finish State not equal to HALT")
+	failed := report.Failed()
+	assert.NotNil(t, failed)
+	assert.Equal(t, 1, failed.Index)
+	assert.Equal(t, vm.FAULT, failed.State)
+	assert.Equal(t, vm.OpCode(0xff), failed.OpCode)
+	assert.Equal(t, 1, failed.Position)
+	assert.NotNil(t, failed.Fault)
+
+	// The report is surfaced in the rule error.
+	ruleErr := programsRuleError(err, report)
+	assert.Equal(t, ErrTransactionSignature, ruleErr.ErrorCode)
+	assert.Equal(t, report, ruleErr.Report)
+	assert.Contains(t, ruleErr.Description, "program 1")
+	assert.Contains(t, ruleErr.Description, "FAULT")
+	assert.Contains(t, ruleErr.Description, "unknown opcode")
+
+	// Programs found in the signature cache are not executed.
+	cache := NewSigCache(DefaultSigCacheSize)
+	cache.Add(tx.Hash(), 0, hash, program, 0)
+	runner := programRunner{sigCache: cache}
+	report, err = runner.run(tx, []common.Uint168{hash}, []*types.Program{program})
+	assert.NoError(t, err)
+	assert.True(t, report.Programs[0].Cached)
+	assert.Equal(t, 0, report.Steps())
+
+	// Programs calling the interop services are not cached.
+	service := vm.NewGeneralService()
+	service.Register("Test.Push", func(engine *vm.ExecutionEngine) bool {
+		engine.GetEvaluationStack().Push(vmtypes.NewBoolean(true))
+		return true
+	})
+	sysCallHash, sysCallProgram := newProgram(
+		append([]byte{vm.SYSCALL, 9}, "Test.Push"...), []byte{})
+	cache = NewSigCache(DefaultSigCacheSize)
+	runner = programRunner{sigCache: cache, service: service}
+	report, err = runner.run(tx, []common.Uint168{hash, sysCallHash},
+		[]*types.Program{program, sysCallProgram})
+	assert.NoError(t, err)
+	assert.False(t, report.Programs[0].SysCall)
+	assert.True(t, report.Programs[1].SysCall)
+	assert.Equal(t, 1, cache.Stats().Entries)
+	assert.False(t, cache.Exists(tx.Hash(), 1, sysCallHash, sysCallProgram, 0))
+}
+
+func TestValidator_TracePrograms(t *testing.T) {
+	v := NewValidator(&Config{ChainParams: &config.Params{
+		TimeLockStartHeight: 10,
+	}})
+	tx := &types.Transaction{
+		TxType:   types.TransferAsset,
+		Payload:  &types.PayloadTransferAsset{},
+		Inputs:   []*types.Input{{Sequence: 0}},
+		LockTime: 100,
+	}
+	code := []byte{vm.PUSHBYTES1, 100, vm.CHECKLOCKTIMEVERIFY}
+	hashes := []common.Uint168{*common.ToProgramHash(0x21, code)}
+	programs := []*types.Program{{Code: code, Parameter: []byte{}}}
+
+	// The time lock opcodes are traced as the validator runs them at the
+	// height.
+	traces, err := v.TracePrograms(tx, 10, hashes, programs)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(traces))
+	assert.NoError(t, traces[0].Error)
+	assert.Equal(t, vm.HALT, traces[0].State)
+	report, err := v.runPrograms(tx, 10, hashes, programs)
+	assert.NoError(t, err)
+	assert.Nil(t, report.Failed())
+
+	// Unknown opcode before activation.
+	traces, err = v.TracePrograms(tx, 9, hashes, programs)
+	assert.NoError(t, err)
+	assert.Error(t, traces[0].Error)
+	assert.Equal(t, vm.FAULT, traces[0].State)
+}
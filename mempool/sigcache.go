@@ -0,0 +1,143 @@
+package mempool
+
+import (
+	"crypto/sha256"
+	"sync"
+	"sync/atomic"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// DefaultSigCacheSize is the default number of program execution results
+// kept by a SigCache.
+const DefaultSigCacheSize = 50000
+
+// sigCacheKey identifies a program by the hash of the transaction and the
+// index of the program in it.
+type sigCacheKey struct {
+	txHash common.Uint256
+	index  int
+}
+
+// SigCacheStats is a snapshot of the SigCache metrics.
+type SigCacheStats struct {
+	Entries int
+	Hits    uint64
+	Misses  uint64
+}
+
+// HitRate returns the ratio of lookups found in the cache.
+func (s SigCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// SigCache keeps the programs executed successfully, so a transaction
+// verified when entering the TxPool is not executed again when the block
+// containing it arrives.
+//
+// The transaction hash does not cover the programs, so the code and the
+// parameter of the program, together with the program hash and the script
+// flags it is verified with, are recorded as a digest and compared on
+// lookup. A transaction with the same hash but different programs, or
+// referencing different outputs, is never treated as verified. The programs
+// calling the interop services are not kept, since their results depend on
+// the height they are executed at.
+type SigCache struct {
+	sync.RWMutex
+	maxEntries int
+	entries    map[sigCacheKey][sha256.Size]byte
+	hits       uint64
+	misses     uint64
+}
+
+// NewSigCache creates a cache keeping at most maxEntries program execution
+// results. When the cache is full a random entry is evicted.
+func NewSigCache(maxEntries int) *SigCache {
+	return &SigCache{
+		maxEntries: maxEntries,
+		entries:    make(map[sigCacheKey][sha256.Size]byte),
+	}
+}
+
+// Exists returns if the program at index of the transaction has been
+// executed successfully against the program hash with the script flags.
+func (c *SigCache) Exists(txHash common.Uint256, index int,
+	programHash common.Uint168, program *types.Program, flags vm.ScriptFlags) bool {
+	c.RLock()
+	digest, ok := c.entries[sigCacheKey{txHash, index}]
+	c.RUnlock()
+
+	if ok && digest == programDigest(programHash, program, flags) {
+		atomic.AddUint64(&c.hits, 1)
+		return true
+	}
+	atomic.AddUint64(&c.misses, 1)
+	return false
+}
+
+// Add records the program at index of the transaction as executed
+// successfully against the program hash with the script flags.
+func (c *SigCache) Add(txHash common.Uint256, index int,
+	programHash common.Uint168, program *types.Program, flags vm.ScriptFlags) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	digest := programDigest(programHash, program, flags)
+
+	c.Lock()
+	defer c.Unlock()
+
+	key := sigCacheKey{txHash, index}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		// Map iteration order is random, so this evicts a random entry.
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = digest
+}
+
+// RemoveTransaction removes the programs of the transaction from the cache.
+func (c *SigCache) RemoveTransaction(tx *types.Transaction) {
+	txHash := tx.Hash()
+
+	c.Lock()
+	for i := range tx.Programs {
+		delete(c.entries, sigCacheKey{txHash, i})
+	}
+	c.Unlock()
+}
+
+// Stats returns the current metrics of the cache.
+func (c *SigCache) Stats() SigCacheStats {
+	c.RLock()
+	entries := len(c.entries)
+	c.RUnlock()
+
+	return SigCacheStats{
+		Entries: entries,
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+	}
+}
+
+// programDigest returns the digest of the program, the program hash it is
+// verified against and the script flags it is verified with.
+func programDigest(programHash common.Uint168, program *types.Program,
+	flags vm.ScriptFlags) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(programHash.Bytes())
+	common.WriteUint32(h, uint32(flags))
+	common.WriteVarBytes(h, program.Code)
+	common.WriteVarBytes(h, program.Parameter)
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
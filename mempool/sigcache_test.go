@@ -0,0 +1,66 @@
+package mempool
+
+import (
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigCache(t *testing.T) {
+	cache := NewSigCache(3)
+	txHash := common.Uint256{1}
+	programHash := common.Uint168{0x21, 1}
+	program := &types.Program{Code: []byte{0x21, 0xac}, Parameter: []byte{0x40}}
+
+	assert.False(t, cache.Exists(txHash, 0, programHash, program, 0))
+	cache.Add(txHash, 0, programHash, program, 0)
+	assert.True(t, cache.Exists(txHash, 0, programHash, program, 0))
+
+	// Another program index, program hash, program content or script flags
+	// is not treated as verified.
+	assert.False(t, cache.Exists(txHash, 1, programHash, program, 0))
+	assert.False(t, cache.Exists(txHash, 0, common.Uint168{0x21, 2}, program, 0))
+	assert.False(t, cache.Exists(txHash, 0, programHash,
+		&types.Program{Code: program.Code, Parameter: []byte{0x41}}, 0))
+	assert.False(t, cache.Exists(txHash, 0, programHash,
+		&types.Program{Code: []byte{0x21}, Parameter: []byte{0xac, 0x40}}, 0))
+	assert.False(t, cache.Exists(txHash, 0, programHash, program,
+		vm.ScriptEnableTimeLock))
+
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(6), stats.Misses)
+	assert.Equal(t, 1.0/7, stats.HitRate())
+
+	// The cache is bounded.
+	for i := 0; i < 10; i++ {
+		cache.Add(common.Uint256{byte(i + 2)}, 0, programHash, program, 0)
+	}
+	assert.Equal(t, 3, cache.Stats().Entries)
+
+	// Remove the programs of a transaction.
+	tx := &types.Transaction{
+		TxType:   types.TransferAsset,
+		Payload:  &types.PayloadTransferAsset{},
+		Programs: []*types.Program{program, program},
+	}
+	cache = NewSigCache(DefaultSigCacheSize)
+	cache.Add(tx.Hash(), 0, programHash, program, 0)
+	cache.Add(tx.Hash(), 1, programHash, program, 0)
+	cache.Add(txHash, 0, programHash, program, 0)
+	cache.RemoveTransaction(tx)
+	assert.False(t, cache.Exists(tx.Hash(), 0, programHash, program, 0))
+	assert.False(t, cache.Exists(tx.Hash(), 1, programHash, program, 0))
+	assert.True(t, cache.Exists(txHash, 0, programHash, program, 0))
+
+	// A cache of zero size is disabled.
+	cache = NewSigCache(0)
+	cache.Add(txHash, 0, programHash, program, 0)
+	assert.False(t, cache.Exists(txHash, 0, programHash, program, 0))
+	assert.Equal(t, 0.0, SigCacheStats{}.HitRate())
+}
@@ -0,0 +1,74 @@
+package mempool
+
+import (
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+)
+
+// ProgramTrace is the step by step execution of a transaction program.
+type ProgramTrace struct {
+	ProgramHash common.Uint168
+	Program     *types.Program
+	Steps       []*vm.StepInfo
+	State       vm.VMState
+	Error       error
+}
+
+// TracePrograms executes the programs of the transaction like RunPrograms,
+// but records every step of the execution engine for debugging. All programs
+// are traced even if some of them fail.
+func TracePrograms(tx *types.Transaction, hashes []common.Uint168, programs []*types.Program) ([]*ProgramTrace, error) {
+	return new(programRunner).trace(tx, hashes, programs)
+}
+
+func (r *programRunner) trace(tx *types.Transaction, hashes []common.Uint168, programs []*types.Program) ([]*ProgramTrace, error) {
+	if tx == nil {
+		return nil, errors.New("invalid data content nil transaction")
+	}
+	if len(hashes) != len(programs) {
+		return nil, errors.New("number of data hashes is different with number of programs")
+	}
+
+	traces := make([]*ProgramTrace, 0, len(programs))
+	for i := 0; i < len(programs); i++ {
+		traces = append(traces, r.traceProgram(tx, hashes[i], programs[i]))
+	}
+	return traces, nil
+}
+
+func (r *programRunner) traceProgram(tx *types.Transaction, hash common.Uint168, program *types.Program) *ProgramTrace {
+	trace := &ProgramTrace{
+		ProgramHash: hash,
+		Program:     program,
+	}
+
+	codeHash := common.ToCodeHash(program.Code)
+	if !hash.ToCodeHash().IsEqual(*codeHash) {
+		trace.Error = errors.New("data hash is different from corresponding program code")
+		return trace
+	}
+
+	se := r.newEngine(tx, hash)
+	se.LoadScript(program.Code, false)
+	se.LoadScript(program.Parameter, true)
+
+	session := vm.NewDebugSession(se)
+	for !session.Done() {
+		trace.Steps = append(trace.Steps, session.Step())
+	}
+	trace.State = se.GetState()
+
+	switch {
+	case se.GetState() != vm.HALT:
+		trace.Error = errors.New("[VM] Finish State not equal to HALT")
+	case se.GetEvaluationStack().Count() != 1:
+		trace.Error = errors.New("[VM] Execute Engine Stack Count Error")
+	case !se.GetExecuteResult():
+		trace.Error = errors.New("[VM] Check Sig FALSE")
+	}
+	return trace
+}
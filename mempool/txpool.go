@@ -10,6 +10,7 @@ import (
 	"github.com/elastos/Elastos.ELA.SideChain/events"
 	"github.com/elastos/Elastos.ELA.SideChain/spv"
 	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
 
 	"github.com/elastos/Elastos.ELA/common"
 )
@@ -18,11 +19,37 @@ type Config struct {
 	ChainParams *config.Params
 	Chain       *blockchain.BlockChain
 	ChainStore  *blockchain.ChainStore
-	SpvService  *spv.Service
+	SpvService  spv.MainChain // spv.Service, or spv.FileMainChain offline
 	Validator   *Validator
 	FeeHelper   *FeeHelper
+
+	// SigCache caches the programs executed successfully, it is disabled
+	// if not set.
+	SigCache *SigCache
+
+	// InteropService provides the interop services called by SYSCALL when
+	// executing the transaction programs, the default services of
+	// vm.NewGeneralService are used if not set. Custom services change the
+	// consensus rules and must be the same for all nodes of the chain.
+	InteropService *vm.GeneralService
+
+	// AcceptNonStandard accepts the transactions with non-standard programs
+	// into the pool, see script.Classify. Blocks are always allowed to
+	// contain them.
+	AcceptNonStandard bool
+
+	// DepositConfirmations is the minimum main chain confirmations of the
+	// deposits recharged by the transactions accepted into the pool, the
+	// younger ones are held and accepted once the confirmations are reached.
+	// It should not be less than the DepositConfirmations of ChainParams
+	// required by the blocks.
+	DepositConfirmations uint32
 }
 
+// maxHeldDeposits is the max number of the recharge transactions held until
+// their deposits reach the confirmations of the pool.
+const maxHeldDeposits = 1000
+
 type TxPool struct {
 	conflictManager
 	chainParams *config.Params
@@ -31,6 +58,10 @@ type TxPool struct {
 	sync.RWMutex
 	txCount uint64                                // count
 	txnList map[common.Uint256]*types.Transaction // transaction which have been verifyed will put into this map
+
+	acceptNonStandard    bool
+	depositConfirmations uint32
+	heldDeposits         map[common.Uint256]*types.Transaction
 }
 
 func New(cfg *Config) *TxPool {
@@ -41,6 +72,10 @@ func New(cfg *Config) *TxPool {
 		conflictManager: newConflictManager(cfg.Chain),
 		txCount:         0,
 		txnList:         make(map[common.Uint256]*types.Transaction),
+
+		acceptNonStandard:    cfg.AcceptNonStandard,
+		depositConfirmations: cfg.DepositConfirmations,
+		heldDeposits:         make(map[common.Uint256]*types.Transaction),
 	}
 	return &p
 }
@@ -66,8 +101,19 @@ func (p *TxPool) appendToTxPool(tx *types.Transaction) error {
 		p.chain.BestChain.MainChainHeight); err != nil {
 		return err
 	}
+	if !p.acceptNonStandard {
+		if err := checkTransactionStandard(tx); err != nil {
+			return err
+		}
+	}
 	if err := p.validator.CheckTransactionContext(tx, p.chain.BestChain.Height,
 		p.chain.BestChain.MainChainHeight); err != nil {
+		p.holdDeposit(tx, err)
+		return err
+	}
+	if err := p.validator.checkDepositConfirmations(tx,
+		p.depositConfirmations); err != nil {
+		p.holdDeposit(tx, err)
 		return err
 	}
 
@@ -76,7 +122,8 @@ func (p *TxPool) appendToTxPool(tx *types.Transaction) error {
 		return err
 	}
 
-	fee, err := p.feeHelper.GetTxFee(tx, p.chainParams.ElaAssetId)
+	fee, err := p.feeHelper.GetTxFee(tx, p.chainParams.ElaAssetId,
+		p.chain.BestChain.Height)
 	if err != nil {
 		return err
 	}
@@ -98,6 +145,68 @@ func (p *TxPool) appendToTxPool(tx *types.Transaction) error {
 	return nil
 }
 
+// holdDeposit holds the recharge transaction rejected by ErrDepositImmature,
+// to append it again when its deposit reaches the confirmations.
+func (p *TxPool) holdDeposit(tx *types.Transaction, err error) {
+	if e, ok := err.(RuleError); !ok || e.ErrorCode != ErrDepositImmature {
+		return
+	}
+	hash := tx.Hash()
+	if _, ok := p.heldDeposits[hash]; ok {
+		return
+	}
+	if len(p.heldDeposits) >= maxHeldDeposits {
+		log.Warnf("held deposits reach the limit %d, drop recharge tx %s",
+			maxHeldDeposits, hash)
+		return
+	}
+	p.heldDeposits[hash] = tx
+}
+
+// OnMainChainBlock is the block handler to register to the SPV service. It
+// appends the held recharge transactions whose deposits reach the
+// confirmations, and drops the ones becoming invalid.
+func (p *TxPool) OnMainChainBlock(height uint32) {
+	var accepted []*types.Transaction
+	p.Lock()
+	for hash, tx := range p.heldDeposits {
+		if _, ok := p.txnList[hash]; ok {
+			delete(p.heldDeposits, hash)
+			continue
+		}
+
+		err := p.appendToTxPool(tx)
+		if e, ok := err.(RuleError); ok && e.ErrorCode == ErrDepositImmature {
+			continue
+		}
+		delete(p.heldDeposits, hash)
+		if err != nil {
+			log.Debugf("drop held recharge tx %s, %s", hash, err)
+			continue
+		}
+		log.Infof("held recharge tx %s accepted at main chain height %d",
+			hash, height)
+		accepted = append(accepted, tx)
+	}
+	p.Unlock()
+
+	for _, tx := range accepted {
+		go events.Notify(events.ETTransactionAccepted, tx)
+	}
+}
+
+// HeldDeposits returns the recharge transactions held until their deposits
+// reach the confirmations.
+func (p *TxPool) HeldDeposits() []*types.Transaction {
+	p.RLock()
+	defer p.RUnlock()
+	txs := make([]*types.Transaction, 0, len(p.heldDeposits))
+	for _, tx := range p.heldDeposits {
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
 // HaveTransaction returns if a transaction is in transaction pool by the given
 // transaction id. If no transaction match the transaction id, return false
 func (p *TxPool) HaveTransaction(txId common.Uint256) bool {
@@ -126,6 +235,17 @@ func (p *TxPool) CleanSubmittedTransactions(block *types.Block) error {
 	p.cleanMainChainTx(block.Transactions)
 	p.cleanTransactionList(block.Transactions)
 	p.checkAndCleanAllTransactions()
+
+	// Programs of the committed transactions will not be executed again.
+	if p.validator.sigCache != nil {
+		for _, tx := range block.Transactions {
+			p.validator.sigCache.RemoveTransaction(tx)
+		}
+		stats := p.validator.sigCache.Stats()
+		log.Debugf("signature cache entries %d, hits %d, misses %d,"+
+			" hit rate %.2f", stats.Entries, stats.Hits, stats.Misses,
+			stats.HitRate())
+	}
 	return nil
 }
 
@@ -149,6 +269,13 @@ func (p *TxPool) checkAndCleanAllTransactions() {
 }
 
 //get the transaction by hash
+// TracePrograms traces the programs of the transaction with the script
+// flags, interop services and limits the pool validates them with at the
+// height, see TracePrograms.
+func (p *TxPool) TracePrograms(tx *types.Transaction, height uint32, hashes []common.Uint168, programs []*types.Program) ([]*ProgramTrace, error) {
+	return p.validator.TracePrograms(tx, height, hashes, programs)
+}
+
 func (p *TxPool) GetTransaction(hash common.Uint256) *types.Transaction {
 	p.RLock()
 	defer p.RUnlock()
@@ -256,6 +383,48 @@ func (p *TxPool) cleanMainChainTx(txs []*types.Transaction) {
 	}
 }
 
+// OnMainChainRollback is the rollback handler to register to the SPV
+// service. It evicts the recharge transactions whose main chain transactions
+// were rolled back, and all their descendants in the pool.
+func (p *TxPool) OnMainChainRollback(height uint32) {
+	if p.validator.spvService == nil {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	for _, tx := range p.txnList {
+		if !tx.IsRechargeToSideChainTx() {
+			continue
+		}
+		if err := p.validator.spvService.VerifyTransaction(tx); err == nil {
+			continue
+		}
+
+		log.Warnf("main chain rolled back to height %d, remove recharge"+
+			" tx %s", height, tx.Hash())
+		p.removeWithDescendants(tx)
+	}
+}
+
+// removeWithDescendants removes the transaction and the transactions
+// spending its outputs in the pool recursively.
+func (p *TxPool) removeWithDescendants(tx *types.Transaction) {
+	p.doRemoveTransaction(tx)
+	txHash := tx.Hash()
+	for i := range tx.Outputs {
+		input := types.Input{
+			Previous: types.OutPoint{
+				TxID:  txHash,
+				Index: uint16(i),
+			},
+		}
+		if txn := p.getInputUTXOList(&input); txn != nil {
+			p.removeWithDescendants(txn)
+		}
+	}
+}
+
 func (p *TxPool) delFromTxList(txId common.Uint256) bool {
 	if _, ok := p.txnList[txId]; !ok {
 		return false
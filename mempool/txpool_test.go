@@ -0,0 +1,207 @@
+package mempool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/config"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxPool_HoldDeposit(t *testing.T) {
+	p := New(&Config{DepositConfirmations: 6})
+	recharge := func(i int) *types.Transaction {
+		return &types.Transaction{
+			TxType:         types.RechargeToSideChain,
+			PayloadVersion: types.RechargeToSideChainPayloadVersion1,
+			Payload: &types.PayloadRechargeToSideChain{
+				MainChainTransactionHash: common.Uint256{byte(i), byte(i >> 8)},
+			},
+		}
+	}
+	immature := ruleError(ErrDepositImmature, "immature")
+
+	// Only the transactions rejected by ErrDepositImmature are held.
+	p.holdDeposit(recharge(0), errors.New("error"))
+	p.holdDeposit(recharge(0), ruleError(ErrRechargeToSideChain, "invalid"))
+	assert.Equal(t, 0, len(p.HeldDeposits()))
+
+	p.holdDeposit(recharge(0), immature)
+	p.holdDeposit(recharge(0), immature)
+	assert.Equal(t, 1, len(p.HeldDeposits()))
+
+	// The held transactions are limited.
+	for i := 1; i <= maxHeldDeposits; i++ {
+		p.holdDeposit(recharge(i), immature)
+	}
+	assert.Equal(t, maxHeldDeposits, len(p.HeldDeposits()))
+	_, ok := p.heldDeposits[recharge(maxHeldDeposits).Hash()]
+	assert.False(t, ok)
+}
+
+func TestValidator_CheckDepositConfirmations(t *testing.T) {
+	v := NewValidator(&Config{ChainParams: &config.Params{
+		DepositConfirmationsStartHeight: 10,
+		DepositConfirmations:            6,
+	}})
+
+	// The confirmations of the blocks are required from the start height.
+	assert.Equal(t, uint32(0), v.blockDepositConfirmations(9))
+	assert.Equal(t, uint32(6), v.blockDepositConfirmations(10))
+
+	// No confirmations are checked without SPV if one or less is required.
+	tx := &types.Transaction{
+		TxType:         types.RechargeToSideChain,
+		PayloadVersion: types.RechargeToSideChainPayloadVersion1,
+		Payload:        &types.PayloadRechargeToSideChain{},
+	}
+	assert.NoError(t, v.checkDepositConfirmations(tx, 0))
+	assert.NoError(t, v.checkDepositConfirmations(tx, 1))
+	assert.NoError(t, v.checkDepositConfirmations(tx,
+		v.blockDepositConfirmations(9)))
+
+	// Only the recharge transactions of payload version 1 are checked.
+	assert.NoError(t, v.checkDepositConfirmations(&types.Transaction{
+		TxType:  types.TransferAsset,
+		Payload: new(types.PayloadTransferAsset),
+	}, 6))
+	tx.PayloadVersion = types.RechargeToSideChainPayloadVersion0
+	assert.NoError(t, v.checkDepositConfirmations(tx, 6))
+}
+
+func TestValidator_IsCrossChainPayloadVersionValid(t *testing.T) {
+	params := &config.Params{}
+	v := NewValidator(&Config{ChainParams: params})
+
+	// All the versions are accepted if the versions are not checked.
+	assert.True(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion0, 100))
+	assert.True(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion1, 100))
+	assert.True(t, v.isCrossChainPayloadVersionValid(3, 100))
+
+	// Only the known versions are accepted from the start height.
+	params.CrossChainPayloadV1StartHeight = 100
+	assert.True(t, v.isCrossChainPayloadVersionValid(3, 99))
+	assert.True(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion1, 100))
+	assert.False(t, v.isCrossChainPayloadVersionValid(3, 100))
+	assert.True(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion0, 100))
+
+	// Version 2 is accepted from its own start height.
+	assert.False(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion2, 100))
+	params.CrossChainTargetDataStartHeight = 200
+	assert.False(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion2, 199))
+	assert.True(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion2, 200))
+
+	// The unknown versions are not accepted after the start height.
+	assert.False(t, v.isCrossChainPayloadVersionValid(3, 200))
+
+	// Nor after the target data is active if the versions are not checked.
+	params.CrossChainPayloadV1StartHeight = 0
+	assert.True(t, v.isCrossChainPayloadVersionValid(3, 199))
+	assert.False(t, v.isCrossChainPayloadVersionValid(3, 200))
+	assert.True(t, v.isCrossChainPayloadVersionValid(
+		types.TransferCrossChainAssetVersion2, 200))
+}
+
+func TestCheckCrossChainTargetData(t *testing.T) {
+	p := &types.PayloadTransferCrossChainAsset{
+		CrossChainAddresses: []string{"a", "b"},
+		TargetData:          [][]byte{[]byte("memo")},
+	}
+
+	// The target data is not allowed before version 2.
+	assert.Error(t, checkCrossChainTargetData(
+		types.TransferCrossChainAssetVersion1, p, true))
+
+	// Every output must have the target data within the size limit.
+	assert.Error(t, checkCrossChainTargetData(
+		types.TransferCrossChainAssetVersion2, p, true))
+	p.TargetData = append(p.TargetData, nil)
+	assert.NoError(t, checkCrossChainTargetData(
+		types.TransferCrossChainAssetVersion2, p, true))
+	p.TargetData[1] = make([]byte, types.MaxCrossChainTargetDataSize+1)
+	assert.Error(t, checkCrossChainTargetData(
+		types.TransferCrossChainAssetVersion2, p, true))
+
+	// The target data is not accepted before it is active.
+	p.TargetData[1] = nil
+	assert.Error(t, checkCrossChainTargetData(
+		types.TransferCrossChainAssetVersion2, p, false))
+
+	p.TargetData = nil
+	assert.NoError(t, checkCrossChainTargetData(
+		types.TransferCrossChainAssetVersion0, p, false))
+}
+
+func TestEqualAssetAmounts(t *testing.T) {
+	ela := common.Uint256{1}
+	token := common.Uint256{2}
+	assert.True(t, equalAssetAmounts(nil, map[common.Uint256]common.Fixed64{}))
+	assert.True(t, equalAssetAmounts(
+		map[common.Uint256]common.Fixed64{ela: 1, token: 2},
+		map[common.Uint256]common.Fixed64{token: 2, ela: 1}))
+	assert.False(t, equalAssetAmounts(
+		map[common.Uint256]common.Fixed64{ela: 1},
+		map[common.Uint256]common.Fixed64{ela: 1, token: 2}))
+	assert.False(t, equalAssetAmounts(
+		map[common.Uint256]common.Fixed64{ela: 1, token: 2},
+		map[common.Uint256]common.Fixed64{ela: 1}))
+	assert.False(t, equalAssetAmounts(
+		map[common.Uint256]common.Fixed64{ela: 1},
+		map[common.Uint256]common.Fixed64{ela: 2}))
+}
+
+func TestCheckCrossChainAmounts(t *testing.T) {
+	ela := common.Uint256{1}
+	token := common.Uint256{2}
+	params := &config.Params{
+		ElaAssetId:         ela,
+		MinCrossChainTxFee: 10000,
+		CrossChainAssets: []config.CrossChainAsset{{
+			AssetID:            token,
+			MainChainAssetID:   common.Uint256{3},
+			MinCrossChainTxFee: 10000,
+		}},
+	}
+	p := &types.PayloadTransferCrossChainAsset{
+		CrossChainAddresses: []string{"EPYSn8Mq5AJ3pVD3e6GZhZcP6pVxX9yGms"},
+		OutputIndexes:       []uint64{0},
+		CrossChainAmounts:   []common.Fixed64{90000},
+	}
+	tx := &types.Transaction{
+		TxType:  types.TransferCrossChainAsset,
+		Payload: p,
+		Outputs: []*types.Output{{AssetID: token, Value: 100000}},
+	}
+	reference := map[*types.Input]*types.Output{
+		{Sequence: 0}: {AssetID: ela, Value: 10000},
+		{Sequence: 1}: {AssetID: token, Value: 100000},
+	}
+
+	// Before the cross chain assets are active, a withdrawal of any asset
+	// pays the fee of all the assets together, as it did before.
+	assert.NoError(t, checkCrossChainAmounts(tx, p, reference, params, false))
+
+	// After, the fee is paid in the asset withdrawn.
+	assert.Error(t, checkCrossChainAmounts(tx, p, reference, params, true))
+	reference[&types.Input{Sequence: 2}] = &types.Output{AssetID: token, Value: 10000}
+	assert.NoError(t, checkCrossChainAmounts(tx, p, reference, params, true))
+
+	// And the assets not declared are not withdrawn.
+	params.CrossChainAssets = nil
+	assert.Error(t, checkCrossChainAmounts(tx, p, reference, params, true))
+	assert.NoError(t, checkCrossChainAmounts(tx, p, reference, params, false))
+
+	// The fee of all the assets together is still checked before.
+	tx.Outputs = append(tx.Outputs, &types.Output{AssetID: ela, Value: 15000})
+	assert.Error(t, checkCrossChainAmounts(tx, p, reference, params, false))
+}
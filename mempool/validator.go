@@ -9,6 +9,8 @@ import (
 
 	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
 	"github.com/elastos/Elastos.ELA.SideChain/config"
+	"github.com/elastos/Elastos.ELA.SideChain/htlc"
+	"github.com/elastos/Elastos.ELA.SideChain/script"
 	"github.com/elastos/Elastos.ELA.SideChain/spv"
 	"github.com/elastos/Elastos.ELA.SideChain/types"
 	"github.com/elastos/Elastos.ELA.SideChain/vm"
@@ -33,18 +35,22 @@ type Validator struct {
 	Chain                 *blockchain.BlockChain
 	db                    *blockchain.ChainStore
 	txFeeHelper           *FeeHelper
-	spvService            *spv.Service
+	spvService            spv.MainChain
+	sigCache              *SigCache
+	interopService        *vm.GeneralService
 	checkSanityFunctions  []*TxValidateAction
 	checkContextFunctions []*TxValidateAction
 }
 
 func NewValidator(cfg *Config) *Validator {
 	v := &Validator{
-		chainParams: cfg.ChainParams,
-		db:          cfg.ChainStore,
-		txFeeHelper: cfg.FeeHelper,
-		spvService:  cfg.SpvService,
-		Chain:       cfg.Chain,
+		chainParams:    cfg.ChainParams,
+		db:             cfg.ChainStore,
+		txFeeHelper:    cfg.FeeHelper,
+		spvService:     cfg.SpvService,
+		sigCache:       cfg.SigCache,
+		interopService: cfg.InteropService,
+		Chain:          cfg.Chain,
 	}
 
 	v.RegisterSanityFunc(FuncNames.CheckTransactionSize, v.checkTransactionSize)
@@ -61,6 +67,7 @@ func NewValidator(cfg *Config) *Validator {
 	v.RegisterContextFunc(FuncNames.CheckRechargeToSideChainTransaction, v.checkRechargeToSideChainTransaction)
 	v.RegisterContextFunc(FuncNames.CheckTransferCrossChainAssetTransaction, v.checkTransferCrossChainAssetTransaction)
 	v.RegisterContextFunc(FuncNames.CheckTransactionUTXOLock, v.checkTransactionUTXOLock)
+	v.RegisterContextFunc(FuncNames.CheckTransactionSequenceLock, v.checkTransactionSequenceLock)
 	v.RegisterContextFunc(FuncNames.CheckTransactionBalance, v.checkTransactionBalance)
 	v.RegisterContextFunc(FuncNames.CheckReferencedOutput, v.checkReferencedOutput)
 	return v
@@ -190,12 +197,15 @@ func (v *Validator) checkTransactionOutput(txn *types.Transaction, height uint32
 
 	// check if output address is valid
 	for _, output := range txn.Outputs {
-		if !output.AssetID.IsEqual(v.chainParams.ElaAssetId) {
+		if !output.AssetID.IsEqual(v.chainParams.ElaAssetId) &&
+			v.chainParams.GetCrossChainAsset(output.AssetID,
+				v.isDeploymentActive(config.DeploymentCrossChainAssets, height)) == nil {
 			str := fmt.Sprint("[checkTransactionOutput] asset ID in output is invalid")
 			return ruleError(ErrInvalidOutput, str)
 		}
 
-		if !v.checkOutputProgramHash(output.ProgramHash) {
+		if !v.checkOutputProgramHash(output.ProgramHash) &&
+			!(htlc.IsHTLCProgramHash(output.ProgramHash) && v.isHTLCEnabled(height)) {
 			str := fmt.Sprint("[checkTransactionOutput] output address is invalid")
 			return ruleError(ErrInvalidOutput, str)
 		}
@@ -205,12 +215,8 @@ func (v *Validator) checkTransactionOutput(txn *types.Transaction, height uint32
 }
 
 func (v *Validator) checkOutputProgramHash(programHash common.Uint168) bool {
-	if programHash.IsEqual(common.Uint168{}) {
-		return true
-	}
-
-	switch contract.PrefixType(programHash[0]) {
-	case contract.PrefixStandard, contract.PrefixMultiSig, contract.PrefixCrossChain:
+	switch script.ClassifyProgramHash(programHash) {
+	case script.Destroy, script.Standard, script.MultiSig, script.CrossChain:
 		return true
 	}
 
@@ -247,6 +253,32 @@ func (v *Validator) checkTransactionUTXOLock(txn *types.Transaction, height uint
 	return nil
 }
 
+// checkTransactionSequenceLock checks the relative lock of the inputs, an
+// input can not be spent until the number of blocks given by its sequence
+// have been generated after the block containing the referenced output.
+func (v *Validator) checkTransactionSequenceLock(txn *types.Transaction, height uint32, mainChainHeight uint32) error {
+	if v.scriptFlags(height)&vm.ScriptEnableTimeLock == 0 {
+		return nil
+	}
+	for _, input := range txn.Inputs {
+		blocks, ok := types.RelativeLock(input.Sequence)
+		if !ok {
+			continue
+		}
+		_, referHeight, err := v.db.GetTransaction(input.Previous.TxID)
+		if err != nil {
+			str := fmt.Sprintf("[checkTransactionSequenceLock] GetTransaction failed: %s", err)
+			return ruleError(ErrUTXOLocked, str)
+		}
+		if height < referHeight+blocks {
+			str := fmt.Sprintf("[checkTransactionSequenceLock] UTXO locked until height %d",
+				referHeight+blocks)
+			return ruleError(ErrUTXOLocked, str)
+		}
+	}
+	return nil
+}
+
 func (v *Validator) checkTransactionSize(txn *types.Transaction, height uint32, mainChainHeight uint32) error {
 	size := txn.GetSize()
 	if size <= 0 || size > types.MaxBlockSize {
@@ -290,7 +322,7 @@ func (v *Validator) checkTransactionBalance(txn *types.Transaction, height uint3
 			return ruleError(ErrTransactionBalance, str)
 		}
 	}
-	results, err := v.txFeeHelper.GetTxFeeMap(txn)
+	results, err := v.txFeeHelper.GetTxFeeMap(txn, height)
 	if err != nil {
 		return ruleError(ErrTransactionBalance, err.Error())
 	}
@@ -337,39 +369,28 @@ func (v *Validator) checkTransactionDuplicate(txn *types.Transaction, height uin
 
 func (v *Validator) checkTransactionCoinBase(txn *types.Transaction, height uint32, mainChainHeight uint32) error {
 	if txn.IsCoinBaseTx() {
-		currentHeight := v.db.GetHeight()
-		if currentHeight >= v.chainParams.RewardMinerOnlyStartHeight {
-			if len(txn.Outputs) != 1 {
-				str := fmt.Sprint("[checkTransactionOutput] coinbase outputs count should be 1")
-				return ruleError(ErrInvalidOutput, str)
-			}
+		period := v.rewardPeriod(height)
+		if len(txn.Outputs) != len(period.Recipients)+1 {
+			str := fmt.Sprintf("[checkTransactionOutput] coinbase outputs count should be %d",
+				len(period.Recipients)+1)
+			return ruleError(ErrInvalidOutput, str)
+		}
 
-			for _, output := range txn.Outputs {
-				if output.AssetID != v.chainParams.ElaAssetId {
-					str := fmt.Sprint("[checkTransactionOutput] asset ID in coinbase is invalid")
-					return ruleError(ErrInvalidOutput, str)
-				}
-			}
-		} else {
-			if len(txn.Outputs) != 2 {
-				str := fmt.Sprint("[checkTransactionOutput] coinbase outputs count should be 2")
+		var totalReward = common.Fixed64(0)
+		var recipientRewards = make(map[common.Uint168]common.Fixed64)
+		for _, output := range txn.Outputs {
+			if output.AssetID != v.chainParams.ElaAssetId {
+				str := fmt.Sprint("[checkTransactionOutput] asset ID in coinbase is invalid")
 				return ruleError(ErrInvalidOutput, str)
 			}
+			totalReward += output.Value
+			recipientRewards[output.ProgramHash] += output.Value
+		}
 
-			var totalReward = common.Fixed64(0)
-			var foundationReward = common.Fixed64(0)
-			for _, output := range txn.Outputs {
-				if output.AssetID != v.chainParams.ElaAssetId {
-					str := fmt.Sprint("[checkTransactionOutput] asset ID in coinbase is invalid")
-					return ruleError(ErrInvalidOutput, str)
-				}
-				totalReward += output.Value
-				if output.ProgramHash.IsEqual(v.chainParams.Foundation) {
-					foundationReward += output.Value
-				}
-			}
-			if common.Fixed64(foundationReward) < common.Fixed64(float64(totalReward)*0.3) {
-				str := fmt.Sprint("[checkTransactionOutput] Reward to foundation in coinbase < 30%")
+		for _, recipient := range period.Recipients {
+			if recipientRewards[recipient.ProgramHash] < recipient.Share(totalReward) {
+				str := fmt.Sprintf("[checkTransactionOutput] Reward to %s in coinbase < %d%%",
+					recipient.ProgramHash.String(), recipient.Percentage)
 				return ruleError(ErrInvalidOutput, str)
 			}
 		}
@@ -389,7 +410,28 @@ func (v *Validator) checkTransactionDoubleSpend(txn *types.Transaction, height u
 	return nil
 }
 
+// SigCacheStats returns the metrics of the signature cache, the zero value is
+// returned if the signature cache is not enabled.
+func (v *Validator) SigCacheStats() SigCacheStats {
+	if v.sigCache == nil {
+		return SigCacheStats{}
+	}
+	return v.sigCache.Stats()
+}
+
+// CheckTransactionSignature verifies the signatures of a transaction, it can
+// be used as blockchain.Config.CheckTxSignature to verify the transactions of
+// a block concurrently.
+func (v *Validator) CheckTransactionSignature(tx *types.Transaction, height uint32, mainChainHeight uint32) error {
+	return v.checkTransactionSignature(tx, height, mainChainHeight)
+}
+
 func (v *Validator) checkTransactionSignature(tx *types.Transaction, height uint32, mainChainHeight uint32) error {
+	// Signatures of the block being accepted have been verified already.
+	if v.Chain != nil && v.Chain.IsSignatureVerified(tx) {
+		return nil
+	}
+
 	if tx.IsRechargeToSideChainTx() {
 		if err := v.spvService.VerifyTransaction(tx); err != nil {
 			return ruleError(ErrTransactionSignature, err.Error())
@@ -408,10 +450,14 @@ func (v *Validator) checkTransactionSignature(tx *types.Transaction, height uint
 		return ruleError(ErrTransactionSignature, err.Error())
 	}
 
-	if err := RunPrograms(tx, hashes, tx.Programs); err != nil {
+	if err := v.checkHTLCPrograms(hashes, tx.Programs, height); err != nil {
 		return ruleError(ErrTransactionSignature, err.Error())
 	}
 
+	if report, err := v.runPrograms(tx, height, hashes, tx.Programs); err != nil {
+		return programsRuleError(err, report)
+	}
+
 	return nil
 }
 
@@ -453,11 +499,6 @@ func (v *Validator) checkRechargeToSideChainTransaction(txn *types.Transaction,
 		return ruleError(ErrRechargeToSideChain, str)
 	}
 
-	if v.chainParams.ExchangeRate <= 0 {
-		str := fmt.Sprint("[checkRechargeToSideChainTransaction] Invalid config exchange rate")
-		return ruleError(ErrRechargeToSideChain, str)
-	}
-
 	mainChainTransaction := new(core.Transaction)
 	if txn.PayloadVersion == types.RechargeToSideChainPayloadVersion0 {
 		proof := new(bloom.MerkleProof)
@@ -478,6 +519,10 @@ func (v *Validator) checkRechargeToSideChainTransaction(txn *types.Transaction,
 			str := fmt.Sprint("[checkRechargeToSideChainTransaction] Get RechargeToSideChain transaction failed")
 			return ruleError(ErrRechargeToSideChain, str)
 		}
+		if err := v.checkDepositConfirmations(txn,
+			v.blockDepositConfirmations(height)); err != nil {
+			return err
+		}
 	} else {
 		str := fmt.Sprint("[checkRechargeToSideChainTransaction] Invalid payload version")
 		return ruleError(ErrRechargeToSideChain, str)
@@ -502,20 +547,43 @@ func (v *Validator) checkRechargeToSideChainTransaction(txn *types.Transaction,
 		return ruleError(ErrRechargeToSideChain, str)
 	}
 
-	//check output fee and rate
-	var oriOutputTotalAmount common.Fixed64
+	//check output fee and rate of every asset, before the cross chain assets
+	//are active the outputs of any asset are recharged as ELA
+	active := v.isDeploymentActive(config.DeploymentCrossChainAssets, height)
+	if !active && v.chainParams.ExchangeRate <= 0 {
+		str := fmt.Sprint("[checkRechargeToSideChainTransaction] Invalid config exchange rate")
+		return ruleError(ErrRechargeToSideChain, str)
+	}
+	assetOf := func(output *types.Output) common.Uint256 {
+		if !active {
+			return v.chainParams.ElaAssetId
+		}
+		return output.AssetID
+	}
+	oriOutputAmounts := make(map[common.Uint256]common.Fixed64)
 	for i := 0; i < len(payloadObj.CrossChainAddresses); i++ {
-		if mainChainTransaction.Outputs[payloadObj.OutputIndexes[i]].ProgramHash.IsEqual(*genesisProgramHash) {
+		mainChainOutput := mainChainTransaction.Outputs[payloadObj.OutputIndexes[i]]
+		if mainChainOutput.ProgramHash.IsEqual(*genesisProgramHash) {
+			asset := v.chainParams.GetCrossChainAsset(v.chainParams.ElaAssetId, false)
+			if active {
+				asset = v.chainParams.GetCrossChainAssetByMainChainAsset(mainChainOutput.AssetID, true)
+			}
+			if asset == nil {
+				str := fmt.Sprint("[checkRechargeToSideChainTransaction] Invalid transaction cross chain asset")
+				return ruleError(ErrRechargeToSideChain, str)
+			}
 			if payloadObj.CrossChainAmounts[i] < 0 || payloadObj.CrossChainAmounts[i] >
-				mainChainTransaction.Outputs[payloadObj.OutputIndexes[i]].Value-
-					common.Fixed64(v.chainParams.MinCrossChainTxFee) {
+				mainChainOutput.Value-asset.MinCrossChainTxFee {
 				str := fmt.Sprint("[checkRechargeToSideChainTransaction] Invalid transaction cross chain amount")
 				return ruleError(ErrRechargeToSideChain, str)
 			}
 
-			crossChainAmount := common.Fixed64(float64(payloadObj.CrossChainAmounts[i]) *
-				v.chainParams.ExchangeRate)
-			oriOutputTotalAmount += crossChainAmount
+			crossChainAmount, err := asset.Convert(payloadObj.CrossChainAmounts[i])
+			if err != nil {
+				str := fmt.Sprint("[checkRechargeToSideChainTransaction] Invalid transaction cross chain amount")
+				return ruleError(ErrRechargeToSideChain, str)
+			}
+			oriOutputAmounts[asset.AssetID] += crossChainAmount
 
 			programHash, err := common.Uint168FromAddress(payloadObj.CrossChainAddresses[i])
 			if err != nil {
@@ -524,7 +592,8 @@ func (v *Validator) checkRechargeToSideChainTransaction(txn *types.Transaction,
 			}
 			isContained := false
 			for _, output := range txn.Outputs {
-				if output.ProgramHash == *programHash && output.Value == crossChainAmount {
+				if output.ProgramHash == *programHash && assetOf(output) == asset.AssetID &&
+					output.Value == crossChainAmount {
 					isContained = true
 					break
 				}
@@ -536,16 +605,16 @@ func (v *Validator) checkRechargeToSideChainTransaction(txn *types.Transaction,
 		}
 	}
 
-	var targetOutputTotalAmount common.Fixed64
+	targetOutputAmounts := make(map[common.Uint256]common.Fixed64)
 	for _, output := range txn.Outputs {
 		if output.Value < 0 {
 			str := fmt.Sprint("[checkRechargeToSideChainTransaction] Invalid transaction output value")
 			return ruleError(ErrRechargeToSideChain, str)
 		}
-		targetOutputTotalAmount += output.Value
+		targetOutputAmounts[assetOf(output)] += output.Value
 	}
 
-	if targetOutputTotalAmount != oriOutputTotalAmount {
+	if !equalAssetAmounts(targetOutputAmounts, oriOutputAmounts) {
 		str := fmt.Sprint("[checkRechargeToSideChainTransaction] Output and fee verify failed")
 		return ruleError(ErrRechargeToSideChain, str)
 	}
@@ -553,6 +622,22 @@ func (v *Validator) checkRechargeToSideChainTransaction(txn *types.Transaction,
 	return ErrBreak
 }
 
+// equalAssetAmounts returns if the amounts of every asset are the same, the
+// assets not in the map are of amount zero.
+func equalAssetAmounts(a, b map[common.Uint256]common.Fixed64) bool {
+	for assetID, amount := range a {
+		if b[assetID] != amount {
+			return false
+		}
+	}
+	for assetID, amount := range b {
+		if a[assetID] != amount {
+			return false
+		}
+	}
+	return true
+}
+
 func (v *Validator) GetParams() *config.Params {
 	return v.chainParams
 }
@@ -561,6 +646,31 @@ func (v *Validator) GetFeeHelper() *FeeHelper {
 	return v.txFeeHelper
 }
 
+// checkDepositConfirmations checks the main chain transaction recharged by
+// the transaction of payload version 1 has the confirmations.
+func (v *Validator) checkDepositConfirmations(txn *types.Transaction, confirmations uint32) error {
+	if confirmations <= 1 || !txn.IsRechargeToSideChainTx() ||
+		txn.PayloadVersion != types.RechargeToSideChainPayloadVersion1 {
+		return nil
+	}
+	payloadRecharge, ok := txn.Payload.(*types.PayloadRechargeToSideChain)
+	if !ok {
+		str := fmt.Sprint("[checkDepositConfirmations] Invalid recharge to side chain payload type")
+		return ruleError(ErrRechargeToSideChain, str)
+	}
+
+	n, err := v.spvService.GetConfirmations(&payloadRecharge.MainChainTransactionHash, confirmations)
+	if err != nil {
+		str := fmt.Sprint("[checkDepositConfirmations] Get RechargeToSideChain transaction confirmations failed")
+		return ruleError(ErrRechargeToSideChain, str)
+	}
+	if n < confirmations {
+		str := fmt.Sprintf("[checkDepositConfirmations] Deposit has %d confirmations, %d needed", n, confirmations)
+		return ruleError(ErrDepositImmature, str)
+	}
+	return nil
+}
+
 func (v *Validator) checkTransferCrossChainAssetTransaction(txn *types.Transaction, height uint32, mainChainHeight uint32) error {
 	if !txn.IsTransferCrossChainAssetTx() {
 		return nil
@@ -571,6 +681,10 @@ func (v *Validator) checkTransferCrossChainAssetTransaction(txn *types.Transacti
 		str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transfer cross chain asset payload type")
 		return ruleError(ErrCrossChain, str)
 	}
+	if !v.isCrossChainPayloadVersionValid(txn.PayloadVersion, height) {
+		str := fmt.Sprintf("[checkTransferCrossChainAssetTransaction] Invalid transfer cross chain asset payload version %d", txn.PayloadVersion)
+		return ruleError(ErrCrossChain, str)
+	}
 	ca, _ := v.spvService.GetConsensusAlgorithm(mainChainHeight)
 	if ca == spvitf.POW {
 		str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Current consensus algorithm is POW")
@@ -585,6 +699,11 @@ func (v *Validator) checkTransferCrossChainAssetTransaction(txn *types.Transacti
 		return ruleError(ErrCrossChain, str)
 	}
 
+	if err := checkCrossChainTargetData(txn.PayloadVersion, payloadObj,
+		v.isDeploymentActive(config.DeploymentCrossChainTargetData, height)); err != nil {
+		return err
+	}
+
 	//check cross chain output index in payload
 	outputIndexMap := make(map[uint64]struct{})
 	for _, outputIndex := range payloadObj.OutputIndexes {
@@ -622,39 +741,67 @@ func (v *Validator) checkTransferCrossChainAssetTransaction(txn *types.Transacti
 		}
 	}
 
+	reference, err := v.db.GetTxReference(txn)
+	if err != nil {
+		str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transaction inputs")
+		return ruleError(ErrCrossChain, str)
+	}
+	return checkCrossChainAmounts(txn, payloadObj, reference, v.chainParams,
+		v.isDeploymentActive(config.DeploymentCrossChainAssets, height))
+}
+
+// checkCrossChainAmounts checks the amounts withdrawn by the transaction of
+// the inputs referenced, and the fee of every asset withdrawn. Before the
+// cross chain assets are active, the outputs of any asset are withdrawn as ELA
+// and the fee of all the assets together is checked.
+func checkCrossChainAmounts(txn *types.Transaction, payloadObj *types.PayloadTransferCrossChainAsset,
+	reference map[*types.Input]*types.Output, params *config.Params, active bool) error {
 	//check cross chain amount in payload
+	assets := make(map[common.Uint256]*config.CrossChainAsset)
 	for i := 0; i < len(payloadObj.OutputIndexes); i++ {
-		if !txn.Outputs[payloadObj.OutputIndexes[i]].ProgramHash.IsEqual(common.Uint168{}) {
+		output := txn.Outputs[payloadObj.OutputIndexes[i]]
+		if !output.ProgramHash.IsEqual(common.Uint168{}) {
 			str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transaction output program hash")
 			return ruleError(ErrCrossChain, str)
 		}
-		if txn.Outputs[payloadObj.OutputIndexes[i]].Value < 0 || payloadObj.CrossChainAmounts[i] < 0 ||
-			payloadObj.CrossChainAmounts[i] > txn.Outputs[payloadObj.OutputIndexes[i]].Value-
-				common.Fixed64(v.chainParams.MinCrossChainTxFee) {
+		asset := params.GetCrossChainAsset(params.ElaAssetId, false)
+		if active {
+			asset = params.GetCrossChainAsset(output.AssetID, true)
+		}
+		if asset == nil {
+			str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transaction cross chain asset")
+			return ruleError(ErrCrossChain, str)
+		}
+		assets[asset.AssetID] = asset
+		if output.Value < 0 || payloadObj.CrossChainAmounts[i] < 0 ||
+			payloadObj.CrossChainAmounts[i] > output.Value-asset.MinCrossChainTxFee {
 			str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transaction outputs")
 			return ruleError(ErrCrossChain, str)
 		}
 	}
 
-	//check transaction fee
-	var totalInput common.Fixed64
-	reference, err := v.db.GetTxReference(txn)
-	if err != nil {
-		str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transaction inputs")
-		return ruleError(ErrCrossChain, str)
+	//check transaction fee of every asset withdrawn
+	assetOf := func(output *types.Output) common.Uint256 {
+		if !active {
+			return params.ElaAssetId
+		}
+		return output.AssetID
 	}
-	for _, v := range reference {
-		totalInput += v.Value
+	totalInputs := make(map[common.Uint256]common.Fixed64)
+	for _, output := range reference {
+		totalInputs[assetOf(output)] += output.Value
 	}
 
-	var totalOutput common.Fixed64
+	totalOutputs := make(map[common.Uint256]common.Fixed64)
 	for _, output := range txn.Outputs {
-		totalOutput += output.Value
+		totalOutputs[assetOf(output)] += output.Value
 	}
 
-	if totalInput-totalOutput < common.Fixed64(v.chainParams.MinCrossChainTxFee) {
-		str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transaction fee")
-		return ruleError(ErrCrossChain, str)
+	for assetID, asset := range assets {
+		if totalInputs[assetID]-totalOutputs[assetID] < asset.MinCrossChainTxFee {
+			str := fmt.Sprint("[checkTransferCrossChainAssetTransaction] Invalid transaction fee")
+			return ruleError(ErrCrossChain, str)
+		}
 	}
 
 	return nil
@@ -670,13 +817,20 @@ func GenesisToProgramHash(genesisHash *common.Uint256) (*common.Uint168, error)
 }
 
 func (v *Validator) TxProgramHashes(tx *types.Transaction) ([]common.Uint168, error) {
+	return GetTxProgramHashes(v.db, tx)
+}
+
+// GetTxProgramHashes returns the program hashes the programs of the
+// transaction are verified against, which are the program hashes of the
+// referenced outputs and of the script attributes.
+func GetTxProgramHashes(db *blockchain.ChainStore, tx *types.Transaction) ([]common.Uint168, error) {
 	if tx == nil {
 		return nil, errors.New("[Transaction],GetProgramHashes transaction is nil.")
 	}
 	hashes := make([]common.Uint168, 0)
 	uniqueHashes := make([]common.Uint168, 0)
 	// add inputUTXO's transaction
-	references, err := v.db.GetTxReference(tx)
+	references, err := db.GetTxReference(tx)
 	if err != nil {
 		return nil, errors.New("[Transaction], GetProgramHashes failed.")
 	}
@@ -706,41 +860,278 @@ func (v *Validator) TxProgramHashes(tx *types.Transaction) ([]common.Uint168, er
 }
 
 func RunPrograms(tx *types.Transaction, hashes []common.Uint168, programs []*types.Program) error {
-	if tx == nil {
-		return errors.New("invalid data content nil transaction")
+	_, err := RunProgramsWithReport(tx, hashes, programs)
+	return err
+}
+
+// RunProgramsWithReport is RunPrograms returning the execution report of the
+// programs executed, the last one is the failed program if an error returned.
+func RunProgramsWithReport(tx *types.Transaction, hashes []common.Uint168, programs []*types.Program) (*ExecutionReport, error) {
+	return new(programRunner).run(tx, hashes, programs)
+}
+
+// runPrograms is RunProgramsWithReport with the interop services and the
+// script flags of the validator at the height, skipping the programs found
+// in the signature cache.
+func (v *Validator) runPrograms(tx *types.Transaction, height uint32, hashes []common.Uint168, programs []*types.Program) (*ExecutionReport, error) {
+	runner := v.programRunner(height)
+	runner.sigCache = v.sigCache
+	return runner.run(tx, hashes, programs)
+}
+
+// TracePrograms is TracePrograms with the interop services, limits and
+// script flags the validator runs the programs with at the height.
+func (v *Validator) TracePrograms(tx *types.Transaction, height uint32, hashes []common.Uint168, programs []*types.Program) ([]*ProgramTrace, error) {
+	return v.programRunner(height).trace(tx, hashes, programs)
+}
+
+// programRunner returns the runner of the programs of the transactions at
+// the height, which does not use the signature cache.
+func (v *Validator) programRunner(height uint32) *programRunner {
+	return &programRunner{
+		service: v.interopService,
+		limits:  v.chainParams.VMLimits,
+		flags:   v.scriptFlags(height),
+		height:  height,
 	}
-	if len(hashes) != len(programs) {
-		return errors.New("number of data hashes is different with number of programs")
+}
+
+// isDeploymentActive returns if the named deployment is active for the block
+// at the height, only the deployments activated at a fixed height are active
+// if the validator has no chain.
+func (v *Validator) isDeploymentActive(name string, height uint32) bool {
+	return isDeploymentActive(v.Chain, v.chainParams, name, height)
+}
+
+// isDeploymentActive returns if the named deployment is active for the block
+// at the height, only the deployments activated at a fixed height of the
+// params are active if there is no chain.
+func isDeploymentActive(chain *blockchain.BlockChain, params *config.Params,
+	name string, height uint32) bool {
+	if chain != nil {
+		return chain.IsDeploymentActiveAtHeight(name, height)
 	}
+	deployment := params.GetDeployment(name)
+	return deployment != nil && deployment.IsActiveAtHeight(height)
+}
 
-	for i := 0; i < len(programs); i++ {
-		codeHash := common.ToCodeHash(programs[i].Code)
+// rewardPeriod returns the reward period of the coinbase at the height.
+func (v *Validator) rewardPeriod(height uint32) *config.RewardPeriod {
+	if v.Chain != nil {
+		return v.Chain.GetRewardPeriod(height)
+	}
+	return v.chainParams.GetRewardPeriod(height)
+}
+
+// blockDepositConfirmations returns the minimum main chain confirmations of
+// the deposits recharged by the transactions in the block of the height.
+func (v *Validator) blockDepositConfirmations(height uint32) uint32 {
+	if !v.isDeploymentActive(config.DeploymentDepositConfirmations, height) {
+		return 0
+	}
+	return v.chainParams.DepositConfirmations
+}
 
-		if !hashes[i].ToCodeHash().IsEqual(*codeHash) {
-			return errors.New("data hash is different from corresponding program code")
+// scriptFlags returns the opcodes enabled at the height.
+func (v *Validator) scriptFlags(height uint32) vm.ScriptFlags {
+	var flags vm.ScriptFlags
+	if v.isDeploymentActive(config.DeploymentTimeLock, height) {
+		flags |= vm.ScriptEnableTimeLock
+	}
+	if v.isDeploymentActive(config.DeploymentHTLC, height) {
+		flags |= vm.ScriptEnableHashLock
+	}
+	if v.isDeploymentActive(config.DeploymentSchnorr, height) {
+		flags |= vm.ScriptEnableSchnorr
+	}
+	if v.isDeploymentActive(config.DeploymentVMLimits, height) {
+		flags |= vm.ScriptEnableLimits
+	}
+	return flags
+}
+
+// isCrossChainPayloadVersionValid returns if the transfer cross chain asset
+// payload of the version is accepted at the height. All the versions are
+// accepted before the height of CrossChainPayloadV1StartHeight, as they were
+// never checked, except the versions above the one of the target data once it
+// is active.
+func (v *Validator) isCrossChainPayloadVersionValid(version byte, height uint32) bool {
+	if !v.isDeploymentActive(config.DeploymentCrossChainPayloadV1, height) {
+		return version <= types.TransferCrossChainAssetVersion2 ||
+			!v.isDeploymentActive(config.DeploymentCrossChainTargetData, height)
+	}
+	switch version {
+	case types.TransferCrossChainAssetVersion0:
+		return true
+	case types.TransferCrossChainAssetVersion1:
+		return v.isDeploymentActive(config.DeploymentCrossChainPayloadV1, height)
+	case types.TransferCrossChainAssetVersion2:
+		return v.isDeploymentActive(config.DeploymentCrossChainTargetData, height)
+	}
+	return false
+}
+
+// checkCrossChainTargetData checks the payload carrying the target data has
+// the target data of every cross chain output within the size limit, and the
+// other ones have none. The target data is accepted only if it is active.
+func checkCrossChainTargetData(version byte, p *types.PayloadTransferCrossChainAsset, active bool) error {
+	if !p.HasTargetData(version) {
+		if len(p.TargetData) != 0 {
+			str := fmt.Sprint("[checkCrossChainTargetData] Target data not supported by the payload version")
+			return ruleError(ErrCrossChain, str)
 		}
-		//execute program on VM
-		se := vm.NewExecutionEngine(types.GetDataContainer(&hashes[i], tx),
-			new(vm.CryptoECDsa), vm.MAXSTEPS, nil, nil)
-		se.LoadScript(programs[i].Code, false)
-		se.LoadScript(programs[i].Parameter, true)
-		se.Execute()
+		return nil
+	}
+	if !active {
+		str := fmt.Sprint("[checkCrossChainTargetData] Target data not active")
+		return ruleError(ErrCrossChain, str)
+	}
 
-		if se.GetState() != vm.HALT {
-			return errors.New("[VM] Finish State not equal to HALT")
+	if len(p.TargetData) != len(p.CrossChainAddresses) {
+		str := fmt.Sprint("[checkCrossChainTargetData] Invalid transaction cross chain target data count")
+		return ruleError(ErrCrossChain, str)
+	}
+	for _, data := range p.TargetData {
+		if len(data) > types.MaxCrossChainTargetDataSize {
+			str := fmt.Sprintf("[checkCrossChainTargetData] Target data size %d exceeds the limit %d",
+				len(data), types.MaxCrossChainTargetDataSize)
+			return ruleError(ErrCrossChain, str)
 		}
+	}
+	return nil
+}
+
+// isHTLCEnabled returns if the hash time locked contracts are enabled at the
+// height.
+func (v *Validator) isHTLCEnabled(height uint32) bool {
+	flags := vm.ScriptEnableTimeLock | vm.ScriptEnableHashLock
+	return v.scriptFlags(height)&flags == flags
+}
 
-		if se.GetEvaluationStack().Count() != 1 {
-			return errors.New("[VM] Execute Engine Stack Count Error")
+// checkHTLCPrograms checks the programs spending the outputs of hash time
+// locked contracts are created by htlc.Contract.
+func (v *Validator) checkHTLCPrograms(hashes []common.Uint168, programs []*types.Program, height uint32) error {
+	for i, hash := range hashes {
+		if !htlc.IsHTLCProgramHash(hash) {
+			continue
+		}
+		if !v.isHTLCEnabled(height) {
+			return errors.New("hash time locked contract is not enabled")
+		}
+		if i >= len(programs) {
+			return errors.New("number of data hashes is different with number of programs")
 		}
+		if _, err := htlc.ParseRedeemScript(programs[i].Code); err != nil {
+			return fmt.Errorf("invalid hash time locked contract: %s", err)
+		}
+	}
+	return nil
+}
+
+// programRunner executes the programs of transactions.
+type programRunner struct {
+	// sigCache keeps the programs executed successfully, programs found in
+	// it are not executed again.
+	sigCache *SigCache
+
+	// service is the interop services of the engine, the default services
+	// are used if it is nil.
+	service *vm.GeneralService
+
+	// limits bounds the stacks of the engine if flags enables them,
+	// vm.DefaultLimits is used if it is nil.
+	limits *vm.Limits
+
+	// flags enables the opcodes activated at the height of the transaction.
+	flags vm.ScriptFlags
+
+	// height is the height the transaction is validated at.
+	height uint32
+}
+
+func (r *programRunner) run(tx *types.Transaction, hashes []common.Uint168, programs []*types.Program) (*ExecutionReport, error) {
+	report := new(ExecutionReport)
+	if tx == nil {
+		return report, errors.New("invalid data content nil transaction")
+	}
+	if len(hashes) != len(programs) {
+		return report, errors.New("number of data hashes is different with number of programs")
+	}
 
-		success := se.GetExecuteResult()
-		if !success {
-			return errors.New("[VM] Check Sig FALSE")
+	var txHash common.Uint256
+	if r.sigCache != nil {
+		txHash = tx.Hash()
+	}
+	for i := 0; i < len(programs); i++ {
+		if r.sigCache != nil && r.sigCache.Exists(txHash, i, hashes[i], programs[i], r.flags) {
+			report.Programs = append(report.Programs, &ProgramReport{
+				Index: i, ProgramHash: hashes[i], Cached: true, Position: -1})
+			continue
+		}
+		programReport := r.runProgram(tx, hashes[i], programs[i])
+		programReport.Index = i
+		report.Programs = append(report.Programs, programReport)
+		if programReport.Error != nil {
+			return report, programReport.Error
+		}
+		// The programs calling the interop services are not cached, the
+		// results of a height are not valid at the others.
+		if r.sigCache != nil && !programReport.SysCall {
+			r.sigCache.Add(txHash, i, hashes[i], programs[i], r.flags)
 		}
 	}
 
-	return nil
+	return report, nil
+}
+
+// newEngine creates the execution engine of the program of the hash in the
+// transaction, with the interop services, script flags, limits and height of
+// the runner.
+func (r *programRunner) newEngine(tx *types.Transaction, hash common.Uint168) *vm.ExecutionEngine {
+	se := vm.NewExecutionEngine(types.GetDataContainer(&hash, tx),
+		new(vm.CryptoECDsa), vm.MAXSTEPS, nil, r.service)
+	se.SetFlags(r.flags)
+	se.SetHeight(r.height)
+	if r.limits != nil {
+		se.SetLimits(*r.limits)
+	}
+	return se
+}
+
+func (r *programRunner) runProgram(tx *types.Transaction, hash common.Uint168, program *types.Program) *ProgramReport {
+	report := &ProgramReport{ProgramHash: hash, Position: -1}
+	codeHash := common.ToCodeHash(program.Code)
+
+	if !hash.ToCodeHash().IsEqual(*codeHash) {
+		report.Error = errors.New("data hash is different from corresponding program code")
+		return report
+	}
+	//execute program on VM
+	se := r.newEngine(tx, hash)
+	se.SetTracer(report.trace)
+	se.LoadScript(program.Code, false)
+	se.LoadScript(program.Parameter, true)
+	se.Execute()
+	report.Steps = se.GetOpCount()
+	report.State = se.GetState()
+
+	if se.GetState() != vm.HALT {
+		report.Error = errors.New("[VM] Finish State not equal to HALT")
+		return report
+	}
+
+	if se.GetEvaluationStack().Count() != 1 {
+		report.Error = errors.New("[VM] Execute Engine Stack Count Error")
+		return report
+	}
+
+	success := se.GetExecuteResult()
+	if !success {
+		report.Error = errors.New("[VM] Check Sig FALSE")
+		return report
+	}
+
+	return report
 }
 
 func SortPrograms(programs []*types.Program) (err error) {
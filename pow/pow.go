@@ -369,18 +369,22 @@ func CreateCoinBaseTx(cfg *Config, nextBlockHeight uint32, addr string) (*types.
 			Sequence: math.MaxUint32,
 		},
 	}
-	txn.Outputs = []*types.Output{
-		{
-			AssetID:     cfg.ChainParams.ElaAssetId,
-			Value:       0,
-			ProgramHash: cfg.ChainParams.Foundation,
-		},
-		{
+	// Outputs of the reward recipients go first, the miner output is the
+	// last one.
+	period := cfg.Chain.GetRewardPeriod(nextBlockHeight)
+	txn.Outputs = make([]*types.Output, 0, len(period.Recipients)+1)
+	for _, recipient := range period.Recipients {
+		txn.Outputs = append(txn.Outputs, &types.Output{
 			AssetID:     cfg.ChainParams.ElaAssetId,
 			Value:       0,
-			ProgramHash: *minerProgramHash,
-		},
+			ProgramHash: recipient.ProgramHash,
+		})
 	}
+	txn.Outputs = append(txn.Outputs, &types.Output{
+		AssetID:     cfg.ChainParams.ElaAssetId,
+		Value:       0,
+		ProgramHash: *minerProgramHash,
+	})
 
 	nonce := make([]byte, 8)
 	binary.BigEndian.PutUint64(nonce, rand.Uint64())
@@ -417,9 +421,14 @@ func GenerateBlock(cfg *Config) (*types.Block, error) {
 		return nil, err
 	}
 
+	version, err := cfg.Chain.CalcNextBlockVersion()
+	if err != nil {
+		return nil, err
+	}
+
 	header := types.Header{
 		Base: types.BaseHeader{
-			Version:    0,
+			Version:    version,
 			Previous:   *cfg.Chain.BestChain.Hash,
 			MerkleRoot: common.EmptyHash,
 			Timestamp:  uint32(cfg.Chain.MedianAdjustedTime().Unix()),
@@ -485,7 +494,8 @@ func GenerateBlockTransactions(cfg *Config, msgBlock *types.Block, coinBaseTx *t
 			continue
 		}
 
-		fee, err := cfg.TxFeeHelper.GetTxFee(tx, cfg.ChainParams.ElaAssetId)
+		fee, err := cfg.TxFeeHelper.GetTxFee(tx, cfg.ChainParams.ElaAssetId,
+			msgBlock.GetHeight())
 		if err != nil || fee != tx.Fee {
 			continue
 		}
@@ -494,13 +504,14 @@ func GenerateBlockTransactions(cfg *Config, msgBlock *types.Block, coinBaseTx *t
 		txCount++
 	}
 
-	reward := totalFee
-	if msgBlock.GetHeight() > cfg.ChainParams.RewardMinerOnlyStartHeight {
-		msgBlock.Transactions[0].Outputs[1].Value = reward
-		msgBlock.Transactions[0].Outputs = msgBlock.Transactions[0].Outputs[1:]
-	} else {
-		rewardFoundation := common.Fixed64(float64(reward) * 0.3)
-		msgBlock.Transactions[0].Outputs[0].Value = rewardFoundation
-		msgBlock.Transactions[0].Outputs[1].Value = common.Fixed64(reward) - rewardFoundation
+	period := cfg.Chain.GetRewardPeriod(msgBlock.GetHeight())
+	reward := totalFee + period.Subsidy
+	outputs := msgBlock.Transactions[0].Outputs
+	rewardMiner := reward
+	for i, recipient := range period.Recipients {
+		share := recipient.Share(reward)
+		outputs[i].Value = share
+		rewardMiner -= share
 	}
+	outputs[len(outputs)-1].Value = rewardMiner
 }
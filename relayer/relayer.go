@@ -0,0 +1,272 @@
+// Package relayer implements the automatic relaying of the deposits of the
+// main chain. The relayer is opt-in, the node enables it by registering
+// Relayer.Notify as a deposit handler of the SPV service and starting the
+// relayer, then the recharge transaction of every deposit notified by the SPV
+// module is created and sent to the transaction pool, instead of waiting for
+// an external party to call SendRechargeToSideChainTxByHash.
+package relayer
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/mempool"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	ela "github.com/elastos/Elastos.ELA/core/types"
+)
+
+const (
+	// DefaultRetryInterval is the default interval between the attempts to
+	// send the recharge transactions not in the chain yet.
+	DefaultRetryInterval = time.Minute
+
+	// DefaultMaxRetries is the default number of failed attempts after which
+	// a deposit is marked as failed.
+	DefaultMaxRetries = 10
+
+	// notifyQueueSize is the size of the queue of the notified deposits, the
+	// deposits not queued are relayed by the next retry.
+	notifyQueueSize = 1000
+)
+
+type Config struct {
+	// ChainStore is the store of the deposit records.
+	ChainStore *blockchain.ChainStore
+
+	// CreateRechargeTx creates the recharge transaction of the deposit.
+	CreateRechargeTx func(tx *ela.Transaction) (*types.Transaction, error)
+
+	// GetMainChainTx returns the deposit transaction of the main chain by
+	// its hash.
+	GetMainChainTx func(hash *common.Uint256) (*ela.Transaction, error)
+
+	// SendTx appends the recharge transaction to the transaction pool and
+	// relays it to the peers.
+	SendTx func(tx *types.Transaction) error
+
+	// HaveTx returns if the transaction is in the transaction pool.
+	HaveTx func(hash common.Uint256) bool
+
+	// RetryInterval is the interval between the retries, DefaultRetryInterval
+	// is used if it is zero.
+	RetryInterval time.Duration
+
+	// MaxRetries is the number of failed attempts after which a deposit is
+	// marked as failed, DefaultMaxRetries is used if it is zero.
+	MaxRetries uint32
+}
+
+// Relayer creates and sends the recharge transactions of the deposits, and
+// tracks their status in the chain store.
+type Relayer struct {
+	cfg    Config
+	mtx    sync.Mutex
+	notify chan *ela.Transaction
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Notify is the deposit handler to register to the SPV service. The deposits
+// already recharged or tracked are ignored.
+func (r *Relayer) Notify(tx *ela.Transaction) {
+	hash := tx.Hash()
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, err := r.cfg.ChainStore.GetDeposit(hash); err == nil {
+		return
+	}
+	if r.cfg.ChainStore.IsDuplicateMainchainTx(hash) {
+		return
+	}
+
+	record := &blockchain.DepositRecord{
+		MainChainTxHash: hash,
+		Status:          blockchain.DepositPending,
+	}
+	if err := r.cfg.ChainStore.PersistDeposit(record); err != nil {
+		log.Errorf("persist deposit %s failed, %s", hash, err)
+		return
+	}
+
+	select {
+	case r.notify <- tx:
+	default:
+	}
+}
+
+// Start starts relaying the notified deposits, and retrying the ones
+// pending or sent but not in the chain yet.
+func (r *Relayer) Start() {
+	r.wg.Add(1)
+	go r.relayHandler()
+}
+
+// Stop stops the relayer and waits for the relaying in progress.
+func (r *Relayer) Stop() {
+	close(r.quit)
+	r.wg.Wait()
+}
+
+func (r *Relayer) relayHandler() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.cfg.RetryInterval)
+	defer ticker.Stop()
+
+	r.retry()
+	for {
+		select {
+		case tx := <-r.notify:
+			r.relay(tx.Hash(), tx)
+
+		case <-ticker.C:
+			r.retry()
+
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// retry relays the deposits pending or sent, and checks the failed ones are
+// recharged by others.
+func (r *Relayer) retry() {
+	records, err := r.cfg.ChainStore.GetDeposits()
+	if err != nil {
+		log.Errorf("get deposits failed, %s", err)
+		return
+	}
+
+	for _, record := range records {
+		switch record.Status {
+		case blockchain.DepositPending, blockchain.DepositSent,
+			blockchain.DepositFailed:
+			r.relay(record.MainChainTxHash, nil)
+		}
+	}
+}
+
+// relay sends the recharge transaction of the deposit if it is not in the
+// chain or the transaction pool, the deposit transaction is got by
+// GetMainChainTx if tx is nil.
+func (r *Relayer) relay(hash common.Uint256, tx *ela.Transaction) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	record, err := r.cfg.ChainStore.GetDeposit(hash)
+	if err != nil {
+		return
+	}
+
+	if record.Status == blockchain.DepositConfirmed {
+		return
+	}
+
+	// The deposit failed here may be recharged by others.
+	if r.cfg.ChainStore.IsDuplicateMainchainTx(hash) {
+		record.Status = blockchain.DepositConfirmed
+		r.persist(record)
+		return
+	}
+
+	switch record.Status {
+	case blockchain.DepositFailed:
+		return
+	case blockchain.DepositSent:
+		if r.cfg.HaveTx(record.RechargeTxHash) {
+			return
+		}
+	}
+
+	rechargeTx, err := r.createRechargeTx(hash, tx)
+	if err == nil && !r.cfg.HaveTx(rechargeTx.Hash()) {
+		err = r.cfg.SendTx(rechargeTx)
+	}
+
+	// The recharge transaction of the deposit not reaching the confirmations
+	// is held by the transaction pool, which is not a failed attempt.
+	if isDepositImmature(err) {
+		log.Debugf("recharge tx of deposit %s held, %s", hash, err)
+		record.LastError = err.Error()
+		r.persist(record)
+		return
+	}
+
+	record.Attempts++
+	if err != nil {
+		r.fail(record, err)
+		return
+	}
+
+	rechargeHash := rechargeTx.Hash()
+	log.Infof("recharge tx %s of deposit %s sent", rechargeHash, hash)
+	record.Status = blockchain.DepositSent
+	record.RechargeTxHash = rechargeHash
+	record.LastError = ""
+	r.persist(record)
+}
+
+func (r *Relayer) createRechargeTx(hash common.Uint256,
+	tx *ela.Transaction) (*types.Transaction, error) {
+	if tx == nil {
+		var err error
+		tx, err = r.cfg.GetMainChainTx(&hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tx.Hash() != hash {
+		return nil, errors.New("deposit transaction hash mismatch")
+	}
+
+	return r.cfg.CreateRechargeTx(tx)
+}
+
+// isDepositImmature returns if the error is the rejection of the recharge
+// transaction whose deposit has not reached the confirmations yet.
+func isDepositImmature(err error) bool {
+	e, ok := err.(mempool.RuleError)
+	return ok && e.ErrorCode == mempool.ErrDepositImmature
+}
+
+// fail records the error of the attempt, and marks the deposit as failed if
+// the attempts reach the max retries.
+func (r *Relayer) fail(record *blockchain.DepositRecord, err error) {
+	log.Warnf("relay deposit %s failed, attempts %d, %s",
+		record.MainChainTxHash, record.Attempts, err)
+	record.LastError = err.Error()
+	if record.Attempts >= r.cfg.MaxRetries {
+		record.Status = blockchain.DepositFailed
+	}
+	r.persist(record)
+}
+
+func (r *Relayer) persist(record *blockchain.DepositRecord) {
+	if err := r.cfg.ChainStore.PersistDeposit(record); err != nil {
+		log.Errorf("persist deposit %s failed, %s",
+			record.MainChainTxHash, err)
+	}
+}
+
+// New creates a relayer with the config, the defaults are used for the
+// retry interval and max retries not set.
+func New(cfg *Config) *Relayer {
+	r := Relayer{
+		cfg:    *cfg,
+		notify: make(chan *ela.Transaction, notifyQueueSize),
+		quit:   make(chan struct{}),
+	}
+	if r.cfg.RetryInterval == 0 {
+		r.cfg.RetryInterval = DefaultRetryInterval
+	}
+	if r.cfg.MaxRetries == 0 {
+		r.cfg.MaxRetries = DefaultMaxRetries
+	}
+	return &r
+}
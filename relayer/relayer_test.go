@@ -0,0 +1,291 @@
+package relayer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.SideChain/auxpow"
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/mempool"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	ela "github.com/elastos/Elastos.ELA/core/types"
+	"github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestChainStore(t *testing.T) (*blockchain.ChainStore, func()) {
+	dir, err := ioutil.TempDir("", "relayer")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	genesis := &types.Block{
+		Header: &types.Header{
+			SideAuxPow: *auxpow.GenerateSideAuxPow(common.EmptyHash,
+				common.EmptyHash),
+		},
+		Transactions: []*types.Transaction{{
+			TxType:  types.CoinBase,
+			Payload: new(types.PayloadCoinBase),
+		}},
+	}
+	store, err := blockchain.NewChainStore(dir, genesis)
+	if !assert.NoError(t, err) {
+		os.RemoveAll(dir)
+		t.FailNow()
+	}
+	return store, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func newDeposit(nonce byte) *ela.Transaction {
+	return &ela.Transaction{
+		TxType:  ela.TransferCrossChainAsset,
+		Payload: new(payload.TransferCrossChainAsset),
+		Attributes: []*ela.Attribute{
+			{Usage: ela.Nonce, Data: []byte{nonce}},
+		},
+	}
+}
+
+// testPool is the transaction pool of the tests, sending fails until fails
+// is zero, and the deposits are immature if immature is true.
+type testPool struct {
+	txs      map[common.Uint256]*types.Transaction
+	fails    int
+	immature bool
+}
+
+func (p *testPool) sendTx(tx *types.Transaction) error {
+	if p.immature {
+		return mempool.RuleError{ErrorCode: mempool.ErrDepositImmature,
+			Description: "deposit immature"}
+	}
+	if p.fails > 0 {
+		p.fails--
+		return errors.New("send failed")
+	}
+	p.txs[tx.Hash()] = tx
+	return nil
+}
+
+func (p *testPool) haveTx(hash common.Uint256) bool {
+	_, ok := p.txs[hash]
+	return ok
+}
+
+func newTestRelayer(store *blockchain.ChainStore, pool *testPool,
+	deposits map[common.Uint256]*ela.Transaction) *Relayer {
+	return New(&Config{
+		ChainStore: store,
+		CreateRechargeTx: func(tx *ela.Transaction) (*types.Transaction, error) {
+			return &types.Transaction{
+				TxType:         types.RechargeToSideChain,
+				PayloadVersion: types.RechargeToSideChainPayloadVersion1,
+				Payload: &types.PayloadRechargeToSideChain{
+					MainChainTransactionHash: tx.Hash(),
+				},
+			}, nil
+		},
+		GetMainChainTx: func(hash *common.Uint256) (*ela.Transaction, error) {
+			tx, ok := deposits[*hash]
+			if !ok {
+				return nil, errors.New("transaction not found")
+			}
+			return tx, nil
+		},
+		SendTx:        pool.sendTx,
+		HaveTx:        pool.haveTx,
+		RetryInterval: time.Hour,
+		MaxRetries:    3,
+	})
+}
+
+func TestRelayer_Relay(t *testing.T) {
+	store, cleanup := newTestChainStore(t)
+	defer cleanup()
+
+	pool := &testPool{txs: map[common.Uint256]*types.Transaction{}, fails: 1}
+	deposit := newDeposit(1)
+	hash := deposit.Hash()
+	r := newTestRelayer(store, pool,
+		map[common.Uint256]*ela.Transaction{hash: deposit})
+
+	// The deposit is tracked as pending once notified.
+	r.Notify(deposit)
+	record, err := store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositPending, record.Status)
+
+	// The failed attempt is recorded and retried.
+	r.relay(hash, <-r.notify)
+	record, err = store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositPending, record.Status)
+	assert.Equal(t, uint32(1), record.Attempts)
+	assert.Equal(t, "send failed", record.LastError)
+
+	r.retry()
+	record, err = store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositSent, record.Status)
+	assert.Equal(t, uint32(2), record.Attempts)
+	assert.Equal(t, "", record.LastError)
+	assert.True(t, pool.haveTx(record.RechargeTxHash))
+
+	// The recharge transaction in the pool is not sent again.
+	r.retry()
+	record, err = store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), record.Attempts)
+
+	// The recharge transaction dropped from the pool is sent again.
+	delete(pool.txs, record.RechargeTxHash)
+	r.retry()
+	record, err = store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositSent, record.Status)
+	assert.Equal(t, uint32(3), record.Attempts)
+	assert.True(t, pool.haveTx(record.RechargeTxHash))
+
+	// Notifying the deposit again does nothing.
+	r.Notify(deposit)
+	assert.Equal(t, 0, len(r.notify))
+
+	// The deposit is confirmed once recharged in the chain.
+	batch := store.NewBatch()
+	store.PersistMainchainTx(batch, hash)
+	assert.NoError(t, batch.Commit())
+	r.retry()
+	record, err = store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositConfirmed, record.Status)
+	assert.Equal(t, uint32(3), record.Attempts)
+
+	// The deposit already recharged is not tracked.
+	recharged := newDeposit(4)
+	batch = store.NewBatch()
+	store.PersistMainchainTx(batch, recharged.Hash())
+	assert.NoError(t, batch.Commit())
+	r.Notify(recharged)
+	_, err = store.GetDeposit(recharged.Hash())
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(r.notify))
+}
+
+func TestRelayer_Failed(t *testing.T) {
+	store, cleanup := newTestChainStore(t)
+	defer cleanup()
+
+	pool := &testPool{txs: map[common.Uint256]*types.Transaction{}}
+	deposit := newDeposit(2)
+	hash := deposit.Hash()
+	r := newTestRelayer(store, pool, map[common.Uint256]*ela.Transaction{})
+
+	// The deposit not found is marked as failed after the max retries.
+	r.Notify(deposit)
+	<-r.notify
+	for i := 0; i < 5; i++ {
+		r.retry()
+	}
+	record, err := store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositFailed, record.Status)
+	assert.Equal(t, uint32(3), record.Attempts)
+	assert.Equal(t, "transaction not found", record.LastError)
+	assert.Equal(t, 0, len(pool.txs))
+}
+
+func TestRelayer_Immature(t *testing.T) {
+	store, cleanup := newTestChainStore(t)
+	defer cleanup()
+
+	pool := &testPool{txs: map[common.Uint256]*types.Transaction{},
+		immature: true}
+	deposit := newDeposit(5)
+	hash := deposit.Hash()
+	r := newTestRelayer(store, pool,
+		map[common.Uint256]*ela.Transaction{hash: deposit})
+
+	// The deposit not reaching the confirmations is not failed whatever the
+	// retries are.
+	r.Notify(deposit)
+	<-r.notify
+	for i := 0; i < 5; i++ {
+		r.retry()
+	}
+	record, err := store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositPending, record.Status)
+	assert.Equal(t, uint32(0), record.Attempts)
+	assert.Equal(t, "deposit immature", record.LastError)
+
+	// It is sent once it reaches the confirmations.
+	pool.immature = false
+	r.retry()
+	record, err = store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositSent, record.Status)
+	assert.Equal(t, uint32(1), record.Attempts)
+	assert.Equal(t, "", record.LastError)
+}
+
+func TestRelayer_FailedRecharged(t *testing.T) {
+	store, cleanup := newTestChainStore(t)
+	defer cleanup()
+
+	pool := &testPool{txs: map[common.Uint256]*types.Transaction{}}
+	deposit := newDeposit(6)
+	hash := deposit.Hash()
+	r := newTestRelayer(store, pool, map[common.Uint256]*ela.Transaction{})
+
+	r.Notify(deposit)
+	<-r.notify
+	for i := 0; i < 3; i++ {
+		r.retry()
+	}
+	record, err := store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositFailed, record.Status)
+
+	// The failed deposit recharged by others is confirmed.
+	batch := store.NewBatch()
+	store.PersistMainchainTx(batch, hash)
+	assert.NoError(t, batch.Commit())
+	r.retry()
+	record, err = store.GetDeposit(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositConfirmed, record.Status)
+}
+
+func TestRelayer_StartStop(t *testing.T) {
+	store, cleanup := newTestChainStore(t)
+	defer cleanup()
+
+	pool := &testPool{txs: map[common.Uint256]*types.Transaction{}}
+	r := newTestRelayer(store, pool, map[common.Uint256]*ela.Transaction{})
+	r.Start()
+
+	deposit := newDeposit(3)
+	r.Notify(deposit)
+	for i := 0; i < 100; i++ {
+		record, err := store.GetDeposit(deposit.Hash())
+		if err == nil && record.Status == blockchain.DepositSent {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.Stop()
+
+	record, err := store.GetDeposit(deposit.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, blockchain.DepositSent, record.Status)
+	assert.Equal(t, 1, len(pool.txs))
+}
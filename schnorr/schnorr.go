@@ -0,0 +1,241 @@
+/*
+Package schnorr implements the Schnorr signatures verified by the
+CHECKSCHNORRSIG and CHECKMULTISCHNORRSIG opcodes.
+
+The signatures are on the curve of the ELA ECDSA signatures, so the same key
+pairs and encoded public keys are used. A signature is the 64 bytes
+r || s of big-endian integers, where r is the x coordinate of the nonce point
+R whose y coordinate is even, and
+
+	s = k + e * d mod N
+	e = SHA256(r || compressed public key || SHA256(data)) mod N
+
+with k the nonce of R and d the private key. The signature is verified by
+checking R = s * G - e * P, and a batch of signatures is verified at once by
+checking a random linear combination of the equations of all signatures.
+*/
+package schnorr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+const (
+	// SignatureLength is the length of the Schnorr signatures.
+	SignatureLength = 64
+
+	// scalarLength is the length of the encoded integers.
+	scalarLength = 32
+)
+
+var (
+	curve  = crypto.DefaultCurve
+	params = crypto.DefaultParams
+
+	// sqrtExp is the exponent of the square roots modulo P, which is
+	// (P + 1) / 4 since P = 3 mod 4.
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(params.P, big.NewInt(1)), 2)
+)
+
+var (
+	// ErrInvalidSignature is returned if a signature is not valid.
+	ErrInvalidSignature = errors.New("invalid schnorr signature")
+
+	// ErrInvalidPublicKey is returned if a public key can not be decoded.
+	ErrInvalidPublicKey = errors.New("invalid public key")
+
+	// ErrInvalidPrivateKey is returned if a private key is out of range.
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+)
+
+// Sign returns the Schnorr signature of the data by the private key. The
+// nonce is derived from the private key and the data, so the signature is
+// deterministic.
+func Sign(privateKey []byte, data []byte) ([]byte, error) {
+	d := new(big.Int).SetBytes(privateKey)
+	if d.Sign() == 0 || d.Cmp(params.N) >= 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	pubKey, err := crypto.NewPubKey(privateKey).EncodePoint(true)
+	if err != nil {
+		return nil, err
+	}
+	msg := sha256.Sum256(data)
+
+	nonce := sha256.Sum256(concat(scalarBytes(d), pubKey, msg[:]))
+	k := new(big.Int).Mod(new(big.Int).SetBytes(nonce[:]), params.N)
+	if k.Sign() == 0 {
+		return nil, errors.New("invalid nonce")
+	}
+	rx, ry := curve.ScalarBaseMult(scalarBytes(k))
+	if ry.Bit(0) == 1 {
+		k.Sub(params.N, k)
+	}
+
+	r := scalarBytes(rx)
+	e := challenge(r, pubKey, msg[:])
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, params.N)
+	return concat(r, scalarBytes(s)), nil
+}
+
+// Verify verifies the Schnorr signature of the data by the encoded public
+// key.
+func Verify(publicKey []byte, data []byte, signature []byte) error {
+	return BatchVerify([][]byte{publicKey}, [][]byte{data}, [][]byte{signature})
+}
+
+// BatchVerify verifies the Schnorr signatures of the data by the encoded
+// public keys at once, the signature of index i is of data[i] by
+// publicKeys[i]. An error is returned if any of the signatures is not valid.
+func BatchVerify(publicKeys [][]byte, data [][]byte, signatures [][]byte) error {
+	if len(publicKeys) != len(data) || len(publicKeys) != len(signatures) {
+		return errors.New("number of public keys, data and signatures mismatch")
+	}
+	if len(signatures) == 0 {
+		return nil
+	}
+
+	// The coefficients of the equations are derived from all the inputs, the
+	// first one is always 1.
+	seed := sha256.New()
+	for i := range signatures {
+		seed.Write(publicKeys[i])
+		seed.Write(data[i])
+		seed.Write(signatures[i])
+	}
+	seedHash := seed.Sum(nil)
+
+	// Checks (sum a * s) * G == sum a * R + sum (a * e) * P.
+	sum := new(big.Int)
+	var x, y *big.Int
+	for i := range signatures {
+		if len(signatures[i]) != SignatureLength {
+			return ErrInvalidSignature
+		}
+		r := signatures[i][:scalarLength]
+		s := new(big.Int).SetBytes(signatures[i][scalarLength:])
+		if s.Cmp(params.N) >= 0 {
+			return ErrInvalidSignature
+		}
+		rx, ry, err := liftX(r)
+		if err != nil {
+			return err
+		}
+		px, py, pubKey, err := decodePublicKey(publicKeys[i])
+		if err != nil {
+			return err
+		}
+		msg := sha256.Sum256(data[i])
+		e := challenge(r, pubKey, msg[:])
+
+		a := coefficient(seedHash, i)
+		ae := new(big.Int).Mul(a, e)
+		ae.Mod(ae, params.N)
+		tx, ty := curve.ScalarMult(rx, ry, scalarBytes(a))
+		ex, ey := curve.ScalarMult(px, py, scalarBytes(ae))
+		tx, ty = add(tx, ty, ex, ey)
+		if x == nil {
+			x, y = tx, ty
+		} else {
+			x, y = add(x, y, tx, ty)
+		}
+
+		sum.Add(sum, new(big.Int).Mul(a, s))
+		sum.Mod(sum, params.N)
+	}
+
+	gx, gy := curve.ScalarBaseMult(scalarBytes(sum))
+	if gx.Cmp(x) != 0 || gy.Cmp(y) != 0 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// challenge returns the challenge e of the signature.
+func challenge(r, pubKey, msg []byte) *big.Int {
+	hash := sha256.Sum256(concat(r, pubKey, msg))
+	return new(big.Int).Mod(new(big.Int).SetBytes(hash[:]), params.N)
+}
+
+// coefficient returns the coefficient of the equation of index i in a batch.
+func coefficient(seed []byte, i int) *big.Int {
+	if i == 0 {
+		return big.NewInt(1)
+	}
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], uint32(i))
+	hash := sha256.Sum256(concat(seed, index[:]))
+	a := new(big.Int).Mod(new(big.Int).SetBytes(hash[:]), params.N)
+	if a.Sign() == 0 {
+		a.SetInt64(1)
+	}
+	return a
+}
+
+// decodePublicKey returns the point and the compressed encoding of the
+// encoded public key.
+func decodePublicKey(publicKey []byte) (*big.Int, *big.Int, []byte, error) {
+	pk, err := crypto.DecodePoint(publicKey)
+	if err != nil || !curve.IsOnCurve(pk.X, pk.Y) {
+		return nil, nil, nil, ErrInvalidPublicKey
+	}
+	compressed, err := pk.EncodePoint(true)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPublicKey
+	}
+	return pk.X, pk.Y, compressed, nil
+}
+
+// liftX returns the point whose x coordinate is r and y coordinate is even.
+func liftX(r []byte) (*big.Int, *big.Int, error) {
+	x := new(big.Int).SetBytes(r)
+	if x.Cmp(params.P) >= 0 {
+		return nil, nil, ErrInvalidSignature
+	}
+	// y^2 = x^3 - 3x + B
+	ySquare := new(big.Int).Mul(x, x)
+	ySquare.Mul(ySquare, x)
+	ySquare.Sub(ySquare, new(big.Int).Mul(x, big.NewInt(3)))
+	ySquare.Add(ySquare, params.B)
+	ySquare.Mod(ySquare, params.P)
+
+	y := new(big.Int).Exp(ySquare, sqrtExp, params.P)
+	if new(big.Int).Exp(y, big.NewInt(2), params.P).Cmp(ySquare) != 0 {
+		return nil, nil, ErrInvalidSignature
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(params.P, y)
+	}
+	return x, y, nil
+}
+
+// add returns the sum of two points, the point at infinity is (0, 0).
+func add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return x2, y2
+	}
+	if x2.Sign() == 0 && y2.Sign() == 0 {
+		return x1, y1
+	}
+	return curve.Add(x1, y1, x2, y2)
+}
+
+// scalarBytes returns the 32 bytes big-endian encoding of the integer.
+func scalarBytes(n *big.Int) []byte {
+	b := make([]byte, scalarLength)
+	nb := n.Bytes()
+	copy(b[scalarLength-len(nb):], nb)
+	return b
+}
+
+func concat(data ...[]byte) []byte {
+	return bytes.Join(data, nil)
+}
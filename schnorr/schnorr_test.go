@@ -0,0 +1,148 @@
+package schnorr
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+var vectors = []struct {
+	privateKey string
+	publicKey  string
+	data       string
+	signature  string
+}{
+	{
+		privateKey: "0000000000000000000000000000000000000000000000000000000000000001",
+		publicKey:  "036b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296",
+		data:       "",
+		signature: "649905192d92a6e9906e2f6ebcc004e64dd9196816355fb17f3e878f194a7a6c" +
+			"1e968f0bf0e739326ea81bde98cdd716a8e802f9d618ca972a902af74ed229d7",
+	},
+	{
+		privateKey: "c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721",
+		publicKey:  "0360fed4ba255a9d31c961eb74c6356d68c049b8923b61fa6ce669622e60f29fb6",
+		data:       "sample",
+		signature: "90c7eb6079836b8126539ad70d4ed7594e15c176db781c50356859a993d2afc5" +
+			"4ac481b88d79314fef8557ddc35ae71914fa4514f51ac71c92f4101b3617baae",
+	},
+	{
+		privateKey: "ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632550",
+		publicKey:  "026b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296",
+		data:       "test",
+		signature: "192b879087758e33bb5733432a85f1222159863268bb81df540be91a583bf0f4" +
+			"d81db3398f0ce3cf3af533b444278c236bcf84e18dd35d787cf3b0f5af513db5",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	assert.NoError(t, err)
+	return b
+}
+
+func TestSign(t *testing.T) {
+	for i, v := range vectors {
+		privateKey := decodeHex(t, v.privateKey)
+		publicKey, err := crypto.NewPubKey(privateKey).EncodePoint(true)
+		assert.NoError(t, err)
+		assert.Equal(t, v.publicKey, hex.EncodeToString(publicKey), "vector %d", i)
+
+		signature, err := Sign(privateKey, []byte(v.data))
+		assert.NoError(t, err)
+		assert.Equal(t, v.signature, hex.EncodeToString(signature), "vector %d", i)
+	}
+
+	_, err := Sign(make([]byte, 32), nil)
+	assert.Equal(t, ErrInvalidPrivateKey, err)
+	_, err = Sign(decodeHex(t, "ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551"), nil)
+	assert.Equal(t, ErrInvalidPrivateKey, err)
+}
+
+func TestVerify(t *testing.T) {
+	for i, v := range vectors {
+		publicKey := decodeHex(t, v.publicKey)
+		signature := decodeHex(t, v.signature)
+		assert.NoError(t, Verify(publicKey, []byte(v.data), signature), "vector %d", i)
+
+		// The uncompressed public key is the same key.
+		pk, err := crypto.DecodePoint(publicKey)
+		assert.NoError(t, err)
+		uncompressed, err := pk.EncodePoint(false)
+		assert.NoError(t, err)
+		assert.NoError(t, Verify(uncompressed, []byte(v.data), signature), "vector %d", i)
+
+		// Other data.
+		assert.Equal(t, ErrInvalidSignature, Verify(publicKey, []byte("other"), signature))
+
+		// Other public key.
+		other := decodeHex(t, vectors[(i+1)%len(vectors)].publicKey)
+		assert.Equal(t, ErrInvalidSignature, Verify(other, []byte(v.data), signature))
+
+		// Tampered r and s.
+		tampered := append([]byte(nil), signature...)
+		tampered[0] ^= 1
+		assert.Error(t, Verify(publicKey, []byte(v.data), tampered))
+		tampered = append([]byte(nil), signature...)
+		tampered[SignatureLength-1] ^= 1
+		assert.Equal(t, ErrInvalidSignature, Verify(publicKey, []byte(v.data), tampered))
+
+		// Invalid length.
+		assert.Equal(t, ErrInvalidSignature, Verify(publicKey, []byte(v.data), signature[1:]))
+	}
+
+	// s not less than N.
+	signature := decodeHex(t, vectors[0].signature)
+	copy(signature[32:], decodeHex(t, "ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551"))
+	assert.Equal(t, ErrInvalidSignature, Verify(decodeHex(t, vectors[0].publicKey), nil, signature))
+
+	// r not less than P.
+	signature = decodeHex(t, vectors[0].signature)
+	copy(signature, decodeHex(t, "ffffffff00000001000000000000000000000000ffffffffffffffffffffffff"))
+	assert.Equal(t, ErrInvalidSignature, Verify(decodeHex(t, vectors[0].publicKey), nil, signature))
+
+	// Invalid public key.
+	assert.Equal(t, ErrInvalidPublicKey, Verify([]byte{0x02}, nil, decodeHex(t, vectors[0].signature)))
+}
+
+func TestBatchVerify(t *testing.T) {
+	var publicKeys, data, signatures [][]byte
+	for _, v := range vectors {
+		publicKeys = append(publicKeys, decodeHex(t, v.publicKey))
+		data = append(data, []byte(v.data))
+		signatures = append(signatures, decodeHex(t, v.signature))
+	}
+	assert.NoError(t, BatchVerify(publicKeys, data, signatures))
+	assert.NoError(t, BatchVerify(nil, nil, nil))
+	assert.Error(t, BatchVerify(publicKeys, data, signatures[1:]))
+
+	// Any invalid signature fails the batch.
+	for i := range signatures {
+		tampered := make([][]byte, len(signatures))
+		copy(tampered, signatures)
+		tampered[i] = append([]byte(nil), signatures[i]...)
+		tampered[i][SignatureLength-1] ^= 1
+		assert.Equal(t, ErrInvalidSignature, BatchVerify(publicKeys, data, tampered), "index %d", i)
+	}
+
+	// Swapped signatures fail even if the sum of the equations holds.
+	swapped := [][]byte{signatures[1], signatures[0], signatures[2]}
+	assert.Equal(t, ErrInvalidSignature, BatchVerify(publicKeys, data, swapped))
+
+	// Many signatures of the same data.
+	publicKeys, data, signatures = nil, nil, nil
+	for i := 0; i < 12; i++ {
+		privateKey, publicKey, err := crypto.GenerateKeyPair()
+		assert.NoError(t, err)
+		pk, _ := publicKey.EncodePoint(true)
+		signature, err := Sign(privateKey, []byte("arbiters"))
+		assert.NoError(t, err)
+		assert.NoError(t, Verify(pk, []byte("arbiters"), signature))
+		publicKeys = append(publicKeys, pk)
+		data = append(data, []byte("arbiters"))
+		signatures = append(signatures, signature)
+	}
+	assert.NoError(t, BatchVerify(publicKeys, data, signatures))
+}
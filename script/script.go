@@ -0,0 +1,178 @@
+/*
+Package script classifies the redeem scripts of transaction programs and the
+program hashes of transaction outputs by the contract templates known to the
+side chain.
+
+The classification is used by the transaction pool policy, which relays only
+the transactions whose programs are of the standard templates, while blocks
+containing non-standard programs are still valid as long as the programs are
+executed successfully.
+*/
+package script
+
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/htlc"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/contract"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// Class is the class of a script.
+type Class byte
+
+const (
+	// NonStandard is the class of scripts not matching any template.
+	NonStandard Class = iota
+
+	// Standard is the class of single signature scripts,
+	// <public key> CHECKSIG.
+	Standard
+
+	// MultiSig is the class of multiple signature scripts,
+	// <m> <public key>... <n> CHECKMULTISIG.
+	MultiSig
+
+	// CrossChain is the class of the cross chain scripts of the main chain,
+	// <genesis hash> CROSSCHAIN.
+	CrossChain
+
+	// HTLC is the class of hash time locked contracts created by
+	// htlc.Contract.
+	HTLC
+
+	// Schnorr is the class of single Schnorr signature scripts,
+	// <public key> CHECKSCHNORRSIG.
+	Schnorr
+
+	// SchnorrMultiSig is the class of multiple Schnorr signature scripts,
+	// <m> <public key>... <n> CHECKMULTISCHNORRSIG.
+	SchnorrMultiSig
+
+	// Destroy is the class of the zero program hash, the outputs to which
+	// can never be spent.
+	Destroy
+)
+
+const (
+	// publicKeyLength is the length of the compressed public keys.
+	publicKeyLength = 33
+
+	// maxMultiSigKeys is the max number of public keys in the multiple
+	// signature scripts, same as contract.CreateMultiSigRedeemScript.
+	maxMultiSigKeys = 24
+)
+
+var classStrings = map[Class]string{
+	NonStandard:     "nonstandard",
+	Standard:        "standard",
+	MultiSig:        "multisig",
+	CrossChain:      "crosschain",
+	HTLC:            "htlc",
+	Schnorr:         "schnorr",
+	SchnorrMultiSig: "schnorrmultisig",
+	Destroy:         "destroy",
+}
+
+// String returns the name of the class.
+func (c Class) String() string {
+	if s, ok := classStrings[c]; ok {
+		return s
+	}
+	return classStrings[NonStandard]
+}
+
+// Classify returns the class of the redeem script.
+func Classify(code []byte) Class {
+	switch {
+	case isSingleSig(code, vm.CHECKSIG):
+		return Standard
+	case isMultiSig(code, vm.CHECKMULTISIG):
+		return MultiSig
+	case isCrossChain(code):
+		return CrossChain
+	case isSingleSig(code, vm.CHECKSCHNORRSIG):
+		return Schnorr
+	case isMultiSig(code, vm.CHECKMULTISCHNORRSIG):
+		return SchnorrMultiSig
+	}
+	if _, err := htlc.ParseRedeemScript(code); err == nil {
+		return HTLC
+	}
+	return NonStandard
+}
+
+// ClassifyProgramHash returns the class of the program hash by its prefix,
+// which is Standard, MultiSig, CrossChain, HTLC, Destroy or NonStandard.
+func ClassifyProgramHash(programHash common.Uint168) Class {
+	if programHash.IsEqual(common.Uint168{}) {
+		return Destroy
+	}
+	switch contract.PrefixType(programHash[0]) {
+	case contract.PrefixStandard:
+		return Standard
+	case contract.PrefixMultiSig:
+		return MultiSig
+	case contract.PrefixCrossChain:
+		return CrossChain
+	case htlc.PrefixHTLC:
+		return HTLC
+	}
+	return NonStandard
+}
+
+// isSingleSig returns if the code is <public key> <op>.
+func isSingleSig(code []byte, op byte) bool {
+	return len(code) == publicKeyLength+2 && code[0] == publicKeyLength &&
+		code[len(code)-1] == op && isPublicKey(code[1:1+publicKeyLength])
+}
+
+// isMultiSig returns if the code is <m> <public key>... <n> <op>.
+func isMultiSig(code []byte, op byte) bool {
+	if len(code) < 3 || code[len(code)-1] != op {
+		return false
+	}
+	m, i, ok := readSmallInt(code, 0)
+	if !ok {
+		return false
+	}
+	var n int
+	for ; i < len(code) && code[i] == publicKeyLength; i += publicKeyLength + 1 {
+		if i+1+publicKeyLength > len(code) ||
+			!isPublicKey(code[i+1:i+1+publicKeyLength]) {
+			return false
+		}
+		n++
+	}
+	count, i, ok := readSmallInt(code, i)
+	return ok && i == len(code)-1 && count == n && m >= 1 && m <= n &&
+		n <= maxMultiSigKeys
+}
+
+// isCrossChain returns if the code is <genesis hash> CROSSCHAIN.
+func isCrossChain(code []byte) bool {
+	return len(code) == common.UINT256SIZE+2 &&
+		code[0] == common.UINT256SIZE && code[len(code)-1] == common.CROSSCHAIN
+}
+
+// readSmallInt reads the number pushed by PUSH1 to PUSH16, or by a one byte
+// data push as program.ProgramBuilder.PushNumber does, returning the number
+// and the position after it.
+func readSmallInt(code []byte, i int) (int, int, bool) {
+	if i >= len(code) {
+		return 0, i, false
+	}
+	switch {
+	case code[i] >= vm.PUSH1 && code[i] <= vm.PUSH16:
+		return int(code[i]-vm.PUSH1) + 1, i + 1, true
+	case code[i] == vm.PUSHBYTES1 && i+1 < len(code):
+		return int(code[i+1]), i + 2, true
+	}
+	return 0, i, false
+}
+
+func isPublicKey(data []byte) bool {
+	_, err := crypto.DecodePoint(data)
+	return err == nil
+}
@@ -0,0 +1,108 @@
+package script
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/htlc"
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/core/contract"
+	"github.com/elastos/Elastos.ELA/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPublicKeys(t *testing.T, n int) []*crypto.PublicKey {
+	publicKeys := make([]*crypto.PublicKey, 0, n)
+	for i := 0; i < n; i++ {
+		_, publicKey, err := crypto.GenerateKeyPair()
+		assert.NoError(t, err)
+		publicKeys = append(publicKeys, publicKey)
+	}
+	return publicKeys
+}
+
+func TestClassify(t *testing.T) {
+	publicKeys := newPublicKeys(t, 20)
+
+	standard, err := contract.CreateStandardRedeemScript(publicKeys[0])
+	assert.NoError(t, err)
+	assert.Equal(t, Standard, Classify(standard))
+
+	for _, mn := range [][2]int{{1, 1}, {2, 3}, {4, 5}, {12, 17}, {17, 20}} {
+		multiSig, err := contract.CreateMultiSigRedeemScript(mn[0],
+			publicKeys[:mn[1]])
+		assert.NoError(t, err)
+		assert.Equal(t, MultiSig, Classify(multiSig), "%d of %d", mn[0], mn[1])
+
+		// The Schnorr multiple signature script has the same layout.
+		multiSig[len(multiSig)-1] = vm.CHECKMULTISCHNORRSIG
+		assert.Equal(t, SchnorrMultiSig, Classify(multiSig))
+	}
+
+	crossChain := contract.CreateCrossChainRedeemScript(common.Uint256{1})
+	assert.Equal(t, CrossChain, Classify(crossChain))
+
+	schnorr := append([]byte(nil), standard...)
+	schnorr[len(schnorr)-1] = vm.CHECKSCHNORRSIG
+	assert.Equal(t, Schnorr, Classify(schnorr))
+
+	hash := sha256.Sum256([]byte("secret"))
+	htlcScript, err := (&htlc.Contract{
+		Hash:         hash[:],
+		Receiver:     publicKeys[0],
+		Sender:       publicKeys[1],
+		RefundHeight: 100,
+	}).RedeemScript()
+	assert.NoError(t, err)
+	assert.Equal(t, HTLC, Classify(htlcScript))
+
+	multiSig, err := contract.CreateMultiSigRedeemScript(2, publicKeys[:3])
+	assert.NoError(t, err)
+	nonStandard := [][]byte{
+		nil,
+		{vm.PUSH1},
+		{vm.CHECKSIG},
+		append(standard[:len(standard)-1:len(standard)-1], vm.CHECKMULTISIG),
+		append([]byte{vm.PUSH1}, standard...),
+		// Invalid public key.
+		append(append([]byte{33, 0x05}, make([]byte, 32)...), vm.CHECKSIG),
+		// m greater than n.
+		append(append([]byte{vm.PUSH4}, multiSig[1:len(multiSig)-2]...), vm.PUSH3, vm.CHECKMULTISIG),
+		// n mismatches the number of keys.
+		append(append([]byte{vm.PUSH2}, multiSig[1:len(multiSig)-2]...), vm.PUSH4, vm.CHECKMULTISIG),
+		// Truncated public key.
+		append(append([]byte{vm.PUSH1}, multiSig[1:len(multiSig)-3]...), vm.PUSH3, vm.CHECKMULTISIG),
+		// Cross chain script with a short hash.
+		crossChain[1:],
+		htlcScript[1:],
+	}
+	for i, code := range nonStandard {
+		assert.Equal(t, NonStandard, Classify(code), "script %d", i)
+	}
+}
+
+func TestClassifyProgramHash(t *testing.T) {
+	code := []byte{vm.PUSH1}
+	tests := []struct {
+		prefix byte
+		class  Class
+	}{
+		{byte(contract.PrefixStandard), Standard},
+		{byte(contract.PrefixMultiSig), MultiSig},
+		{byte(contract.PrefixCrossChain), CrossChain},
+		{byte(htlc.PrefixHTLC), HTLC},
+		{byte(contract.PrefixDeposit), NonStandard},
+		{byte(contract.PrefixCRDID), NonStandard},
+	}
+	for _, test := range tests {
+		programHash := common.ToProgramHash(test.prefix, code)
+		assert.Equal(t, test.class, ClassifyProgramHash(*programHash))
+	}
+	assert.Equal(t, Destroy, ClassifyProgramHash(common.Uint168{}))
+
+	assert.Equal(t, "standard", Standard.String())
+	assert.Equal(t, "schnorrmultisig", SchnorrMultiSig.String())
+	assert.Equal(t, "nonstandard", Class(100).String())
+}
@@ -898,9 +898,13 @@ func New(cfg *Config) (*server, error) {
 	svrcfg := p2psvr.NewDefaultConfig(
 		params.Magic, pact.EBIP002Version, uint64(services),
 		params.DefaultPort, params.DNSSeeds, params.ListenAddrs,
-		nil, nil, makeEmptyMessage,
+		nil, nil, func(cmd string) (p2p.Message, error) {
+			return makeEmptyMessage(cfg.Chain, cmd)
+		},
 		func() uint64 { return uint64(cfg.Chain.GetBestHeight()) },
-		cfg.ChainParams.NewP2PProtocolVersionHeight, cfg.NodeVersion,
+		uint64(cfg.ChainParams.GetDeployment(
+			config.DeploymentNewP2PProtocolVersion).ActivationHeight),
+		cfg.NodeVersion,
 	)
 	svrcfg.DataDir = cfg.DataDir
 	svrcfg.NAFilter = &naFilter{}
@@ -934,7 +938,9 @@ func New(cfg *Config) (*server, error) {
 	return &s, nil
 }
 
-func makeEmptyMessage(cmd string) (p2p.Message, error) {
+// makeEmptyMessage returns the empty message of the command, whose block is
+// read with the target data active in the chain.
+func makeEmptyMessage(chain *blockchain.BlockChain, cmd string) (p2p.Message, error) {
 	var message p2p.Message
 	switch cmd {
 	case p2p.CmdMemPool:
@@ -944,7 +950,8 @@ func makeEmptyMessage(cmd string) (p2p.Message, error) {
 		message = msg.NewTx(&types.Transaction{})
 
 	case p2p.CmdBlock:
-		message = msg.NewBlock(types.NewBlock())
+		message = msg.NewBlock(types.NewBlockWithTargetData(
+			chain.IsCrossChainTargetDataActive))
 
 	case p2p.CmdInv:
 		message = &msg.Inv{}
@@ -21,11 +21,23 @@ type OutputInfo struct {
 	Address    string `json:"address"`
 	AssetID    string `json:"assetid"`
 	OutputLock uint32 `json:"outputlock"`
+
+	// ScriptClass is the class of the output program hash, see
+	// script.ClassifyProgramHash.
+	ScriptClass string `json:"scriptclass"`
 }
 
 type ProgramInfo struct {
 	Code      string `json:"code"`
 	Parameter string `json:"parameter"`
+
+	// ScriptClass is the class of the code, see script.Classify.
+	ScriptClass string `json:"scriptclass"`
+
+	// CodeAsm and ParameterAsm are the disassembled code and parameter,
+	// which are filled by DecodeProgramScripts.
+	CodeAsm      string `json:"codeasm,omitempty"`
+	ParameterAsm string `json:"parameterasm,omitempty"`
 }
 
 type TransactionInfo struct {
@@ -108,6 +120,7 @@ type CrossChainAssetInfo struct {
 	CrossChainAddress string `json:"crosschainaddress"`
 	OutputIndex       uint64 `json:"outputindex"`
 	CrossChainAmount  string `json:"crosschainamount"`
+	TargetData        string `json:"targetdata,omitempty"`
 }
 
 type TransferCrossChainAssetInfo struct {
@@ -122,3 +135,55 @@ type AssetInfo struct {
 	AssetType   int    `json:"assettype"`
 	RecordType  int    `jso:"recordtype"`
 }
+
+type DeploymentInfo struct {
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	State            string `json:"state"`
+	ActivationHeight uint32 `json:"activationheight"`
+	Bit              uint8  `json:"bit"`
+	StartTime        uint32 `json:"starttime"`
+	ExpireTime       uint32 `json:"expiretime"`
+}
+
+type DeploymentsInfo struct {
+	Height      uint32           `json:"height"`
+	Deployments []DeploymentInfo `json:"deployments"`
+}
+
+type StepInfo struct {
+	Step               int      `json:"step"`
+	Executed           bool     `json:"executed"`
+	Position           int      `json:"position"`
+	OpCode             string   `json:"opcode"`
+	OpName             string   `json:"opname"`
+	InstructionPointer int      `json:"instructionpointer"`
+	State              string   `json:"state"`
+	EvaluationStack    []string `json:"evaluationstack"`
+	AltStack           []string `json:"altstack"`
+	InvocationDepth    int      `json:"invocationdepth"`
+}
+
+type ProgramTraceInfo struct {
+	ProgramHash string     `json:"programhash"`
+	Code        string     `json:"code"`
+	Parameter   string     `json:"parameter"`
+	State       string     `json:"state"`
+	Error       string     `json:"error"`
+	Steps       []StepInfo `json:"steps"`
+}
+
+type HTLCInfo struct {
+	Hash         string `json:"hash"`
+	Receiver     string `json:"receiver"`
+	Sender       string `json:"sender"`
+	RefundHeight uint32 `json:"refundheight"`
+	RedeemScript string `json:"redeemscript"`
+	Address      string `json:"address"`
+}
+
+type HTLCTransactionInfo struct {
+	RawTransaction string `json:"rawtransaction"`
+	SignData       string `json:"signdata"`
+	Complete       bool   `json:"complete"`
+}
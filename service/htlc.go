@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+
+	"github.com/elastos/Elastos.ELA.SideChain/htlc"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/elastos/Elastos.ELA/crypto"
+	"github.com/elastos/Elastos.ELA/utils/http"
+)
+
+// CreateHTLC returns the redeem script and the address of a hash time locked
+// contract given by the "hash" of the secret, the "receiver" and "sender"
+// public keys and the "refundheight".
+func (s *HttpService) CreateHTLC(param http.Params) (interface{}, error) {
+	contract := new(htlc.Contract)
+	var err error
+	if contract.Hash, err = hexParam(param, "hash"); err != nil {
+		return nil, err
+	}
+	if contract.Receiver, err = publicKeyParam(param, "receiver"); err != nil {
+		return nil, err
+	}
+	if contract.Sender, err = publicKeyParam(param, "sender"); err != nil {
+		return nil, err
+	}
+	refundHeight, ok := param.Uint32("refundheight")
+	if !ok {
+		return nil, http.NewError(int(InvalidParams), "refundheight not found")
+	}
+	contract.RefundHeight = refundHeight
+
+	return getHTLCInfo(contract)
+}
+
+// CreateHTLCClaimTransaction builds the transaction claiming the output of a
+// hash time locked contract to an address. The transaction is complete if the
+// receiver "signature" of the returned sign data and the "secret" are given.
+func (s *HttpService) CreateHTLCClaimTransaction(param http.Params) (interface{}, error) {
+	return s.createHTLCTransaction(param, true)
+}
+
+// CreateHTLCRefundTransaction builds the transaction refunding the output of
+// a hash time locked contract to an address after the refund height. The
+// transaction is complete if the sender "signature" of the returned sign data
+// is given.
+func (s *HttpService) CreateHTLCRefundTransaction(param http.Params) (interface{}, error) {
+	return s.createHTLCTransaction(param, false)
+}
+
+func (s *HttpService) createHTLCTransaction(param http.Params, claim bool) (interface{}, error) {
+	redeemScript, err := hexParam(param, "redeemscript")
+	if err != nil {
+		return nil, err
+	}
+	contract, err := htlc.ParseRedeemScript(redeemScript)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), err.Error())
+	}
+	programHash, err := contract.ProgramHash()
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), err.Error())
+	}
+
+	str, ok := param.String("txid")
+	if !ok {
+		return nil, http.NewError(int(InvalidParams), "txid not found")
+	}
+	hex, err := FromReversedString(str)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "invalid txid")
+	}
+	txid, err := common.Uint256FromBytes(hex)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "invalid txid")
+	}
+	index, ok := param.Uint16("index")
+	if !ok {
+		return nil, http.NewError(int(InvalidParams), "index not found")
+	}
+	address, ok := param.String("address")
+	if !ok {
+		return nil, http.NewError(int(InvalidParams), "address not found")
+	}
+	target, err := common.Uint168FromAddress(address)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "invalid address")
+	}
+	fee := common.Fixed64(s.cfg.Chain.GetParams().MinTransactionFee)
+	if str, ok := param.String("fee"); ok {
+		value, err := common.StringToFixed64(str)
+		if err != nil {
+			return nil, http.NewError(int(InvalidParams), "invalid fee")
+		}
+		fee = *value
+	}
+
+	referTx, _, err := s.cfg.Chain.GetTransaction(*txid)
+	if err != nil {
+		return nil, newError(UnknownTransaction)
+	}
+	if int(index) >= len(referTx.Outputs) {
+		return nil, http.NewError(int(InvalidParams), "index out of range")
+	}
+	output := referTx.Outputs[index]
+	if !output.ProgramHash.IsEqual(*programHash) {
+		return nil, http.NewError(int(InvalidParams),
+			"output is not locked by the contract")
+	}
+	if output.Value <= fee {
+		return nil, http.NewError(int(InvalidParams), "fee is not less than the output value")
+	}
+
+	// The sequence enables the lock time of the transaction, which is
+	// required by CHECKLOCKTIMEVERIFY.
+	tx := &types.Transaction{
+		TxType:  types.TransferAsset,
+		Payload: &types.PayloadTransferAsset{},
+		Inputs: []*types.Input{{
+			Previous: *types.NewOutPoint(*txid, index),
+			Sequence: 0,
+		}},
+		Outputs: []*types.Output{{
+			AssetID:     output.AssetID,
+			Value:       output.Value - fee,
+			ProgramHash: *target,
+		}},
+		Programs: []*types.Program{{Code: redeemScript}},
+	}
+	if !claim {
+		tx.LockTime = contract.RefundHeight
+	}
+
+	complete := false
+	if str, ok := param.String("signature"); ok {
+		signature, err := common.HexStringToBytes(str)
+		if err != nil {
+			return nil, http.NewError(int(InvalidParams), "invalid signature")
+		}
+		if claim {
+			secret, err := hexParam(param, "secret")
+			if err != nil {
+				return nil, err
+			}
+			tx.Programs[0].Parameter, err = htlc.ClaimParameter(signature, secret)
+		} else {
+			tx.Programs[0].Parameter, err = htlc.RefundParameter(signature)
+		}
+		if err != nil {
+			return nil, http.NewError(int(InvalidParams), err.Error())
+		}
+		complete = true
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tx.Serialize(buf); err != nil {
+		return nil, http.NewError(int(InternalError), err.Error())
+	}
+	return HTLCTransactionInfo{
+		RawTransaction: common.BytesToHexString(buf.Bytes()),
+		SignData:       common.BytesToHexString(tx.GetData()),
+		Complete:       complete,
+	}, nil
+}
+
+func getHTLCInfo(contract *htlc.Contract) (*HTLCInfo, error) {
+	redeemScript, err := contract.RedeemScript()
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), err.Error())
+	}
+	programHash, err := contract.ProgramHash()
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), err.Error())
+	}
+	address, err := programHash.ToAddress()
+	if err != nil {
+		return nil, http.NewError(int(InternalError), err.Error())
+	}
+	receiver, _ := contract.Receiver.EncodePoint(true)
+	sender, _ := contract.Sender.EncodePoint(true)
+	return &HTLCInfo{
+		Hash:         common.BytesToHexString(contract.Hash),
+		Receiver:     common.BytesToHexString(receiver),
+		Sender:       common.BytesToHexString(sender),
+		RefundHeight: contract.RefundHeight,
+		RedeemScript: common.BytesToHexString(redeemScript),
+		Address:      address,
+	}, nil
+}
+
+func hexParam(param http.Params, key string) ([]byte, error) {
+	str, ok := param.String(key)
+	if !ok {
+		return nil, http.NewError(int(InvalidParams), key+" not found")
+	}
+	data, err := common.HexStringToBytes(str)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "invalid "+key)
+	}
+	return data, nil
+}
+
+func publicKeyParam(param http.Params, key string) (*crypto.PublicKey, error) {
+	data, err := hexParam(param, key)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := crypto.DecodePoint(data)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "invalid "+key)
+	}
+	return publicKey, nil
+}
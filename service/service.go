@@ -7,15 +7,18 @@ import (
 	"errors"
 	"fmt"
 	"github.com/elastos/Elastos.ELA/core/types/outputpayload"
+	"strings"
 
 	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
 	"github.com/elastos/Elastos.ELA.SideChain/config"
 	"github.com/elastos/Elastos.ELA.SideChain/interfaces"
 	"github.com/elastos/Elastos.ELA.SideChain/mempool"
 	"github.com/elastos/Elastos.ELA.SideChain/pow"
+	"github.com/elastos/Elastos.ELA.SideChain/script"
 	"github.com/elastos/Elastos.ELA.SideChain/server"
 	"github.com/elastos/Elastos.ELA.SideChain/spv"
 	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/asm"
 
 	"github.com/elastos/Elastos.ELA/common"
 	ela "github.com/elastos/Elastos.ELA/core/types"
@@ -49,7 +52,7 @@ type Config struct {
 	GenesisAddress         string
 	TxMemPool              *mempool.TxPool
 	PowService             *pow.Service
-	SpvService             *spv.Service
+	SpvService             spv.MainChain // spv.Service, or spv.FileMainChain offline
 	SetLogLevel            func(level elalog.Level)
 	ConfigurationPermitted string
 
@@ -122,7 +125,11 @@ func (s *HttpService) GetRawTransaction(param http.Params) (interface{}, error)
 
 	verbose, ok := param.Bool("verbose")
 	if verbose {
-		return s.cfg.GetTransactionInfo(s.cfg, header, tx), nil
+		txInfo := s.cfg.GetTransactionInfo(s.cfg, header, tx)
+		if decode, _ := param.Bool("decodescripts"); decode {
+			DecodeProgramScripts(txInfo)
+		}
+		return txInfo, nil
 	} else {
 		buf := new(bytes.Buffer)
 		tx.Serialize(buf)
@@ -339,7 +346,9 @@ func (s *HttpService) SendRechargeToSideChainTxByHash(param http.Params) (interf
 		return nil, http.NewError(int(InvalidParams), "invalid tx hash")
 	}
 
-	depositTx, err := createRechargeToSideChainTransaction(tx, s.cfg.GenesisAddress)
+	depositTx, err := CreateCrossChainRechargeTransaction(tx, s.cfg.GenesisAddress,
+		s.cfg.Chain.GetParams(), s.cfg.Chain.IsDeploymentActiveAtHeight(
+			config.DeploymentCrossChainAssets, s.cfg.Chain.GetBestHeight()))
 	if err != nil {
 		return nil, http.NewError(int(InvalidParams), "create recharge tx failed")
 	}
@@ -350,20 +359,51 @@ func (s *HttpService) SendRechargeToSideChainTxByHash(param http.Params) (interf
 	return depositTx.Hash().String(), nil
 }
 
-func createRechargeToSideChainTransaction(tx *ela.Transaction, genesisAddress string) (*types.Transaction, error) {
+// CreateRechargeToSideChainTransaction creates the recharge transaction of
+// the deposit transaction to the genesis address on the main chain.
+func CreateRechargeToSideChainTransaction(tx *ela.Transaction, genesisAddress string) (*types.Transaction, error) {
+	return CreateCrossChainRechargeTransaction(tx, genesisAddress, nil, false)
+}
+
+// CreateCrossChainRechargeTransaction creates the recharge transaction of the
+// deposit transaction like CreateRechargeToSideChainTransaction, with the
+// side chain assets and amounts of the cross chain assets in params, which
+// include the assets of CrossChainAssets if active. The ELA asset and the
+// amounts on the main chain are used if params is nil.
+func CreateCrossChainRechargeTransaction(tx *ela.Transaction, genesisAddress string,
+	params *config.Params, active bool) (*types.Transaction, error) {
 	if tx.PayloadVersion >= payload.TransferCrossChainVersionV1 {
-		return createRechargeToSideChainTransactionV1(tx, genesisAddress)
+		return createRechargeToSideChainTransactionV1(tx, genesisAddress, params, active)
 	}
 	rechargeInfo, err := parseRechargeToSideChainTransactionInfo(tx, genesisAddress)
 	if err != nil {
 		return nil, err
 	}
 
-	return createRechargeToSideChainTransactionByInfo(rechargeInfo)
+	return createRechargeToSideChainTransactionByInfo(rechargeInfo, params, active)
+}
+
+// getRechargeOutput returns the side chain asset and amount recharged by the
+// main chain asset and amount.
+func getRechargeOutput(params *config.Params, active bool, assetID common.Uint256,
+	amount common.Fixed64) (common.Uint256, common.Fixed64, error) {
+	if params == nil {
+		return types.GetSystemAssetId(), amount, nil
+	}
+	asset := params.GetCrossChainAssetByMainChainAsset(assetID, active)
+	if asset == nil {
+		return common.Uint256{}, 0, errors.New("invalid cross chain asset")
+	}
+	value, err := asset.Convert(amount)
+	if err != nil {
+		return common.Uint256{}, 0, err
+	}
+	return asset.AssetID, value, nil
 }
 
 type RechargeToSideChainAsset struct {
 	TargetAddress    string
+	AssetID          common.Uint256
 	Amount           *common.Fixed64
 	CrossChainAmount *common.Fixed64
 }
@@ -393,6 +433,7 @@ func parseRechargeToSideChainTransactionInfo(txn *ela.Transaction, genesisAddres
 		if txn.Outputs[payloadObj.OutputIndexes[i]].ProgramHash.IsEqual(*programHash) {
 			result.DepositAssets = append(result.DepositAssets, &RechargeToSideChainAsset{
 				TargetAddress:    payloadObj.CrossChainAddresses[i],
+				AssetID:          txn.Outputs[payloadObj.OutputIndexes[i]].AssetID,
 				Amount:           &txn.Outputs[payloadObj.OutputIndexes[i]].Value,
 				CrossChainAmount: &payloadObj.CrossChainAmounts[i],
 			})
@@ -401,7 +442,8 @@ func parseRechargeToSideChainTransactionInfo(txn *ela.Transaction, genesisAddres
 	return result, nil
 }
 
-func createRechargeToSideChainTransactionV1(txn *ela.Transaction, genesisAddress string) (*types.Transaction, error) {
+func createRechargeToSideChainTransactionV1(txn *ela.Transaction, genesisAddress string,
+	params *config.Params, active bool) (*types.Transaction, error) {
 	// create payload
 	payload := new(types.PayloadRechargeToSideChain)
 
@@ -409,10 +451,6 @@ func createRechargeToSideChainTransactionV1(txn *ela.Transaction, genesisAddress
 	hash := txn.Hash()
 	payload.MainChainTransactionHash = hash
 
-	// get assetId
-	asset := types.GetSystemAssetId()
-	assetId := &asset
-
 	// get cross chain address's program hash
 	crossChainHash, err := common.Uint168FromAddress(genesisAddress)
 	if err != nil {
@@ -438,9 +476,13 @@ func createRechargeToSideChainTransactionV1(txn *ela.Transaction, genesisAddress
 		if err != nil {
 			return nil, err
 		}
+		assetId, value, err := getRechargeOutput(params, active, output.AssetID, op.TargetAmount)
+		if err != nil {
+			return nil, err
+		}
 		output := &types.Output{
-			AssetID:     *assetId,
-			Value:       op.TargetAmount,
+			AssetID:     assetId,
+			Value:       value,
 			OutputLock:  0,
 			ProgramHash: *target,
 		}
@@ -456,23 +498,25 @@ func createRechargeToSideChainTransactionV1(txn *ela.Transaction, genesisAddress
 	return txTransaction, nil
 }
 
-func createRechargeToSideChainTransactionByInfo(txInfo *RechargeToSideChainInfo) (*types.Transaction, error) {
+func createRechargeToSideChainTransactionByInfo(txInfo *RechargeToSideChainInfo,
+	params *config.Params, active bool) (*types.Transaction, error) {
 	// create payload
 	payload := new(types.PayloadRechargeToSideChain)
 	payload.MainChainTransactionHash = *txInfo.MainChainTransactionHash
 
 	var txOutputs []*types.Output
 	for _, output := range txInfo.DepositAssets {
-		asset := types.GetSystemAssetId()
-		assetId := &asset
-
 		programHash, err := common.Uint168FromAddress(output.TargetAddress)
 		if err != nil {
 			return nil, err
 		}
+		assetId, value, err := getRechargeOutput(params, active, output.AssetID, *output.CrossChainAmount)
+		if err != nil {
+			return nil, err
+		}
 		output := &types.Output{
-			AssetID:     *assetId,
-			Value:       *output.CrossChainAmount,
+			AssetID:     assetId,
+			Value:       value,
 			OutputLock:  0,
 			ProgramHash: *programHash,
 		}
@@ -514,6 +558,95 @@ func (s *HttpService) SendRawTransaction(param http.Params) (interface{}, error)
 	return ToReversedString(txn.Hash()), nil
 }
 
+// TraceTransaction executes the programs of a transaction step by step and
+// returns the trace of each program. The transaction is given by the raw
+// transaction in the "data" parameter, or found by the "txid" parameter in
+// the chain or the transaction pool. The programs are executed with the rules
+// of the height of the block containing the transaction, or of the best
+// height the pool validates the transactions at.
+func (s *HttpService) TraceTransaction(param http.Params) (interface{}, error) {
+	var tx *types.Transaction
+	height := s.cfg.Chain.GetBestHeight()
+	if str, ok := param.String("data"); ok {
+		bys, err := common.HexStringToBytes(str)
+		if err != nil {
+			return nil, http.NewError(int(InvalidParams), "hex string to bytes error:"+err.Error())
+		}
+		tx = new(types.Transaction)
+		if err := tx.Deserialize(bytes.NewReader(bys)); err != nil {
+			return nil, http.NewError(int(InvalidTransaction), "transaction deserialize error:"+err.Error())
+		}
+	} else if str, ok := param.String("txid"); ok {
+		hex, err := FromReversedString(str)
+		if err != nil {
+			return nil, newError(InvalidParams)
+		}
+		hash, err := common.Uint256FromBytes(hex)
+		if err != nil {
+			return nil, newError(InvalidParams)
+		}
+		var txHeight uint32
+		tx, txHeight, err = s.cfg.Chain.GetTransaction(*hash)
+		if err == nil {
+			height = txHeight
+		} else {
+			tx = s.cfg.TxMemPool.GetTransaction(*hash)
+			if tx == nil {
+				return nil, newError(UnknownTransaction)
+			}
+		}
+	} else {
+		return nil, http.NewError(int(InvalidParams), "data or txid parameter is required")
+	}
+
+	if tx.IsCoinBaseTx() || tx.IsRechargeToSideChainTx() {
+		return nil, http.NewError(int(InvalidTransaction), "transaction has no programs to trace")
+	}
+
+	hashes, err := mempool.GetTxProgramHashes(s.cfg.Store, tx)
+	if err != nil {
+		return nil, http.NewError(int(InvalidTransaction), err.Error())
+	}
+	common.SortProgramHashByCodeHash(hashes)
+	mempool.SortPrograms(tx.Programs)
+
+	traces, err := s.cfg.TxMemPool.TracePrograms(tx, height, hashes, tx.Programs)
+	if err != nil {
+		return nil, http.NewError(int(InvalidTransaction), err.Error())
+	}
+
+	result := make([]ProgramTraceInfo, 0, len(traces))
+	for _, trace := range traces {
+		address, _ := trace.ProgramHash.ToAddress()
+		info := ProgramTraceInfo{
+			ProgramHash: address,
+			Code:        common.BytesToHexString(trace.Program.Code),
+			Parameter:   common.BytesToHexString(trace.Program.Parameter),
+			State:       trace.State.String(),
+			Steps:       make([]StepInfo, 0, len(trace.Steps)),
+		}
+		if trace.Error != nil {
+			info.Error = trace.Error.Error()
+		}
+		for _, step := range trace.Steps {
+			info.Steps = append(info.Steps, StepInfo{
+				Step:               step.Step,
+				Executed:           step.Executed,
+				Position:           step.Position,
+				OpCode:             fmt.Sprintf("%02x", byte(step.OpCode)),
+				OpName:             step.OpName,
+				InstructionPointer: step.InstructionPointer,
+				State:              step.State.String(),
+				EvaluationStack:    step.EvaluationStack,
+				AltStack:           step.AltStack,
+				InvocationDepth:    step.InvocationDepth,
+			})
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
 func (s *HttpService) GetBlockHeight(param http.Params) (interface{}, error) {
 	return s.cfg.Chain.GetBestHeight(), nil
 }
@@ -544,6 +677,49 @@ func (s *HttpService) GetBestBlockHash(param http.Params) (interface{}, error) {
 	return ToReversedString(hash), nil
 }
 
+// GetDeploymentInfo returns the state of the soft fork deployments for the
+// block after the best block, or the deployment given by the optional name
+// parameter.
+func (s *HttpService) GetDeploymentInfo(param http.Params) (interface{}, error) {
+	name, hasName := param.String("name")
+
+	var deployments []config.Deployment
+	if hasName {
+		deployment := s.cfg.Chain.GetParams().GetDeployment(name)
+		if deployment == nil {
+			return nil, http.NewError(int(InvalidParams), "unknown deployment "+name)
+		}
+		deployments = append(deployments, *deployment)
+	} else {
+		deployments = s.cfg.Chain.GetParams().GetDeployments()
+	}
+
+	info := DeploymentsInfo{
+		Height:      s.cfg.Chain.GetBestHeight(),
+		Deployments: make([]DeploymentInfo, 0, len(deployments)),
+	}
+	for _, d := range deployments {
+		state, err := s.cfg.Chain.NextDeploymentState(d.Name)
+		if err != nil {
+			return nil, http.NewError(int(InternalError), err.Error())
+		}
+		deploymentType := "height"
+		if d.IsVersionBits() {
+			deploymentType = "versionbits"
+		}
+		info.Deployments = append(info.Deployments, DeploymentInfo{
+			Name:             d.Name,
+			Type:             deploymentType,
+			State:            state.String(),
+			ActivationHeight: d.ActivationHeight,
+			Bit:              d.BitNumber,
+			StartTime:        d.StartTime,
+			ExpireTime:       d.ExpireTime,
+		})
+	}
+	return info, nil
+}
+
 func (s *HttpService) getBlockTransactions(block *types.Block) interface{} {
 	trans := make([]string, len(block.Transactions))
 	for i := 0; i < len(block.Transactions); i++ {
@@ -820,7 +996,11 @@ func (s *HttpService) GetTransactionByHash(param http.Params) (interface{}, erro
 		return nil, newError(UnknownBlock)
 	}
 
-	return s.cfg.GetTransactionInfo(s.cfg, header, txn), nil
+	txInfo := s.cfg.GetTransactionInfo(s.cfg, header, txn)
+	if decode, _ := param.Bool("decodescripts"); decode {
+		DecodeProgramScripts(txInfo)
+	}
+	return txInfo, nil
 }
 
 func GetStringArray(param http.Params, key string) ([]string, bool) {
@@ -870,6 +1050,74 @@ func (s *HttpService) GetExistDepositTransactions(param http.Params) (interface{
 	return resultTxHashes, nil
 }
 
+type DepositInfo struct {
+	TxID         string `json:"txid"`
+	Status       string `json:"status"`
+	RechargeTxID string `json:"rechargetxid"`
+	Attempts     uint32 `json:"attempts"`
+	LastError    string `json:"lasterror"`
+	InStore      bool   `json:"instore"`
+	InTxPool     bool   `json:"intxpool"`
+}
+
+func (s *HttpService) GetDepositStatus(param http.Params) (interface{}, error) {
+	txid, ok := param.String("txid")
+	if !ok {
+		return nil, http.NewError(int(InvalidParams), "txid not found")
+	}
+
+	txBytes, err := common.HexStringToBytes(txid)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "invalid txid")
+	}
+
+	hash, err := common.Uint256FromBytes(txBytes)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "to tx hash failed")
+	}
+
+	record, err := s.cfg.Store.GetDeposit(*hash)
+	if err != nil {
+		return nil, newError(UnknownTransaction)
+	}
+
+	return DepositInfo{
+		TxID:         txid,
+		Status:       record.Status.String(),
+		RechargeTxID: ToReversedString(record.RechargeTxHash),
+		Attempts:     record.Attempts,
+		LastError:    record.LastError,
+		InStore:      s.cfg.Chain.IsDuplicateMainchainTx(*hash),
+		InTxPool:     s.cfg.TxMemPool.IsDuplicateMainChainTx(*hash),
+	}, nil
+}
+
+type OrphanedDepositInfo struct {
+	TxID           string `json:"txid"`
+	RollbackHeight uint32 `json:"rollbackheight"`
+	Backed         bool   `json:"backed"`
+	InStore        bool   `json:"instore"`
+}
+
+func (s *HttpService) GetOrphanedDeposits(param http.Params) (interface{}, error) {
+	deposits, err := s.cfg.Store.GetOrphanedDeposits()
+	if err != nil {
+		return nil, http.NewError(int(InternalError), err.Error())
+	}
+
+	result := make([]OrphanedDepositInfo, 0, len(deposits))
+	for _, deposit := range deposits {
+		_, err := s.cfg.SpvService.GetTransaction(&deposit.MainChainTxHash)
+		result = append(result, OrphanedDepositInfo{
+			TxID:           common.BytesToHexString(deposit.MainChainTxHash.Bytes()),
+			RollbackHeight: deposit.RollbackHeight,
+			Backed:         err == nil,
+			InStore:        s.cfg.Chain.IsDuplicateMainchainTx(deposit.MainChainTxHash),
+		})
+	}
+	return result, nil
+}
+
 func (s *HttpService) GetBlockTransactionsDetail(block *types.Block, filter func(*types.Transaction) bool) []*types.Transaction {
 	var trans []*types.Transaction
 	for _, tx := range block.Transactions {
@@ -896,7 +1144,7 @@ func (s *HttpService) GetWithdrawTxsInfo(txs []*types.Transaction) interface{} {
 				CrossChainAddress: payload.CrossChainAddresses[i],
 				CrossChainAmount:  payload.CrossChainAmounts[i].String(),
 				OutputAmount:      tx.Outputs[payload.OutputIndexes[i]].Value.String(),
-				TargetData:        "",
+				TargetData:        getTargetData(payload, i),
 			})
 		}
 
@@ -911,6 +1159,15 @@ func (s *HttpService) GetWithdrawTxsInfo(txs []*types.Transaction) interface{} {
 	return trans
 }
 
+// getTargetData returns the hex string of the target data of the cross chain
+// output of the index, or empty if the payload has no target data.
+func getTargetData(payload *types.PayloadTransferCrossChainAsset, index int) string {
+	if index >= len(payload.TargetData) {
+		return ""
+	}
+	return common.BytesToHexString(payload.TargetData[index])
+}
+
 type WithdrawOutputInfo struct {
 	CrossChainAddress string `json:"crosschainaddress"`
 	CrossChainAmount  string `json:"crosschainamount"`
@@ -984,6 +1241,7 @@ func (s *HttpService) GetWithdrawTransactionByHash(param http.Params) (interface
 			CrossChainAddress: payload.CrossChainAddresses[i],
 			CrossChainAmount:  payload.CrossChainAmounts[i].String(),
 			OutputAmount:      tx.Outputs[payload.OutputIndexes[i]].Value.String(),
+			TargetData:        getTargetData(payload, i),
 		})
 	}
 
@@ -995,6 +1253,169 @@ func (s *HttpService) GetWithdrawTransactionByHash(param http.Params) (interface
 	return txWithdraw, nil
 }
 
+type SpvInfo struct {
+	Height uint32 `json:"height"`
+	Hash   string `json:"hash"`
+	Synced bool   `json:"synced"`
+	Peers  int32  `json:"peers"`
+}
+
+func (s *HttpService) GetSpvInfo(param http.Params) (interface{}, error) {
+	if s.cfg.SpvService == nil {
+		return nil, http.NewError(int(InternalError), "SPV service not available")
+	}
+	height, hash, err := s.cfg.SpvService.BestHeader()
+	if err != nil {
+		return nil, http.NewError(int(InternalError), err.Error())
+	}
+	return &SpvInfo{
+		Height: height,
+		Hash:   ToReversedString(hash),
+		Synced: s.cfg.SpvService.IsCurrent(),
+		Peers:  s.cfg.SpvService.PeerCount(),
+	}, nil
+}
+
+type ArbitersInfo struct {
+	Height         uint32   `json:"height"`
+	CRCArbiters    []string `json:"crcarbiters"`
+	NormalArbiters []string `json:"normalarbiters"`
+}
+
+func (s *HttpService) GetArbitersByHeight(param http.Params) (interface{}, error) {
+	if s.cfg.SpvService == nil {
+		return nil, http.NewError(int(InternalError), "SPV service not available")
+	}
+	height, ok := param.Uint("height")
+	if !ok {
+		height = uint(s.cfg.SpvService.BestHeight())
+	}
+
+	crcArbiters, normalArbiters, err := s.cfg.SpvService.GetArbiters(uint32(height))
+	if err != nil {
+		return nil, http.NewError(int(InternalError), err.Error())
+	}
+	info := &ArbitersInfo{
+		Height:         uint32(height),
+		CRCArbiters:    make([]string, 0, len(crcArbiters)),
+		NormalArbiters: make([]string, 0, len(normalArbiters)),
+	}
+	for _, a := range crcArbiters {
+		info.CRCArbiters = append(info.CRCArbiters, common.BytesToHexString(a))
+	}
+	for _, a := range normalArbiters {
+		info.NormalArbiters = append(info.NormalArbiters, common.BytesToHexString(a))
+	}
+	return info, nil
+}
+
+type LastAuxPowInfo struct {
+	Height          uint32 `json:"height"`
+	Hash            string `json:"hash"`
+	MainChainHeight uint32 `json:"mainchainheight"`
+	MainChainHash   string `json:"mainchainhash"`
+	SideAuxBlockTx  string `json:"sideauxblocktxid"`
+}
+
+// GetLastAuxPowInfo returns the main chain block of the aux pow of the best
+// block, which is the last main chain block used by the merged mining.
+func (s *HttpService) GetLastAuxPowInfo(param http.Params) (interface{}, error) {
+	hash := s.cfg.Chain.CurrentBlockHash()
+	header, err := s.cfg.Chain.GetHeader(hash)
+	if err != nil {
+		return nil, newError(UnknownBlock)
+	}
+	sideAuxPow := header.GetAuxPow()
+	return &LastAuxPowInfo{
+		Height:          header.GetHeight(),
+		Hash:            ToReversedString(hash),
+		MainChainHeight: sideAuxPow.MainBlockHeader.Height,
+		MainChainHash:   ToReversedString(sideAuxPow.MainBlockHeader.Hash()),
+		SideAuxBlockTx:  ToReversedString(sideAuxPow.SideAuxBlockTx.Hash()),
+	}, nil
+}
+
+type WithdrawalInfo struct {
+	TxID          string `json:"txid"`
+	Height        uint32 `json:"height"`
+	Status        string `json:"status"`
+	MainChainTxID string `json:"mainchaintxid"`
+}
+
+func newWithdrawalInfo(record *blockchain.WithdrawalRecord) *WithdrawalInfo {
+	info := &WithdrawalInfo{
+		TxID:   ToReversedString(record.TxID),
+		Height: record.Height,
+		Status: record.Status.String(),
+	}
+	if record.Status == blockchain.WithdrawalIncluded {
+		info.MainChainTxID = ToReversedString(record.MainChainTxHash)
+	}
+	return info
+}
+
+func (s *HttpService) GetWithdrawalStatus(param http.Params) (interface{}, error) {
+	str, ok := param.String("txid")
+	if !ok {
+		return nil, http.NewError(int(InvalidParams), "txid not found")
+	}
+	hex, err := FromReversedString(str)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "txid reverse failed")
+	}
+	var hash common.Uint256
+	err = hash.Deserialize(bytes.NewReader(hex))
+	if err != nil {
+		return nil, http.NewError(int(InvalidTransaction), "txid deserialize failed")
+	}
+
+	record, err := s.cfg.Store.GetWithdrawal(hash)
+	if err != nil {
+		return nil, http.NewError(int(UnknownTransaction), "get withdrawal by txid failed")
+	}
+	return newWithdrawalInfo(record), nil
+}
+
+// maxWithdrawalsPerPage is the max number of the withdrawals returned by one
+// call of GetPendingWithdrawals.
+const maxWithdrawalsPerPage = 1000
+
+func (s *HttpService) GetPendingWithdrawals(param http.Params) (interface{}, error) {
+	start, ok := param.Uint("startheight")
+	if !ok {
+		start = 0
+	}
+	end, ok := param.Uint("endheight")
+	if !ok {
+		end = uint(s.cfg.Chain.GetBestHeight())
+	}
+	if start > end {
+		return nil, http.NewError(int(InvalidParams), "startheight is greater than endheight")
+	}
+	offset, ok := param.Uint("offset")
+	if !ok {
+		offset = 0
+	}
+	limit, ok := param.Uint("limit")
+	if !ok || limit > maxWithdrawalsPerPage {
+		limit = maxWithdrawalsPerPage
+	}
+
+	records, err := s.cfg.Store.GetWithdrawals(uint32(start), uint32(end),
+		func(record *blockchain.WithdrawalRecord) bool {
+			return record.Status == blockchain.WithdrawalPending
+		}, uint32(offset), uint32(limit))
+	if err != nil {
+		return nil, http.NewError(int(InternalError), err.Error())
+	}
+
+	withdrawals := make([]*WithdrawalInfo, 0, len(records))
+	for _, record := range records {
+		withdrawals = append(withdrawals, newWithdrawalInfo(record))
+	}
+	return withdrawals, nil
+}
+
 type SidechainIllegalDataInfo struct {
 	IllegalType     uint8  `json:"illegaltype"`
 	Height          uint32 `json:"height"`
@@ -1009,19 +1430,21 @@ func (s *HttpService) GetIllegalEvidenceByHeight(param http.Params) (interface{}
 		return nil, http.NewError(int(InvalidParams), "height parameter should be a positive integer")
 	}
 
-	hash, err := s.cfg.Chain.GetBlockHash(uint32(height))
-	if err != nil {
-		return nil, newError(UnknownBlock)
-
-	}
-	_, err = s.cfg.Chain.GetBlockByHash(hash)
+	evidences, err := s.cfg.Chain.GetIllegalEvidences(uint32(height))
 	if err != nil {
-		return nil, newError(UnknownBlock)
+		return nil, http.NewError(int(InternalError), err.Error())
 	}
 
-	// todo get illegal evidences in block
-
-	result := make([]*SidechainIllegalDataInfo, 0)
+	result := make([]*SidechainIllegalDataInfo, 0, len(evidences))
+	for _, e := range evidences {
+		result = append(result, &SidechainIllegalDataInfo{
+			IllegalType:     uint8(e.IllegalType),
+			Height:          e.Height,
+			IllegalSigner:   common.BytesToHexString(e.IllegalSigner),
+			Evidence:        ToReversedString(e.Evidence.DataHash),
+			CompareEvidence: ToReversedString(e.CompareEvidence.DataHash),
+		})
+	}
 	return result, nil
 }
 
@@ -1036,9 +1459,37 @@ func (s *HttpService) CheckIllegalEvidence(param http.Params) (interface{}, erro
 		return false, err
 	}
 
-	// todo check illegal evidence
+	signer, err := common.HexStringToBytes(e.IllegalSigner)
+	if err != nil {
+		return nil, http.NewError(int(InvalidParams), "invalid illegal signer")
+	}
+	var hash, compareHash common.Uint256
+	for _, v := range []struct {
+		str  string
+		hash *common.Uint256
+	}{{e.Evidence, &hash}, {e.CompareEvidence, &compareHash}} {
+		hashBytes, err := FromReversedString(v.str)
+		if err != nil {
+			return nil, http.NewError(int(InvalidParams), "invalid evidence")
+		}
+		if err := v.hash.Deserialize(bytes.NewReader(hashBytes)); err != nil {
+			return nil, http.NewError(int(InvalidParams), "invalid evidence")
+		}
+	}
 
-	return false, nil
+	err = s.cfg.Chain.CheckIllegalEvidence(&payload.SidechainIllegalData{
+		IllegalType:         payload.IllegalDataType(e.IllegalType),
+		Height:              e.Height,
+		IllegalSigner:       signer,
+		Evidence:            payload.SidechainIllegalEvidence{DataHash: hash},
+		CompareEvidence:     payload.SidechainIllegalEvidence{DataHash: compareHash},
+		GenesisBlockAddress: s.cfg.GenesisAddress,
+	})
+	if err != nil {
+		log.Debugf("[CheckIllegalEvidence] invalid evidence, %s", err)
+		return false, nil
+	}
+	return true, nil
 }
 
 func Unmarshal(result interface{}, target interface{}) error {
@@ -1138,6 +1589,7 @@ func GetTransactionInfo(cfg *Config, header interfaces.Header, tx *types.Transac
 		outputs[i].Address = address
 		outputs[i].AssetID = ToReversedString(v.AssetID)
 		outputs[i].OutputLock = v.OutputLock
+		outputs[i].ScriptClass = script.ClassifyProgramHash(v.ProgramHash).String()
 	}
 
 	attributes := make([]AttributeInfo, len(tx.Attributes))
@@ -1150,6 +1602,7 @@ func GetTransactionInfo(cfg *Config, header interfaces.Header, tx *types.Transac
 	for i, v := range tx.Programs {
 		programs[i].Code = common.BytesToHexString(v.Code)
 		programs[i].Parameter = common.BytesToHexString(v.Parameter)
+		programs[i].ScriptClass = script.Classify(v.Code).String()
 	}
 
 	var txHash = tx.Hash()
@@ -1187,6 +1640,29 @@ func GetTransactionInfo(cfg *Config, header interfaces.Header, tx *types.Transac
 	}
 }
 
+// DecodeProgramScripts fills the disassembled code and parameter of the
+// programs in the transaction info, a script failed to disassemble is shown
+// with the error after the opcodes disassembled.
+func DecodeProgramScripts(txInfo *TransactionInfo) {
+	for i := range txInfo.Programs {
+		program := &txInfo.Programs[i]
+		program.CodeAsm = disassemble(program.Code)
+		program.ParameterAsm = disassemble(program.Parameter)
+	}
+}
+
+func disassemble(script string) string {
+	b, err := common.HexStringToBytes(script)
+	if err != nil {
+		return "[error: " + err.Error() + "]"
+	}
+	text, err := asm.Disassemble(b)
+	if err != nil {
+		return strings.TrimSpace(text + " [error: " + err.Error() + "]")
+	}
+	return text
+}
+
 func GetTransactionInfoFromBytes(txInfoBytes []byte) (*TransactionInfo, error) {
 	var txInfo TransactionInfo
 	err := json.Unmarshal(txInfoBytes, &txInfo)
@@ -1226,6 +1702,10 @@ func GetTransaction(cfg *Config, txInfo *TransactionInfo) (*types.Transaction, e
 	if err != nil {
 		return nil, err
 	}
+	if p, ok := txPaload.(*types.PayloadTransferCrossChainAsset); ok &&
+		!p.HasTargetData(txInfo.PayloadVersion) {
+		p.TargetData = nil
+	}
 
 	var txAttribute []*types.Attribute
 	for _, att := range txInfo.Attributes {
@@ -1342,6 +1822,7 @@ func GetPayloadInfo(p types.Payload, pVersion byte) PayloadInfo {
 				CrossChainAddress: object.CrossChainAddresses[i],
 				OutputIndex:       object.OutputIndexes[i],
 				CrossChainAmount:  object.CrossChainAmounts[i].String(),
+				TargetData:        getTargetData(object, i),
 			}
 			obj.CrossChainAssets = append(obj.CrossChainAssets, assetInfo)
 		}
@@ -1418,7 +1899,15 @@ func GetPayload(pInfo PayloadInfo) (types.Payload, error) {
 				return nil, err
 			}
 			obj.CrossChainAmounts = append(obj.CrossChainAmounts, *amount)
+			data, err := common.HexStringToBytes(assetInfo.TargetData)
+			if err != nil {
+				return nil, err
+			}
+			obj.TargetData = append(obj.TargetData, data)
 		}
+		// The target data of every output is kept, the empty ones are
+		// omitted by the JSON. GetTransaction drops it if the payload
+		// version carries no target data.
 		return obj, nil
 	}
 
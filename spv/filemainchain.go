@@ -0,0 +1,317 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sync"
+
+	spv "github.com/elastos/Elastos.ELA.SPV/interface"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA/common"
+	ela "github.com/elastos/Elastos.ELA/core/types"
+)
+
+var _ MainChain = (*Service)(nil)
+var _ MainChain = (*FileMainChain)(nil)
+
+// FileHeader is a main chain header in the file of FileMainChain.
+type FileHeader struct {
+	Height uint32 `json:"height"`
+	Bits   uint32 `json:"bits"`
+	// Hash is the reversed hex string of the header hash, it is optional
+	// and only returned as the hash of the best header.
+	Hash string `json:"hash,omitempty"`
+}
+
+// FileTransaction is a main chain transaction in the file of FileMainChain,
+// the data is the hex string of the serialized transaction.
+type FileTransaction struct {
+	Height uint32 `json:"height"`
+	Data   string `json:"data"`
+}
+
+// FileArbiters are the arbiters of the main chain from the height, until the
+// height of the next arbiters in the file of FileMainChain.
+type FileArbiters struct {
+	Height         uint32   `json:"height"`
+	CRCArbiters    []string `json:"crcarbiters"`
+	NormalArbiters []string `json:"normalarbiters"`
+}
+
+// FileMainChainData is the JSON content of the file of FileMainChain.
+type FileMainChainData struct {
+	// GenesisAddress is the address generated by the side chain genesis
+	// block.
+	GenesisAddress string `json:"genesisaddress"`
+
+	// DPOSHeight is the height from which the consensus algorithm of the
+	// main chain is DPOS.
+	DPOSHeight uint32 `json:"dposheight"`
+
+	// CRCArbiters are the CRC arbiters in params signing the side chain pow
+	// transactions.
+	CRCArbiters []string `json:"crcarbiters"`
+
+	Headers      []FileHeader      `json:"headers"`
+	Transactions []FileTransaction `json:"transactions"`
+	Arbiters     []FileArbiters    `json:"arbiters"`
+}
+
+type fileTx struct {
+	height uint32
+	tx     *ela.Transaction
+}
+
+// FileMainChain is the main chain of the headers, transactions and arbiters
+// in a JSON file of FileMainChainData, so the side chain nodes can be run and
+// tested without the SPV module connecting to the main chain network.
+type FileMainChain struct {
+	path string
+
+	mtx            sync.RWMutex
+	data           FileMainChainData
+	crcArbiters    [][]byte
+	headers        map[uint32]uint32
+	txs            map[common.Uint256]fileTx
+	hashes         map[uint32]common.Uint256
+	bestHeight     uint32
+	arbitersHeight []uint32
+	arbiters       map[uint32][2][][]byte
+}
+
+// Reload reads the main chain data from the file again.
+func (c *FileMainChain) Reload() error {
+	content, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	var data FileMainChainData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return err
+	}
+
+	crcArbiters, err := decodeArbiters(data.CRCArbiters)
+	if err != nil {
+		return err
+	}
+	var bestHeight uint32
+	headers := make(map[uint32]uint32, len(data.Headers))
+	hashes := make(map[uint32]common.Uint256)
+	for _, h := range data.Headers {
+		headers[h.Height] = h.Bits
+		if h.Hash != "" {
+			hash, err := decodeReversedHash(h.Hash)
+			if err != nil {
+				return err
+			}
+			hashes[h.Height] = hash
+		}
+		if h.Height > bestHeight {
+			bestHeight = h.Height
+		}
+	}
+	txs := make(map[common.Uint256]fileTx, len(data.Transactions))
+	for _, t := range data.Transactions {
+		raw, err := common.HexStringToBytes(t.Data)
+		if err != nil {
+			return err
+		}
+		tx := new(ela.Transaction)
+		if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+			return err
+		}
+		txs[tx.Hash()] = fileTx{height: t.Height, tx: tx}
+		if t.Height > bestHeight {
+			bestHeight = t.Height
+		}
+	}
+	arbitersHeight := make([]uint32, 0, len(data.Arbiters))
+	arbiters := make(map[uint32][2][][]byte, len(data.Arbiters))
+	for _, a := range data.Arbiters {
+		crc, err := decodeArbiters(a.CRCArbiters)
+		if err != nil {
+			return err
+		}
+		normal, err := decodeArbiters(a.NormalArbiters)
+		if err != nil {
+			return err
+		}
+		if _, ok := arbiters[a.Height]; !ok {
+			arbitersHeight = append(arbitersHeight, a.Height)
+		}
+		arbiters[a.Height] = [2][][]byte{crc, normal}
+	}
+
+	c.mtx.Lock()
+	c.data = data
+	c.crcArbiters = crcArbiters
+	c.headers = headers
+	c.hashes = hashes
+	c.txs = txs
+	c.bestHeight = bestHeight
+	c.arbitersHeight = arbitersHeight
+	c.arbiters = arbiters
+	c.mtx.Unlock()
+	return nil
+}
+
+func (c *FileMainChain) GenesisAddress() string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.data.GenesisAddress
+}
+
+func (c *FileMainChain) GetTransaction(txId *common.Uint256) (*ela.Transaction, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	t, ok := c.txs[*txId]
+	if !ok {
+		return nil, errors.New("transaction not found")
+	}
+	return t.tx, nil
+}
+
+// VerifyTransaction checks the main chain transaction recharged is in the
+// file, the merkle proof of payload version 0 is not checked.
+func (c *FileMainChain) VerifyTransaction(tx *types.Transaction) error {
+	payload, ok := tx.Payload.(*types.PayloadRechargeToSideChain)
+	if !ok {
+		return errors.New("[VerifyTransaction] Invalid payload PayloadRechargeToSideChain")
+	}
+	hash, err := payload.GetMainchainTxHash(tx.PayloadVersion)
+	if err != nil {
+		return err
+	}
+	if _, err := c.GetTransaction(hash); err != nil {
+		return errors.New("[VerifyTransaction] Main chain transaction not found")
+	}
+	return nil
+}
+
+// GetConfirmations returns the confirmations of the main chain transaction
+// by the best height, which is at most max.
+func (c *FileMainChain) GetConfirmations(txId *common.Uint256, max uint32) (uint32, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	t, ok := c.txs[*txId]
+	if !ok {
+		return 0, errors.New("transaction not found")
+	}
+	confirmations := c.bestHeight - t.height + 1
+	if confirmations > max {
+		confirmations = max
+	}
+	return confirmations, nil
+}
+
+// GetArbiters returns the arbiters of the largest height not greater than
+// the height in the file.
+func (c *FileMainChain) GetArbiters(height uint32) ([][]byte, [][]byte, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	found := false
+	var arbitersHeight uint32
+	for _, h := range c.arbitersHeight {
+		if h <= height && (!found || h > arbitersHeight) {
+			arbitersHeight = h
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil, errors.New("arbiters not found")
+	}
+	arbiters := c.arbiters[arbitersHeight]
+	return arbiters[0], arbiters[1], nil
+}
+
+func (c *FileMainChain) GetConsensusAlgorithm(height uint32) (spv.ConsensusAlgorithm, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	if height < c.data.DPOSHeight {
+		return spv.POW, nil
+	}
+	return spv.DPOS, nil
+}
+
+func (c *FileMainChain) CheckCRCArbiterSignatureV0(sideChainPowTx *ela.Transaction) error {
+	c.mtx.RLock()
+	crcArbiters := c.crcArbiters
+	c.mtx.RUnlock()
+	if _, err := sideChainPowSigner(crcArbiters, sideChainPowTx); err != nil {
+		return errors.New("CRC arbiter expected.")
+	}
+	return nil
+}
+
+func (c *FileMainChain) GetSideChainPowSigner(height uint32, sideChainPowTx *ela.Transaction) ([]byte, error) {
+	c.mtx.RLock()
+	candidates := append([][]byte{}, c.crcArbiters...)
+	c.mtx.RUnlock()
+	crcArbiters, normalArbiters, err := c.GetArbiters(height)
+	if err == nil {
+		candidates = append(candidates, crcArbiters...)
+		candidates = append(candidates, normalArbiters...)
+	}
+	return sideChainPowSigner(candidates, sideChainPowTx)
+}
+
+func (c *FileMainChain) GetHeaderBits(height uint32) (uint32, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	bits, ok := c.headers[height]
+	if !ok {
+		return 0, errors.New("header not found")
+	}
+	return bits, nil
+}
+
+// BestHeight returns the max height of the headers and transactions in the
+// file.
+func (c *FileMainChain) BestHeight() uint32 {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.bestHeight
+}
+
+// BestHeader returns the best height and the hash of the header of the
+// height, the hash is empty if it is not in the file.
+func (c *FileMainChain) BestHeader() (uint32, common.Uint256, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.bestHeight, c.hashes[c.bestHeight], nil
+}
+
+// IsCurrent always returns true, the file is the whole main chain known to
+// the node.
+func (c *FileMainChain) IsCurrent() bool {
+	return true
+}
+
+// PeerCount always returns 0, no peers are connected to read the file.
+func (c *FileMainChain) PeerCount() int32 {
+	return 0
+}
+
+// NewFileMainChain creates a main chain of the data in the file.
+func NewFileMainChain(path string) (*FileMainChain, error) {
+	c := &FileMainChain{path: path}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// decodeReversedHash returns the hash of the reversed hex string.
+func decodeReversedHash(reversed string) (common.Uint256, error) {
+	raw, err := common.HexStringToBytes(reversed)
+	if err != nil {
+		return common.Uint256{}, err
+	}
+	hash, err := common.Uint256FromBytes(common.BytesReverse(raw))
+	if err != nil {
+		return common.Uint256{}, err
+	}
+	return *hash, nil
+}
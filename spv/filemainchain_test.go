@@ -0,0 +1,149 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	spv "github.com/elastos/Elastos.ELA.SPV/interface"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA/common"
+	ela "github.com/elastos/Elastos.ELA/core/types"
+	elapayload "github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/elastos/Elastos.ELA/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMainChain(t *testing.T) {
+	_, crcPubKey, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	crcKey, err := crcPubKey.EncodePoint(true)
+	assert.NoError(t, err)
+	_, arbiterPubKey, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	arbiterKey, err := arbiterPubKey.EncodePoint(true)
+	assert.NoError(t, err)
+
+	deposit := &ela.Transaction{
+		TxType:  ela.TransferCrossChainAsset,
+		Payload: new(elapayload.TransferCrossChainAsset),
+	}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, deposit.Serialize(buf))
+	depositHash := deposit.Hash()
+
+	data := FileMainChainData{
+		GenesisAddress: "address",
+		DPOSHeight:     10,
+		CRCArbiters:    []string{common.BytesToHexString(crcKey)},
+		Headers: []FileHeader{{
+			Height: 20,
+			Bits:   0x1d03ffff,
+			Hash:   "0000000000000000000000000000000000000000000000000000000000000102",
+		}},
+		Transactions: []FileTransaction{
+			{Height: 15, Data: common.BytesToHexString(buf.Bytes())},
+		},
+		Arbiters: []FileArbiters{{
+			Height:         12,
+			NormalArbiters: []string{common.BytesToHexString(arbiterKey)},
+		}},
+	}
+	content, err := json.Marshal(data)
+	assert.NoError(t, err)
+	dir, err := ioutil.TempDir("", "mainchain")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mainchain.json")
+	assert.NoError(t, ioutil.WriteFile(path, content, 0644))
+
+	c, err := NewFileMainChain(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "address", c.GenesisAddress())
+	assert.Equal(t, uint32(20), c.BestHeight())
+	height, hash, err := c.BestHeader()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(20), height)
+	assert.Equal(t, common.Uint256{0x02, 0x01}, hash)
+	assert.True(t, c.IsCurrent())
+	assert.Equal(t, int32(0), c.PeerCount())
+
+	// Transactions and confirmations
+	tx, err := c.GetTransaction(&depositHash)
+	assert.NoError(t, err)
+	assert.Equal(t, depositHash, tx.Hash())
+	_, err = c.GetTransaction(&common.Uint256{})
+	assert.Error(t, err)
+	n, err := c.GetConfirmations(&depositHash, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(6), n)
+	n, err = c.GetConfirmations(&depositHash, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), n)
+
+	// Recharge transactions of the payload versions
+	assert.NoError(t, c.VerifyTransaction(&types.Transaction{
+		TxType:         types.RechargeToSideChain,
+		PayloadVersion: types.RechargeToSideChainPayloadVersion1,
+		Payload: &types.PayloadRechargeToSideChain{
+			MainChainTransactionHash: depositHash,
+		},
+	}))
+	assert.NoError(t, c.VerifyTransaction(&types.Transaction{
+		TxType:         types.RechargeToSideChain,
+		PayloadVersion: types.RechargeToSideChainPayloadVersion0,
+		Payload: &types.PayloadRechargeToSideChain{
+			MainChainTransaction: buf.Bytes(),
+		},
+	}))
+	assert.Error(t, c.VerifyTransaction(&types.Transaction{
+		TxType:         types.RechargeToSideChain,
+		PayloadVersion: types.RechargeToSideChainPayloadVersion1,
+		Payload:        &types.PayloadRechargeToSideChain{},
+	}))
+
+	// Headers, consensus and arbiters
+	bits, err := c.GetHeaderBits(20)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x1d03ffff), bits)
+	_, err = c.GetHeaderBits(21)
+	assert.Error(t, err)
+	ca, _ := c.GetConsensusAlgorithm(9)
+	assert.Equal(t, spv.POW, ca)
+	ca, _ = c.GetConsensusAlgorithm(10)
+	assert.Equal(t, spv.DPOS, ca)
+	_, _, err = c.GetArbiters(11)
+	assert.Error(t, err)
+	_, normal, err := c.GetArbiters(100)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{arbiterKey}, normal)
+
+	// Side chain pow signers, the ECDSA signing is not available in the
+	// tests so only the invalid signatures are checked.
+	pow := &ela.Transaction{
+		TxType: ela.SideChainPow,
+		Payload: &elapayload.SideChainPow{
+			SideBlockHash: common.Uint256{1},
+			BlockHeight:   100,
+			Signature:     make([]byte, crypto.SignatureLength),
+		},
+	}
+	assert.Error(t, c.CheckCRCArbiterSignatureV0(pow))
+	_, err = c.GetSideChainPowSigner(100, pow)
+	assert.Error(t, err)
+	_, err = c.GetSideChainPowSigner(100, deposit)
+	assert.Error(t, err)
+
+	// Reload the data appended to the file
+	data.Headers = append(data.Headers, FileHeader{Height: 21, Bits: 1})
+	content, err = json.Marshal(data)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, content, 0644))
+	assert.NoError(t, c.Reload())
+	assert.Equal(t, uint32(21), c.BestHeight())
+}
@@ -0,0 +1,104 @@
+package spv
+
+import (
+	"bytes"
+	"errors"
+
+	spv "github.com/elastos/Elastos.ELA.SPV/interface"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA/common"
+	ela "github.com/elastos/Elastos.ELA/core/types"
+	elapayload "github.com/elastos/Elastos.ELA/core/types/payload"
+	"github.com/elastos/Elastos.ELA/crypto"
+)
+
+// MainChain is the view of the main chain used to verify the side chain
+// blocks and transactions. It is implemented by Service with the SPV module,
+// and by FileMainChain with a file of main chain data to run the side chain
+// nodes offline.
+type MainChain interface {
+	// GenesisAddress returns the address generated by the side chain
+	// genesis block.
+	GenesisAddress() string
+
+	// GetTransaction returns the main chain transaction of the hash.
+	GetTransaction(txId *common.Uint256) (*ela.Transaction, error)
+
+	// VerifyTransaction checks the main chain transaction recharged by the
+	// side chain transaction is in the main chain.
+	VerifyTransaction(tx *types.Transaction) error
+
+	// GetConfirmations returns the confirmations of the main chain
+	// transaction, which is at most max.
+	GetConfirmations(txId *common.Uint256, max uint32) (uint32, error)
+
+	// GetArbiters returns the CRC and normal arbiters of the height.
+	GetArbiters(height uint32) (crcArbiters [][]byte, normalArbiters [][]byte, err error)
+
+	// GetConsensusAlgorithm returns the consensus algorithm of the height.
+	GetConsensusAlgorithm(height uint32) (spv.ConsensusAlgorithm, error)
+
+	// CheckCRCArbiterSignatureV0 checks the side chain pow transaction is
+	// signed by one of the CRC arbiters in params.
+	CheckCRCArbiterSignatureV0(sideChainPowTx *ela.Transaction) error
+
+	// GetSideChainPowSigner returns the public key of the arbiter signed the
+	// side chain pow transaction of the height.
+	GetSideChainPowSigner(height uint32, sideChainPowTx *ela.Transaction) ([]byte, error)
+
+	// GetHeaderBits returns the bits of the main chain header of the height.
+	GetHeaderBits(height uint32) (uint32, error)
+
+	// BestHeight returns the height of the best main chain header.
+	BestHeight() uint32
+
+	// BestHeader returns the height and hash of the best main chain header.
+	BestHeader() (uint32, common.Uint256, error)
+
+	// IsCurrent returns if the main chain headers are synced.
+	IsCurrent() bool
+
+	// PeerCount returns the count of the connected main chain peers, or -1
+	// if it is not known.
+	PeerCount() int32
+}
+
+// sideChainPowSigner returns the one of the candidates signed the side chain
+// pow transaction.
+func sideChainPowSigner(candidates [][]byte, sideChainPowTx *ela.Transaction) ([]byte, error) {
+	payload, ok := sideChainPowTx.Payload.(*elapayload.SideChainPow)
+	if !ok {
+		return nil, errors.New("[sideChainPowSigner], invalid sideChainPow tx")
+	}
+	buf := new(bytes.Buffer)
+	if err := payload.SerializeUnsigned(buf, elapayload.SideChainPowVersion); err != nil {
+		return nil, err
+	}
+
+	for _, v := range candidates {
+		if len(v) == 0 {
+			continue
+		}
+		pubKey, err := crypto.DecodePoint(v)
+		if err != nil {
+			continue
+		}
+		if err := crypto.Verify(*pubKey, buf.Bytes(), payload.Signature); err == nil {
+			return v, nil
+		}
+	}
+	return nil, errors.New("[sideChainPowSigner], signer not found")
+}
+
+// decodeArbiters returns the public keys of the hex strings.
+func decodeArbiters(arbiters []string) ([][]byte, error) {
+	keys := make([][]byte, 0, len(arbiters))
+	for _, v := range arbiters {
+		key, err := common.HexStringToBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -3,6 +3,10 @@ package spv
 import (
 	"bytes"
 	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/elastos/Elastos.ELA.SPV/bloom"
 	spv "github.com/elastos/Elastos.ELA.SPV/interface"
 	"github.com/elastos/Elastos.ELA.SPV/util"
@@ -35,15 +39,31 @@ type Config struct {
 
 type Service struct {
 	spv.SPVService
-	chainParams *config.Params
+	chainParams      *config.Params
+	listener         *listener
+	withdrawListener *listener
+	blockListener    *BlockListener
+	querier          stateQuerier
+
+	// current and peers are the state of the SPV module refreshed by
+	// stateHandler.
+	current int32
+	peers   int32
+
+	wg   sync.WaitGroup
+	quit chan struct{}
 }
 
 func NewService(cfg *Config) (*Service, error) {
+	l := &listener{
+		address: cfg.GenesisAddress,
+		txType:  ela.TransferCrossChainAsset,
+	}
 	spvCfg := spv.Config{
 		DataDir:        cfg.DataDir,
 		ChainParams:    cfg.ChainParams,
 		PermanentPeers: cfg.PermanentPeers,
-		OnRollback:     nil, // Not implemented yet
+		OnRollback:     l.rollback,
 		FilterType:     cfg.FilterType,
 		NodeVersion:    cfg.NodeVersion,
 	}
@@ -53,25 +73,106 @@ func NewService(cfg *Config) (*Service, error) {
 		return nil, err
 	}
 
-	err = service.RegisterTransactionListener(&listener{
+	l.service = service
+	err = service.RegisterTransactionListener(l)
+	if err != nil {
+		return nil, err
+	}
+
+	wl := &listener{
 		address: cfg.GenesisAddress,
+		txType:  ela.WithdrawFromSideChain,
 		service: service,
-	})
+	}
+	err = service.RegisterTransactionListener(wl)
 	if err != nil {
 		return nil, err
 	}
 
-	err = service.RegisterBlockListener(&BlockListener{})
+	bl := &BlockListener{}
+	err = service.RegisterBlockListener(bl)
 	if err != nil {
 		return nil, err
 	}
 
+	querier, _ := service.IService.(stateQuerier)
 	return &Service{
-		SPVService:  service,
-		chainParams: cfg.ChainParams,
+		SPVService:       service,
+		chainParams:      cfg.ChainParams,
+		listener:         l,
+		withdrawListener: wl,
+		blockListener:    bl,
+		querier:          querier,
+		peers:            -1,
+		quit:             make(chan struct{}),
 	}, nil
 }
 
+// RegisterDepositHandler registers the handler called with the deposit
+// transactions to the genesis address, when they are notified by the SPV
+// module.
+func (s *Service) RegisterDepositHandler(handler func(tx *ela.Transaction)) {
+	s.listener.mtx.Lock()
+	s.listener.handlers = append(s.listener.handlers,
+		func(tx *ela.Transaction, height uint32) { handler(tx) })
+	s.listener.mtx.Unlock()
+}
+
+// RegisterWithdrawHandler registers the handler called with the
+// WithdrawFromSideChain transactions of the genesis address and the heights
+// of their main chain blocks, when they are notified by the SPV module.
+func (s *Service) RegisterWithdrawHandler(handler func(tx *ela.Transaction, height uint32)) {
+	s.withdrawListener.mtx.Lock()
+	s.withdrawListener.handlers = append(s.withdrawListener.handlers, handler)
+	s.withdrawListener.mtx.Unlock()
+}
+
+// RegisterRollbackHandler registers the handler called with the main chain
+// height rolled back, after the transactions of the height are removed from
+// the SPV module.
+func (s *Service) RegisterRollbackHandler(handler func(height uint32)) {
+	s.listener.mtx.Lock()
+	s.listener.rollbacks = append(s.listener.rollbacks, handler)
+	s.listener.mtx.Unlock()
+}
+
+// RegisterBlockHandler registers the handler called with the height of the
+// main chain blocks notified by the SPV module.
+func (s *Service) RegisterBlockHandler(handler func(height uint32)) {
+	s.blockListener.mtx.Lock()
+	s.blockListener.handlers = append(s.blockListener.handlers, handler)
+	s.blockListener.mtx.Unlock()
+}
+
+// GetConfirmations returns the confirmations of the main chain transaction
+// by the best header of the SPV module, which is at most max. The
+// transaction has max or more confirmations if max is returned.
+func (s *Service) GetConfirmations(txId *common.Uint256, max uint32) (uint32, error) {
+	if _, err := s.GetTransaction(txId); err != nil {
+		return 0, err
+	}
+	best, err := s.HeaderStore().GetBest()
+	if err != nil {
+		return 0, err
+	}
+
+	// The transaction has max or more confirmations if it is not in the
+	// last max-1 blocks.
+	for confirmations := uint32(1); confirmations < max &&
+		confirmations <= best.Height; confirmations++ {
+		ids, err := s.GetTransactionIds(best.Height - confirmations + 1)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range ids {
+			if id.IsEqual(*txId) {
+				return confirmations, nil
+			}
+		}
+	}
+	return max, nil
+}
+
 func (s *Service) VerifyTransaction(tx *types.Transaction) error {
 	payload, ok := tx.Payload.(*types.PayloadRechargeToSideChain)
 	if !ok {
@@ -166,9 +267,131 @@ func (s *Service) CheckCRCArbiterSignatureV1(height uint32, sideChainPowTx *ela.
 	return errors.New("CRC arbiter expected")
 }
 
+// GenesisAddress returns the address generated by the side chain genesis
+// block.
+func (s *Service) GenesisAddress() string {
+	return s.listener.address
+}
+
+// GetSideChainPowSigner returns the public key of the arbiter signed the side
+// chain pow transaction, which is one of the CRC arbiters in params or the
+// arbiters of the main chain height.
+func (s *Service) GetSideChainPowSigner(height uint32, sideChainPowTx *ela.Transaction) ([]byte, error) {
+	candidates, err := decodeArbiters(s.chainParams.CRCArbiters)
+	if err != nil {
+		return nil, err
+	}
+	crcArbiters, normalArbiters, err := s.GetArbiters(height)
+	if err == nil {
+		candidates = append(candidates, crcArbiters...)
+		candidates = append(candidates, normalArbiters...)
+	}
+	return sideChainPowSigner(candidates, sideChainPowTx)
+}
+
+// GetHeaderBits returns the bits of the main chain header of the height in
+// the header store of the SPV module.
+func (s *Service) GetHeaderBits(height uint32) (uint32, error) {
+	header, err := s.HeaderStore().GetByHeight(height)
+	if err != nil {
+		return 0, err
+	}
+	return header.Bits(), nil
+}
+
+// BestHeight returns the height of the best header in the header store of
+// the SPV module, or zero if there is no header.
+func (s *Service) BestHeight() uint32 {
+	best, err := s.HeaderStore().GetBest()
+	if err != nil {
+		return 0
+	}
+	return best.Height
+}
+
+// BestHeader returns the height and hash of the best header in the header
+// store of the SPV module.
+func (s *Service) BestHeader() (uint32, common.Uint256, error) {
+	best, err := s.HeaderStore().GetBest()
+	if err != nil {
+		return 0, common.Uint256{}, err
+	}
+	return best.Height, best.Hash(), nil
+}
+
+// stateInterval is the interval to refresh the sync state and peer count of
+// the SPV module.
+const stateInterval = 5 * time.Second
+
+// stateQuerier is the sdk service of the SPV module, answering the queries of
+// its sync manager and p2p server while they are running.
+type stateQuerier interface {
+	IsCurrent() bool
+	ConnectedCount() int32
+}
+
+// Start starts the SPV module and the handler refreshing its state.
+func (s *Service) Start() {
+	s.SPVService.Start()
+	if s.querier == nil {
+		return
+	}
+	s.wg.Add(1)
+	go s.stateHandler()
+}
+
+// Stop stops the handler refreshing the state before the SPV module, which
+// no longer answers the queries once stopped.
+func (s *Service) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+	s.SPVService.Stop()
+}
+
+// stateHandler refreshes the state of the SPV module every stateInterval
+// until the service is stopped.
+func (s *Service) stateHandler() {
+	ticker := time.NewTicker(stateInterval)
+	defer ticker.Stop()
+
+out:
+	for {
+		var current int32
+		if s.querier.IsCurrent() {
+			current = 1
+		}
+		atomic.StoreInt32(&s.current, current)
+		atomic.StoreInt32(&s.peers, s.querier.ConnectedCount())
+
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			break out
+		}
+	}
+	s.wg.Done()
+}
+
+// IsCurrent returns if the SPV module believed it was synced with the
+// connected peers when its state was last refreshed.
+func (s *Service) IsCurrent() bool {
+	return atomic.LoadInt32(&s.current) == 1
+}
+
+// PeerCount returns the number of the peers connected by the SPV module when
+// its state was last refreshed, or -1 if it is not known yet.
+func (s *Service) PeerCount() int32 {
+	return atomic.LoadInt32(&s.peers)
+}
+
 type listener struct {
 	address string
+	txType  ela.TxType
 	service spv.SPVService
+
+	mtx       sync.RWMutex
+	handlers  []func(tx *ela.Transaction, height uint32)
+	rollbacks []func(height uint32)
 }
 
 func (l *listener) Address() string {
@@ -176,7 +399,7 @@ func (l *listener) Address() string {
 }
 
 func (l *listener) Type() ela.TxType {
-	return ela.TransferCrossChainAsset
+	return l.txType
 }
 
 func (l *listener) Flags() uint64 {
@@ -184,13 +407,29 @@ func (l *listener) Flags() uint64 {
 }
 
 func (l *listener) Notify(id common.Uint256, proof bloom.MerkleProof, tx ela.Transaction) {
+	l.mtx.RLock()
+	for _, handler := range l.handlers {
+		handler(&tx, proof.Height)
+	}
+	l.mtx.RUnlock()
 	l.service.SubmitTransactionReceipt(id, tx.Hash())
 }
 
+func (l *listener) rollback(height uint32) {
+	l.mtx.RLock()
+	for _, handler := range l.rollbacks {
+		handler(height)
+	}
+	l.mtx.RUnlock()
+}
+
 type BlockListener struct {
 	blockNumber uint32
 	param       auxParam
 	handle      func(block interface{}) error
+
+	mtx      sync.RWMutex
+	handlers []func(height uint32)
 }
 
 type auxParam struct {
@@ -210,6 +449,12 @@ func (l *BlockListener) NotifyBlock(block *util.Block) {
 		l.handle(l.param.block)
 		l.param.clean()
 	}
+
+	l.mtx.RLock()
+	for _, handler := range l.handlers {
+		handler(block.Height)
+	}
+	l.mtx.RUnlock()
 }
 
 func (l *BlockListener) BlockHeight() uint32 {
@@ -36,3 +36,38 @@ func TestSpvInit(t *testing.T) {
 
 	assert.Equal(t, address, addr)
 }
+
+// stateQuerierMock answers the state queries, and reports the queries of
+// IsCurrent.
+type stateQuerierMock struct {
+	queried chan struct{}
+}
+
+func (q *stateQuerierMock) IsCurrent() bool {
+	q.queried <- struct{}{}
+	return true
+}
+
+func (q *stateQuerierMock) ConnectedCount() int32 {
+	return 3
+}
+
+func TestService_StateHandler(t *testing.T) {
+	querier := &stateQuerierMock{queried: make(chan struct{})}
+	s := &Service{querier: querier, peers: -1, quit: make(chan struct{})}
+
+	// The state is not known until refreshed.
+	assert.False(t, s.IsCurrent())
+	assert.Equal(t, int32(-1), s.PeerCount())
+
+	s.wg.Add(1)
+	go s.stateHandler()
+	<-querier.queried
+
+	// The handler returns once stopped after the refresh, so no goroutine is
+	// left waiting for the queries.
+	close(s.quit)
+	s.wg.Wait()
+	assert.True(t, s.IsCurrent())
+	assert.Equal(t, int32(3), s.PeerCount())
+}
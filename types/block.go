@@ -33,6 +33,11 @@ type Block struct {
 	interfaces.Header
 
 	Transactions []*Transaction
+
+	// targetDataActive returns if the target data is active in the block of
+	// the height, the transactions are deserialized with the target data if
+	// it is nil.
+	targetDataActive func(height uint32) bool
 }
 
 func (b *Block) Serialize(w io.Writer) error {
@@ -72,9 +77,11 @@ func (b *Block) Deserialize(r io.Reader) error {
 	// Deserialize each transaction while keeping track of its location
 	// within the byte stream.
 	b.Transactions = make([]*Transaction, 0)
+	targetData := b.targetDataActive == nil ||
+		b.targetDataActive(b.Header.GetHeight())
 	for i := uint32(0); i < txCount; i++ {
 		tx := Transaction{}
-		if err := tx.Deserialize(r); err != nil {
+		if err := tx.DeserializeWithTargetData(r, targetData); err != nil {
 			return err
 		}
 		b.Transactions = append(b.Transactions, &tx)
@@ -85,17 +92,17 @@ func (b *Block) Deserialize(r io.Reader) error {
 
 func (b *Block) Trim(w io.Writer) error {
 	if err := b.Header.Serialize(w); err != nil {
-		return fmt.Errorf("Trim block serialize header failed:", err)
+		return fmt.Errorf("Trim block serialize header failed: %s", err)
 	}
 
 	if err := common.WriteUint32(w, uint32(len(b.Transactions))); err != nil {
-		return fmt.Errorf("Trim block write txs len failed:", err)
+		return fmt.Errorf("Trim block write txs len failed: %s", err)
 	}
 
 	for _, transaction := range b.Transactions {
 		hash := transaction.Hash()
 		if err := hash.Serialize(w); err != nil {
-			return fmt.Errorf("Trim block serialize tx hash failed:", err)
+			return fmt.Errorf("Trim block serialize tx hash failed: %s", err)
 		}
 	}
 
@@ -144,3 +151,12 @@ func NewBlock() *Block {
 	}
 	return b
 }
+
+// NewBlockWithTargetData returns an empty block to deserialize, whose
+// transactions carry the target data if targetDataActive returns true for the
+// height of the block.
+func NewBlockWithTargetData(targetDataActive func(height uint32) bool) *Block {
+	b := NewBlock()
+	b.targetDataActive = targetDataActive
+	return b
+}
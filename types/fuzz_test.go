@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzTransaction(f *testing.F) {
+	for _, tx := range seedTransactions() {
+		buf := new(bytes.Buffer)
+		tx.Serialize(buf)
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, func() serializable {
+			return new(Transaction)
+		}, true)
+	})
+}
+
+func FuzzPayload(f *testing.F) {
+	for _, tx := range seedTransactions() {
+		buf := new(bytes.Buffer)
+		tx.Payload.Serialize(buf, tx.PayloadVersion)
+		f.Add(byte(tx.TxType), tx.PayloadVersion, buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, txType byte, version byte, data []byte) {
+		payload, err := GetPayloadByTxType(TxType(txType))
+		if err != nil {
+			return
+		}
+		r := bytes.NewReader(data)
+		if err := payload.Deserialize(r, version); err != nil {
+			return
+		}
+		payload.Data(version)
+
+		buf := new(bytes.Buffer)
+		assert.NoError(t, payload.Serialize(buf, version))
+		assert.True(t, bytes.Equal(data[:len(data)-r.Len()], buf.Bytes()))
+	})
+}
+
+func FuzzHeader(f *testing.F) {
+	buf := new(bytes.Buffer)
+	seedBlock().Header.Serialize(buf)
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// The byte after the side aux pow is ignored when deserializing.
+		checkRoundTrip(t, data, func() serializable {
+			return new(Header)
+		}, false)
+	})
+}
+
+func FuzzBlock(f *testing.F) {
+	buf := new(bytes.Buffer)
+	seedBlock().Serialize(buf)
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, func() serializable {
+			return NewBlock()
+		}, false)
+	})
+}
@@ -9,6 +9,33 @@ import (
 	"github.com/elastos/Elastos.ELA/common"
 )
 
+const (
+	// SequenceLockDisableFlag disables the relative lock of the input if it
+	// is set in the sequence.
+	SequenceLockDisableFlag uint32 = 1 << 31
+
+	// SequenceRelativeLockFlag enables the relative lock of the input if it
+	// is set in the sequence, the input can not be spent until the number of
+	// blocks given by SequenceLockMask have been generated after the block
+	// containing the referenced output.
+	SequenceRelativeLockFlag uint32 = 1 << 30
+
+	// SequenceLockMask extracts the number of blocks of the relative lock
+	// from the sequence.
+	SequenceLockMask uint32 = 0x0000ffff
+)
+
+// RelativeLock returns the number of blocks the input is locked for after the
+// block containing the referenced output, and if the relative lock is
+// enabled by the sequence.
+func RelativeLock(sequence uint32) (uint32, bool) {
+	if sequence&SequenceLockDisableFlag != 0 ||
+		sequence&SequenceRelativeLockFlag == 0 {
+		return 0, false
+	}
+	return sequence & SequenceLockMask, true
+}
+
 type Input struct {
 	// Reference outpoint of this input
 	Previous OutPoint
@@ -0,0 +1,273 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/auxpow"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// payloadVectors are the serialized bytes and the data of the payloads of
+// all the transaction types, shared with the other implementations of the
+// side chain transactions.
+var payloadVectors = []struct {
+	name       string
+	txType     TxType
+	version    byte
+	payload    Payload
+	serialized string
+	data       string
+}{
+	{
+		name:       "coinbase",
+		txType:     CoinBase,
+		version:    PayloadCoinBaseVersion,
+		payload:    &PayloadCoinBase{CoinbaseData: []byte("ELA")},
+		serialized: "03454c41",
+		data:       "454c41",
+	},
+	{
+		name:    "register asset",
+		txType:  RegisterAsset,
+		version: 0,
+		payload: &PayloadRegisterAsset{
+			Asset: Asset{
+				Name:      "ELA",
+				Precision: 0x08,
+				AssetType: Token,
+			},
+			Controller: common.Uint168{0x21, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		serialized: "03454c410008000000000000000000002101020304" +
+			"05060708090a00000000000000000000",
+		data: "00",
+	},
+	{
+		name:       "transfer asset",
+		txType:     TransferAsset,
+		version:    0,
+		payload:    new(PayloadTransferAsset),
+		serialized: "",
+		data:       "00",
+	},
+	{
+		name:    "record",
+		txType:  Record,
+		version: RecordPayloadVersion,
+		payload: &PayloadRecord{
+			RecordType: "test",
+			RecordData: []byte("record"),
+		},
+		serialized: "0474657374067265636f7264",
+		data:       "00",
+	},
+	{
+		name:    "recharge to side chain version 0",
+		txType:  RechargeToSideChain,
+		version: RechargeToSideChainPayloadVersion0,
+		payload: &PayloadRechargeToSideChain{
+			MerkleProof:          []byte{4, 4},
+			MainChainTransaction: []byte{5, 5, 5},
+		},
+		serialized: "020404" + "03050505",
+		data:       "020404" + "03050505",
+	},
+	{
+		name:    "recharge to side chain version 1",
+		txType:  RechargeToSideChain,
+		version: RechargeToSideChainPayloadVersion1,
+		payload: &PayloadRechargeToSideChain{
+			MainChainTransactionHash: common.Uint256{6, 7, 8},
+		},
+		serialized: "0607080000000000000000000000000000000000" +
+			"000000000000000000000000",
+		data: "0607080000000000000000000000000000000000" +
+			"000000000000000000000000",
+	},
+	{
+		name:    "transfer cross chain asset version 0",
+		txType:  TransferCrossChainAsset,
+		version: TransferCrossChainAssetVersion0,
+		payload: &PayloadTransferCrossChainAsset{
+			CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+			OutputIndexes:       []uint64{0},
+			CrossChainAmounts:   []common.Fixed64{99990000},
+		},
+		serialized: "0122455861377334314b5948564d37544d446743" +
+			"35444e674c774738544547526478695300f0b9f50500000000",
+		data: "00",
+	},
+	{
+		name:    "transfer cross chain asset version 1",
+		txType:  TransferCrossChainAsset,
+		version: TransferCrossChainAssetVersion1,
+		payload: &PayloadTransferCrossChainAsset{
+			CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+			OutputIndexes:       []uint64{0},
+			CrossChainAmounts:   []common.Fixed64{99990000},
+		},
+		serialized: "0122455861377334314b5948564d37544d446743" +
+			"35444e674c774738544547526478695300f0b9f50500000000",
+		data: "0122455861377334314b5948564d37544d446743" +
+			"35444e674c774738544547526478695300f0b9f50500000000",
+	},
+	{
+		name:    "transfer cross chain asset version 2",
+		txType:  TransferCrossChainAsset,
+		version: TransferCrossChainAssetVersion2,
+		payload: &PayloadTransferCrossChainAsset{
+			CrossChainAddresses: []string{
+				"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS",
+				"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS",
+			},
+			OutputIndexes:     []uint64{0, 1},
+			CrossChainAmounts: []common.Fixed64{99990000, 100},
+			TargetData:        [][]byte{[]byte("memo"), {}},
+		},
+		serialized: "0222455861377334314b5948564d37544d446743" +
+			"35444e674c774738544547526478695300f0b9f50500000000" +
+			"046d656d6f22455861377334314b5948564d37544d446743" +
+			"35444e674c77473854454752647869530164000000000000" +
+			"0000",
+		data: "0222455861377334314b5948564d37544d446743" +
+			"35444e674c774738544547526478695300f0b9f50500000000" +
+			"046d656d6f22455861377334314b5948564d37544d446743" +
+			"35444e674c77473854454752647869530164000000000000" +
+			"0000",
+	},
+}
+
+func TestPayloadVectors(t *testing.T) {
+	for _, v := range payloadVectors {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, v.payload.Serialize(buf, v.version), v.name)
+		assert.Equal(t, v.serialized, common.BytesToHexString(buf.Bytes()),
+			v.name)
+		assert.Equal(t, v.data,
+			common.BytesToHexString(v.payload.Data(v.version)), v.name)
+
+		decoded, err := GetPayloadByTxType(v.txType)
+		assert.NoError(t, err, v.name)
+		assert.NoError(t, decoded.Deserialize(bytes.NewReader(buf.Bytes()),
+			v.version), v.name)
+		assert.Equal(t, v.data,
+			common.BytesToHexString(decoded.Data(v.version)), v.name)
+	}
+}
+
+func TestPayloadTransferCrossChainAsset_Data(t *testing.T) {
+	p := &PayloadTransferCrossChainAsset{
+		CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+		OutputIndexes:       []uint64{0},
+		CrossChainAmounts:   []common.Fixed64{99990000},
+	}
+	data := p.Data(TransferCrossChainAssetVersion1)
+
+	// The version 1 data commits to the payload content.
+	p.CrossChainAmounts[0] = 99980000
+	assert.NotEqual(t, data, p.Data(TransferCrossChainAssetVersion1))
+	assert.Equal(t, []byte{0}, p.Data(TransferCrossChainAssetVersion0))
+
+	// The invalid payload has no data.
+	p.OutputIndexes = nil
+	assert.Equal(t, []byte{0}, p.Data(TransferCrossChainAssetVersion1))
+}
+
+func TestPayloadTransferCrossChainAsset_TargetData(t *testing.T) {
+	p := &PayloadTransferCrossChainAsset{
+		CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+		OutputIndexes:       []uint64{0},
+		CrossChainAmounts:   []common.Fixed64{99990000},
+	}
+
+	// The version 2 payload must have the target data of every output.
+	buf := new(bytes.Buffer)
+	assert.Error(t, p.Serialize(buf, TransferCrossChainAssetVersion2))
+
+	// The target data is not serialized before version 2.
+	p.TargetData = [][]byte{[]byte("memo")}
+	buf = new(bytes.Buffer)
+	assert.NoError(t, p.Serialize(buf, TransferCrossChainAssetVersion1))
+	decoded := new(PayloadTransferCrossChainAsset)
+	assert.NoError(t, decoded.Deserialize(bytes.NewReader(buf.Bytes()),
+		TransferCrossChainAssetVersion1))
+	assert.Nil(t, decoded.TargetData)
+
+	// The target data over the size limit is not deserialized.
+	p.TargetData = [][]byte{make([]byte, MaxCrossChainTargetDataSize+1)}
+	buf = new(bytes.Buffer)
+	assert.NoError(t, p.Serialize(buf, TransferCrossChainAssetVersion2))
+	decoded = new(PayloadTransferCrossChainAsset)
+	assert.Error(t, decoded.Deserialize(bytes.NewReader(buf.Bytes()),
+		TransferCrossChainAssetVersion2))
+}
+
+func TestTransaction_DeserializeWithTargetData(t *testing.T) {
+	tx := &Transaction{
+		TxType: TransferCrossChainAsset,
+		Payload: &PayloadTransferCrossChainAsset{
+			CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+			OutputIndexes:       []uint64{0},
+			CrossChainAmounts:   []common.Fixed64{99990000},
+		},
+		Outputs: []*Output{{Value: 100000000}},
+	}
+
+	// The version 2 payloads stored below the activation were accepted
+	// without the target data, and are read and written as they were.
+	buf := new(bytes.Buffer)
+	assert.NoError(t, tx.Serialize(buf))
+	legacy := buf.Bytes()
+	legacy[1] = TransferCrossChainAssetVersion2
+	decoded := new(Transaction)
+	assert.NoError(t, decoded.DeserializeWithTargetData(bytes.NewReader(legacy), false))
+	p := decoded.Payload.(*PayloadTransferCrossChainAsset)
+	assert.False(t, p.HasTargetData(decoded.PayloadVersion))
+	assert.Nil(t, p.TargetData)
+	buf = new(bytes.Buffer)
+	assert.NoError(t, decoded.Serialize(buf))
+	assert.Equal(t, legacy, buf.Bytes())
+
+	// The version 2 payloads carry the target data from the activation.
+	tx.PayloadVersion = TransferCrossChainAssetVersion2
+	tx.Payload.(*PayloadTransferCrossChainAsset).TargetData = [][]byte{{}}
+	buf = new(bytes.Buffer)
+	assert.NoError(t, tx.Serialize(buf))
+	data := buf.Bytes()
+	decoded = new(Transaction)
+	assert.NoError(t, decoded.DeserializeWithTargetData(bytes.NewReader(data), true))
+	assert.Equal(t, tx.Hash(), decoded.Hash())
+	assert.Equal(t, [][]byte{{}},
+		decoded.Payload.(*PayloadTransferCrossChainAsset).TargetData)
+
+	// The versions above 2 carry no target data.
+	legacy[1] = TransferCrossChainAssetVersion2 + 1
+	decoded = new(Transaction)
+	assert.NoError(t, decoded.DeserializeWithTargetData(bytes.NewReader(legacy), true))
+	assert.False(t, decoded.Payload.(*PayloadTransferCrossChainAsset).
+		HasTargetData(decoded.PayloadVersion))
+
+	// The blocks are read with the target data active at their heights.
+	legacy[1] = TransferCrossChainAssetVersion2
+	legacyTx := new(Transaction)
+	assert.NoError(t, legacyTx.DeserializeWithTargetData(bytes.NewReader(legacy), false))
+	active := func(height uint32) bool { return height >= 100 }
+	for height, tx := range map[uint32]*Transaction{99: legacyTx, 100: tx} {
+		block := &Block{
+			Header: &Header{
+				Base:       BaseHeader{Height: height},
+				SideAuxPow: *auxpow.GenerateSideAuxPow(common.EmptyHash, common.EmptyHash),
+			},
+			Transactions: []*Transaction{tx},
+		}
+		buf = new(bytes.Buffer)
+		assert.NoError(t, block.Serialize(buf))
+		decodedBlock := NewBlockWithTargetData(active)
+		assert.NoError(t, decodedBlock.Deserialize(bytes.NewReader(buf.Bytes())))
+		assert.Equal(t, tx.Hash(), decodedBlock.Transactions[0].Hash())
+		assert.Equal(t, tx.Payload, decodedBlock.Transactions[0].Payload)
+	}
+}
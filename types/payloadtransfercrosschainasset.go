@@ -1,27 +1,79 @@
 package types
 
 import (
+	"bytes"
 	"errors"
 	"io"
 
 	"github.com/elastos/Elastos.ELA/common"
 )
 
+const (
+	// TransferCrossChainAssetVersion0 is the original payload version, whose
+	// Data is a placeholder not committing to the payload content.
+	TransferCrossChainAssetVersion0 byte = 0x00
+
+	// TransferCrossChainAssetVersion1 is the payload version whose Data is
+	// the serialized payload. Data is only shown by Transaction.String, the
+	// payload content is committed to by the transaction hash of all the
+	// versions.
+	TransferCrossChainAssetVersion1 byte = 0x01
+
+	// TransferCrossChainAssetVersion2 is the payload version carrying the
+	// target data of every cross chain output, which is accepted from the
+	// height of CrossChainTargetDataStartHeight. The payloads of the blocks
+	// below it carry no target data whatever their versions are.
+	TransferCrossChainAssetVersion2 byte = 0x02
+
+	// MaxCrossChainTargetDataSize is the max size of the target data of a
+	// cross chain output.
+	MaxCrossChainTargetDataSize = 1024
+)
+
 type PayloadTransferCrossChainAsset struct {
 	CrossChainAddresses []string
 	OutputIndexes       []uint64
 	CrossChainAmounts   []common.Fixed64
+
+	// TargetData is the data passed to the target of every cross chain
+	// output, such as a memo or contract call data, which is serialized
+	// from TransferCrossChainAssetVersion2 only.
+	TargetData [][]byte
+
+	// withoutTargetData is set for the payloads read from the blocks where
+	// the target data is not active, which are serialized without the
+	// target data whatever their versions are.
+	withoutTargetData bool
+}
+
+// HasTargetData returns if the payload of the version carries the target data
+// of the cross chain outputs. Only TransferCrossChainAssetVersion2 carries it,
+// the versions above it are read as the ones before it.
+func (a *PayloadTransferCrossChainAsset) HasTargetData(version byte) bool {
+	return version == TransferCrossChainAssetVersion2 && !a.withoutTargetData
 }
 
 func (a *PayloadTransferCrossChainAsset) Data(version byte) []byte {
-	//TODO: implement TransferCrossChainAsset.Data()
-	return []byte{0}
+	// The data of the version 0 payloads is kept unchanged.
+	if version < TransferCrossChainAssetVersion1 {
+		return []byte{0}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := a.Serialize(buf, version); err != nil {
+		return []byte{0}
+	}
+	return buf.Bytes()
 }
 
 func (a *PayloadTransferCrossChainAsset) Serialize(w io.Writer, version byte) error {
 	if len(a.CrossChainAddresses) != len(a.OutputIndexes) || len(a.OutputIndexes) != len(a.CrossChainAmounts) {
 		return errors.New("Invalid cross chain asset")
 	}
+	if a.HasTargetData(version) &&
+		len(a.TargetData) != len(a.CrossChainAddresses) {
+		return errors.New("Invalid cross chain asset target data")
+	}
 
 	if err := common.WriteVarUint(w, uint64(len(a.CrossChainAddresses))); err != nil {
 		return errors.New("PayloadTransferCrossChainAsset length serialize failed")
@@ -40,6 +92,12 @@ func (a *PayloadTransferCrossChainAsset) Serialize(w io.Writer, version byte) er
 		if err != nil {
 			return errors.New("CrossChainAmounts serialize failed")
 		}
+
+		if a.HasTargetData(version) {
+			if err := common.WriteVarBytes(w, a.TargetData[i]); err != nil {
+				return errors.New("TargetData serialize failed")
+			}
+		}
 	}
 
 	return nil
@@ -71,6 +129,15 @@ func (a *PayloadTransferCrossChainAsset) Deserialize(r io.Reader, version byte)
 		a.CrossChainAddresses = append(a.CrossChainAddresses, address)
 		a.OutputIndexes = append(a.OutputIndexes, index)
 		a.CrossChainAmounts = append(a.CrossChainAmounts, amount)
+
+		if a.HasTargetData(version) {
+			data, err := common.ReadVarBytes(r, MaxCrossChainTargetDataSize,
+				"PayloadTransferCrossChainAsset TargetData")
+			if err != nil {
+				return errors.New("TargetData deserialize failed")
+			}
+			a.TargetData = append(a.TargetData, data)
+		}
 	}
 
 	return nil
@@ -0,0 +1,290 @@
+package types
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastos/Elastos.ELA.SideChain/auxpow"
+
+	"github.com/elastos/Elastos.ELA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// serializable is the interface of the items read from the network.
+type serializable interface {
+	Serialize(w io.Writer) error
+	Deserialize(r io.Reader) error
+}
+
+// seedTransactions returns transactions of all the types of
+// GetPayloadByTxType, in the same forms as the ones in the blocks.
+func seedTransactions() []*Transaction {
+	assetID := GetSystemAssetId()
+	programHash := common.Uint168{0x21, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	program := &Program{
+		Code: append(append([]byte{33}, bytes.Repeat([]byte{2}, 33)...),
+			0xac),
+		Parameter: append([]byte{64}, bytes.Repeat([]byte{3}, 64)...),
+	}
+	input := &Input{
+		Previous: OutPoint{TxID: common.Uint256{1, 2, 3}, Index: 1},
+		Sequence: math.MaxUint32,
+	}
+	output := &Output{
+		AssetID:     assetID,
+		Value:       100000000,
+		OutputLock:  0,
+		ProgramHash: programHash,
+	}
+
+	return []*Transaction{
+		{
+			TxType:         CoinBase,
+			PayloadVersion: PayloadCoinBaseVersion,
+			Payload:        &PayloadCoinBase{CoinbaseData: []byte("ELA")},
+			Attributes: []*Attribute{
+				{Usage: Nonce, Data: []byte("1546421405")},
+			},
+			Inputs: []*Input{{
+				Previous: OutPoint{TxID: common.EmptyHash, Index: math.MaxUint16},
+				Sequence: math.MaxUint32,
+			}},
+			Outputs:  []*Output{output, output},
+			LockTime: 100,
+		},
+		{
+			TxType: RegisterAsset,
+			Payload: &PayloadRegisterAsset{
+				Asset: Asset{
+					Name:      "ELA",
+					Precision: 0x08,
+					AssetType: Token,
+				},
+				Controller: programHash,
+			},
+		},
+		{
+			TxType:     TransferAsset,
+			Attributes: []*Attribute{{Usage: Memo, Data: []byte("memo")}},
+			Payload:    new(PayloadTransferAsset),
+			Inputs:     []*Input{input},
+			Outputs:    []*Output{output},
+			Programs:   []*Program{program},
+		},
+		{
+			TxType: Record,
+			Payload: &PayloadRecord{
+				RecordType: "test",
+				RecordData: []byte("record"),
+			},
+			Inputs:   []*Input{input},
+			Programs: []*Program{program},
+		},
+		{
+			TxType:         RechargeToSideChain,
+			PayloadVersion: RechargeToSideChainPayloadVersion0,
+			Payload: &PayloadRechargeToSideChain{
+				MerkleProof:          bytes.Repeat([]byte{4}, 100),
+				MainChainTransaction: bytes.Repeat([]byte{5}, 200),
+			},
+			Outputs: []*Output{output},
+		},
+		{
+			TxType:         RechargeToSideChain,
+			PayloadVersion: RechargeToSideChainPayloadVersion1,
+			Payload: &PayloadRechargeToSideChain{
+				MainChainTransactionHash: common.Uint256{6, 7, 8},
+			},
+			Outputs: []*Output{output},
+		},
+		{
+			TxType: TransferCrossChainAsset,
+			Payload: &PayloadTransferCrossChainAsset{
+				CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+				OutputIndexes:       []uint64{0},
+				CrossChainAmounts:   []common.Fixed64{99990000},
+			},
+			Inputs:   []*Input{input},
+			Outputs:  []*Output{output},
+			Programs: []*Program{program},
+		},
+		{
+			TxType:         TransferCrossChainAsset,
+			PayloadVersion: TransferCrossChainAssetVersion2,
+			Payload: &PayloadTransferCrossChainAsset{
+				CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+				OutputIndexes:       []uint64{0},
+				CrossChainAmounts:   []common.Fixed64{99990000},
+				TargetData:          [][]byte{[]byte("memo")},
+			},
+			Inputs:   []*Input{input},
+			Outputs:  []*Output{output},
+			Programs: []*Program{program},
+		},
+		{
+			TxType:         TransferCrossChainAsset,
+			PayloadVersion: TransferCrossChainAssetVersion1,
+			Payload: &PayloadTransferCrossChainAsset{
+				CrossChainAddresses: []string{"EXa7s41KYHVM7TMDgC5DNgLwG8TEGRdxiS"},
+				OutputIndexes:       []uint64{0},
+				CrossChainAmounts:   []common.Fixed64{99990000},
+			},
+			Inputs:   []*Input{input},
+			Outputs:  []*Output{output},
+			Programs: []*Program{program},
+		},
+	}
+}
+
+// seedBlock returns a block with the transactions of seedTransactions.
+func seedBlock() *Block {
+	sideAuxPow := auxpow.GenerateSideAuxPow(common.Uint256{1}, common.Uint256{2})
+	return &Block{
+		Header: &Header{
+			Base: BaseHeader{
+				Version:    BlockVersion,
+				Previous:   common.Uint256{3},
+				MerkleRoot: common.Uint256{4},
+				Timestamp:  1546421405,
+				Bits:       0x1d03ffff,
+				Nonce:      GenesisNonce,
+				Height:     1000,
+			},
+			SideAuxPow: *sideAuxPow,
+		},
+		Transactions: seedTransactions(),
+	}
+}
+
+// sideChainGenesisBlock returns the genesis block of a side chain built from
+// its params, which registers the ELA asset at height zero.
+func sideChainGenesisBlock() *Block {
+	elaAsset := &Transaction{
+		TxType: RegisterAsset,
+		Payload: &PayloadRegisterAsset{
+			Asset: Asset{
+				Name:      "ELA",
+				Precision: 0x08,
+				AssetType: 0x00,
+			},
+			Controller: common.Uint168{},
+		},
+		Attributes: []*Attribute{},
+		Inputs:     []*Input{},
+		Outputs:    []*Output{},
+		Programs:   []*Program{},
+	}
+	return &Block{
+		Header: &Header{
+			Base: BaseHeader{
+				Version:    BlockVersion,
+				Previous:   common.EmptyHash,
+				MerkleRoot: elaAsset.Hash(),
+				Timestamp:  uint32(time.Date(2018, time.June, 30, 12, 0, 0, 0, time.UTC).Unix()),
+				Bits:       0x1d03ffff,
+				Nonce:      GenesisNonce,
+				Height:     0,
+			},
+			SideAuxPow: *auxpow.GenerateSideAuxPow(common.EmptyHash, common.EmptyHash),
+		},
+		Transactions: []*Transaction{elaAsset},
+	}
+}
+
+// serialize returns the serialized bytes of the item.
+func serialize(t *testing.T, item serializable) []byte {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, item.Serialize(buf))
+	return buf.Bytes()
+}
+
+// checkRoundTrip deserializes the data into the item, and checks the item is
+// serialized into the same bytes as the ones deserialized again. If exact is
+// true, the bytes must be the ones read from the data.
+func checkRoundTrip(t *testing.T, data []byte, newItem func() serializable,
+	exact bool) {
+	r := bytes.NewReader(data)
+	item := newItem()
+	if err := item.Deserialize(r); err != nil {
+		return
+	}
+	serialized := serialize(t, item)
+	if exact {
+		assert.True(t, bytes.Equal(data[:len(data)-r.Len()], serialized))
+	}
+
+	again := newItem()
+	assert.NoError(t, again.Deserialize(bytes.NewReader(serialized)))
+	assert.Equal(t, serialized, serialize(t, again))
+}
+
+func TestSerializeSeeds(t *testing.T) {
+	for _, tx := range seedTransactions() {
+		data := serialize(t, tx)
+		decoded := new(Transaction)
+		assert.NoError(t, decoded.Deserialize(bytes.NewReader(data)))
+		assert.Equal(t, data, serialize(t, decoded), tx.TxType.String())
+	}
+
+	data := serialize(t, seedBlock())
+	decoded := NewBlock()
+	assert.NoError(t, decoded.Deserialize(bytes.NewReader(data)))
+	assert.Equal(t, data, serialize(t, decoded))
+}
+
+func TestRealTransactionCorpus(t *testing.T) {
+	// The corpus holds the transactions of the ELA main chain genesis blocks,
+	// which must decode to the same hashes they have on the main chain.
+	hashes := map[string]common.Uint256{
+		"ela_mainnet_genesis_coinbase": hashFromString(t, "b07c062090c44682e29832f1993d4a0f47e49a148d8b0e07d739a32670ff3a95"),
+		"ela_testnet_genesis_coinbase": hashFromString(t, "6817addb1eb959d0d56117fd54b6e795788d54ec2a950c209d858da182cf3291"),
+		"ela_asset_registration":       GetSystemAssetId(),
+	}
+	for name, hash := range hashes {
+		tx := new(Transaction)
+		data := readCorpus(t, "FuzzTransaction", name)
+		assert.NoError(t, tx.Deserialize(bytes.NewReader(data)))
+		assert.Equal(t, hash, tx.Hash(), name)
+	}
+}
+
+func TestSideChainGenesisCorpus(t *testing.T) {
+	// The corpus holds the genesis block of a side chain, which must decode
+	// to the block built from the params and register the ELA asset.
+	genesis := sideChainGenesisBlock()
+	assert.Equal(t, GetSystemAssetId(), genesis.Transactions[0].Hash())
+
+	block := NewBlock()
+	data := readCorpus(t, "FuzzBlock", "side_genesis_block")
+	assert.NoError(t, block.Deserialize(bytes.NewReader(data)))
+	assert.Equal(t, genesis.Hash(), block.Hash())
+
+	header := new(Header)
+	data = readCorpus(t, "FuzzHeader", "side_genesis_header")
+	assert.NoError(t, header.Deserialize(bytes.NewReader(data)))
+	assert.Equal(t, genesis.Hash(), header.Hash())
+}
+
+// readCorpus returns the bytes of a seed in the corpus of the fuzz target.
+func readCorpus(t *testing.T, target, name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "fuzz", target, name))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+	data, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(lines[1], "[]byte("), ")"))
+	assert.NoError(t, err)
+	return []byte(data)
+}
+
+func hashFromString(t *testing.T, s string) common.Uint256 {
+	hash, err := common.Uint256FromHexString(s)
+	assert.NoError(t, err)
+	return *hash
+}
@@ -142,8 +142,21 @@ func (tx *Transaction) SerializeUnsigned(w io.Writer) error {
 
 //deserialize the Transaction
 func (tx *Transaction) Deserialize(r io.Reader) error {
+	return tx.deserialize(r, true)
+}
+
+// DeserializeWithTargetData deserializes the transaction of a block, whose
+// transfer cross chain asset payload carries the target data only if it is
+// active in the block. All the payload versions were accepted before the
+// target data, so the payloads of the blocks below its activation are read as
+// they were stored.
+func (tx *Transaction) DeserializeWithTargetData(r io.Reader, active bool) error {
+	return tx.deserialize(r, active)
+}
+
+func (tx *Transaction) deserialize(r io.Reader, targetData bool) error {
 	// tx deserialize
-	err := tx.DeserializeUnsigned(r)
+	err := tx.deserializeUnsigned(r, targetData)
 	if err != nil {
 		return errors.New("transaction Deserialize error: " + err.Error())
 	}
@@ -168,6 +181,10 @@ func (tx *Transaction) Deserialize(r io.Reader) error {
 }
 
 func (tx *Transaction) DeserializeUnsigned(r io.Reader) error {
+	return tx.deserializeUnsigned(r, true)
+}
+
+func (tx *Transaction) deserializeUnsigned(r io.Reader, targetData bool) error {
 	var txType = make([]byte, 1)
 	_, err := r.Read(txType)
 	if err != nil {
@@ -186,6 +203,9 @@ func (tx *Transaction) DeserializeUnsigned(r io.Reader) error {
 	if err != nil {
 		return err
 	}
+	if p, ok := tx.Payload.(*PayloadTransferCrossChainAsset); ok {
+		p.withoutTargetData = !targetData
+	}
 
 	err = tx.Payload.Deserialize(r, tx.PayloadVersion)
 	if err != nil {
@@ -299,6 +319,22 @@ func (tx *Transaction) GetData() []byte {
 	return buf.Bytes()
 }
 
+// GetLockTime returns the lock time of the transaction, it implements the VM
+// ITimeLockContainer interface.
+func (tx *Transaction) GetLockTime() uint32 {
+	return tx.LockTime
+}
+
+// GetInputSequences returns the sequences of the transaction inputs, it
+// implements the VM ITimeLockContainer interface.
+func (tx *Transaction) GetInputSequences() []uint32 {
+	sequences := make([]uint32, 0, len(tx.Inputs))
+	for _, input := range tx.Inputs {
+		sequences = append(sequences, input.Sequence)
+	}
+	return sequences
+}
+
 var TxTypeStr = func(txType TxType) string {
 	s, ok := ttStrings[txType]
 	if ok {
@@ -0,0 +1,373 @@
+/*
+Package asm disassembles the scripts of transaction programs into readable
+opcodes and assembles the text back into scripts.
+
+The text is a list of tokens separated by white spaces. An opcode is written
+by its name in the vm.OpExecList table, and its operand, if any, is the
+following token:
+
+	0x<hex>               data pushed by PUSHBYTES1 to PUSHBYTES75
+	PUSHDATA1 0x<hex>     data pushed by PUSHDATA1, same for PUSHDATA2 and PUSHDATA4
+	JMP <offset>          decimal offset of JMP, JMPIF, JMPIFNOT and CALL
+	APPCALL 0x<hex>       20 bytes script hash
+	SYSCALL <name>        name of the interop service
+	UNKNOWN_0x<hex>       byte which is not an opcode
+
+For example, the standard signature redeem script is
+
+	0x02...ab CHECKSIG
+
+The data is assembled by the shortest push opcode, so a script assembled from
+its disassembly is the same script if its data pushes are the shortest ones.
+*/
+package asm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+)
+
+const (
+	// dataPrefix is the prefix of data tokens.
+	dataPrefix = "0x"
+
+	// unknownPrefix is the prefix of bytes which are not opcodes.
+	unknownPrefix = "UNKNOWN_0x"
+
+	// scriptHashLength is the length of the script hash of APPCALL.
+	scriptHashLength = 20
+)
+
+// opCodes maps the names of opcodes to opcodes, including the aliases of the
+// names in vm.OpExecList.
+var opCodes = func() map[string]vm.OpCode {
+	opCodes := map[string]vm.OpCode{
+		"PUSH0": vm.PUSH0,
+		"PUSHF": vm.PUSHF,
+		"PUSHT": vm.PUSHT,
+		"XSWAP": vm.XSWAP,
+	}
+	for i := 1; i <= 16; i++ {
+		opCodes["PUSH"+strconv.Itoa(i)] = vm.OpCode(vm.PUSH1 + i - 1)
+	}
+	for i, op := range vm.OpExecList {
+		if op.Name == "" || i >= vm.PUSHBYTES1 && i <= vm.PUSHBYTES75 {
+			continue
+		}
+		opCodes[op.Name] = vm.OpCode(i)
+	}
+	return opCodes
+}()
+
+// Disassemble returns the text of the script. If the script is truncated,
+// the text of the opcodes before the truncated one is returned with the
+// error.
+func Disassemble(script []byte) (string, error) {
+	tokens := make([]string, 0, len(script))
+	for i := 0; i < len(script); {
+		op := vm.OpCode(script[i])
+		i++
+
+		// read returns the next n bytes of the script.
+		read := func(n int) ([]byte, error) {
+			if n < 0 || n > len(script)-i {
+				return nil, fmt.Errorf("script is truncated at %d", i)
+			}
+			b := script[i : i+n]
+			i += n
+			return b, nil
+		}
+
+		switch {
+		case op >= vm.PUSHBYTES1 && op <= vm.PUSHBYTES75:
+			data, err := read(int(op))
+			if err != nil {
+				return strings.Join(tokens, " "), err
+			}
+			tokens = append(tokens, dataPrefix+hex.EncodeToString(data))
+			continue
+
+		case vm.OpExecList[op].Name == "":
+			tokens = append(tokens, fmt.Sprintf("%s%02x", unknownPrefix, byte(op)))
+			continue
+		}
+
+		name := vm.OpExecList[op].Name
+		var operand string
+		var err error
+		switch op {
+		case vm.PUSHDATA1, vm.PUSHDATA2, vm.PUSHDATA4:
+			var length []byte
+			if length, err = read(lengthSize(op)); err != nil {
+				break
+			}
+			var data []byte
+			if data, err = read(decodeLength(op, length)); err != nil {
+				break
+			}
+			operand = dataPrefix + hex.EncodeToString(data)
+
+		case vm.JMP, vm.JMPIF, vm.JMPIFNOT, vm.CALL:
+			var offset []byte
+			if offset, err = read(2); err != nil {
+				break
+			}
+			operand = strconv.Itoa(int(int16(binary.BigEndian.Uint16(offset))))
+
+		case vm.APPCALL:
+			var hash []byte
+			if hash, err = read(scriptHashLength); err != nil {
+				break
+			}
+			operand = dataPrefix + hex.EncodeToString(hash)
+
+		case vm.SYSCALL:
+			var name []byte
+			if name, err = readVarBytes(read); err != nil {
+				break
+			}
+			if !isServiceName(string(name)) {
+				err = fmt.Errorf("invalid interop service name %q", name)
+				break
+			}
+			operand = string(name)
+		}
+		if err != nil {
+			return strings.Join(tokens, " "), err
+		}
+
+		tokens = append(tokens, name)
+		if operand != "" {
+			tokens = append(tokens, operand)
+		}
+	}
+	return strings.Join(tokens, " "), nil
+}
+
+// Assemble returns the script of the text.
+func Assemble(text string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	tokens := strings.Fields(text)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if strings.HasPrefix(token, unknownPrefix) {
+			b, err := hex.DecodeString(strings.TrimPrefix(token, unknownPrefix))
+			if err != nil || len(b) != 1 {
+				return nil, fmt.Errorf("invalid token %q", token)
+			}
+			buf.WriteByte(b[0])
+			continue
+		}
+
+		if strings.HasPrefix(token, dataPrefix) {
+			data, err := decodeData(token)
+			if err != nil {
+				return nil, err
+			}
+			if err := pushData(buf, data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		op, ok := opCodes[token]
+		if !ok {
+			return nil, fmt.Errorf("unknown opcode %q", token)
+		}
+		buf.WriteByte(byte(op))
+
+		switch op {
+		case vm.PUSHDATA1, vm.PUSHDATA2, vm.PUSHDATA4, vm.JMP, vm.JMPIF,
+			vm.JMPIFNOT, vm.CALL, vm.APPCALL, vm.SYSCALL:
+		default:
+			continue
+		}
+		i++
+		if i >= len(tokens) {
+			return nil, fmt.Errorf("missing operand of %s", token)
+		}
+		operand := tokens[i]
+
+		switch op {
+		case vm.PUSHDATA1, vm.PUSHDATA2, vm.PUSHDATA4:
+			data, err := decodeData(operand)
+			if err != nil {
+				return nil, err
+			}
+			if err := writeLength(buf, op, len(data)); err != nil {
+				return nil, err
+			}
+			buf.Write(data)
+
+		case vm.JMP, vm.JMPIF, vm.JMPIFNOT, vm.CALL:
+			offset, err := strconv.ParseInt(operand, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid offset %q", operand)
+			}
+			var b [2]byte
+			binary.BigEndian.PutUint16(b[:], uint16(offset))
+			buf.Write(b[:])
+
+		case vm.APPCALL:
+			hash, err := decodeData(operand)
+			if err != nil {
+				return nil, err
+			}
+			if len(hash) != scriptHashLength {
+				return nil, fmt.Errorf("invalid script hash %q", operand)
+			}
+			buf.Write(hash)
+
+		case vm.SYSCALL:
+			if !isServiceName(operand) {
+				return nil, fmt.Errorf("invalid interop service name %q", operand)
+			}
+			writeVarBytes(buf, []byte(operand))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// pushData writes the data with the shortest push opcode.
+func pushData(buf *bytes.Buffer, data []byte) error {
+	var op vm.OpCode
+	switch {
+	case len(data) >= vm.PUSHBYTES1 && len(data) <= vm.PUSHBYTES75:
+		buf.WriteByte(byte(len(data)))
+		buf.Write(data)
+		return nil
+	case len(data) <= math.MaxUint8:
+		op = vm.PUSHDATA1
+	case len(data) <= math.MaxUint16:
+		op = vm.PUSHDATA2
+	default:
+		op = vm.PUSHDATA4
+	}
+	buf.WriteByte(byte(op))
+	if err := writeLength(buf, op, len(data)); err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// lengthSize returns the size of the data length of the PUSHDATA opcode.
+func lengthSize(op vm.OpCode) int {
+	switch op {
+	case vm.PUSHDATA1:
+		return 1
+	case vm.PUSHDATA2:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// decodeLength returns the data length of the PUSHDATA opcode, which is read
+// by the execution engine as a byte, a little-endian uint16 or a big-endian
+// int32.
+func decodeLength(op vm.OpCode, b []byte) int {
+	switch op {
+	case vm.PUSHDATA1:
+		return int(b[0])
+	case vm.PUSHDATA2:
+		return int(binary.LittleEndian.Uint16(b))
+	default:
+		return int(int32(binary.BigEndian.Uint32(b)))
+	}
+}
+
+// writeLength writes the data length of the PUSHDATA opcode.
+func writeLength(buf *bytes.Buffer, op vm.OpCode, length int) error {
+	switch op {
+	case vm.PUSHDATA1:
+		if length > math.MaxUint8 {
+			return errors.New("data is too long for PUSHDATA1")
+		}
+		buf.WriteByte(byte(length))
+	case vm.PUSHDATA2:
+		if length > math.MaxUint16 {
+			return errors.New("data is too long for PUSHDATA2")
+		}
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(length))
+		buf.Write(b[:])
+	default:
+		if length > math.MaxInt32 {
+			return errors.New("data is too long for PUSHDATA4")
+		}
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(length))
+		buf.Write(b[:])
+	}
+	return nil
+}
+
+// readVarBytes reads the variable length bytes of SYSCALL, whose length is
+// read by the execution engine as a byte, or a big-endian uint16 after 0xFD.
+func readVarBytes(read func(n int) ([]byte, error)) ([]byte, error) {
+	prefix, err := read(1)
+	if err != nil {
+		return nil, err
+	}
+	length := int(prefix[0])
+	switch prefix[0] {
+	case 0xFD:
+		b, err := read(2)
+		if err != nil {
+			return nil, err
+		}
+		length = int(int16(binary.BigEndian.Uint16(b)))
+	case 0xFE, 0xFF:
+		return nil, errors.New("interop service name is too long")
+	}
+	return read(length)
+}
+
+// writeVarBytes writes the variable length bytes of SYSCALL.
+func writeVarBytes(buf *bytes.Buffer, data []byte) {
+	if len(data) < 0xFD {
+		buf.WriteByte(byte(len(data)))
+	} else {
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(len(data)))
+		buf.WriteByte(0xFD)
+		buf.Write(b[:])
+	}
+	buf.Write(data)
+}
+
+// decodeData returns the bytes of the data token.
+func decodeData(token string) ([]byte, error) {
+	if !strings.HasPrefix(token, dataPrefix) {
+		return nil, fmt.Errorf("invalid data %q", token)
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(token, dataPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid data %q", token)
+	}
+	return data, nil
+}
+
+// isServiceName returns if the name is a valid interop service name token.
+func isServiceName(name string) bool {
+	if name == "" || len(name) > math.MaxInt16 {
+		return false
+	}
+	for _, c := range name {
+		if !(c == '.' || c == '_' || c >= '0' && c <= '9' ||
+			c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
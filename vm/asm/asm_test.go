@@ -0,0 +1,114 @@
+package asm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisassemble(t *testing.T) {
+	publicKey := "02" + strings.Repeat("ab", 32)
+	pk, _ := hex.DecodeString(publicKey)
+	long := bytes.Repeat([]byte{0xcd}, 80)
+
+	tests := []struct {
+		script []byte
+		text   string
+	}{
+		{nil, ""},
+		{append(append([]byte{33}, pk...), vm.CHECKSIG),
+			"0x" + publicKey + " CHECKSIG"},
+		{append(append([]byte{vm.PUSH1, 33}, pk...), vm.PUSH1, vm.CHECKMULTISIG),
+			"1 0x" + publicKey + " 1 CHECKMULTISIG"},
+		{[]byte{vm.PUSH0, vm.PUSHM1, vm.PUSH16, vm.DUP, vm.XSWAP},
+			"0 PUSHM1 16 DUP XSWAPP"},
+		{append([]byte{vm.PUSHDATA1, 80}, long...),
+			"PUSHDATA1 0x" + hex.EncodeToString(long)},
+		{[]byte{vm.PUSHDATA2, 2, 0, 1, 2}, "PUSHDATA2 0x0102"},
+		{[]byte{vm.PUSHDATA4, 0, 0, 0, 1, 3}, "PUSHDATA4 0x03"},
+		{[]byte{vm.PUSHDATA1, 0}, "PUSHDATA1 0x"},
+		{[]byte{vm.JMP, 0xff, 0xfd, vm.JMPIF, 0, 5}, "JMP -3 JMPIF 5"},
+		{append([]byte{vm.SYSCALL, 27}, "System.Blockchain.GetHeight"...),
+			"SYSCALL System.Blockchain.GetHeight"},
+		{append([]byte{vm.APPCALL}, bytes.Repeat([]byte{1}, 20)...),
+			"APPCALL 0x" + strings.Repeat("01", 20)},
+		{[]byte{0xff, vm.CHECKSCHNORRSIG}, "UNKNOWN_0xff CHECKSCHNORRSIG"},
+	}
+	for _, test := range tests {
+		text, err := Disassemble(test.script)
+		assert.NoError(t, err)
+		assert.Equal(t, test.text, text)
+
+		script, err := Assemble(test.text)
+		assert.NoError(t, err)
+		assert.Equal(t, test.script, script, test.text)
+	}
+
+	// Truncated scripts.
+	truncated := [][]byte{
+		{33, 1, 2},
+		{vm.PUSHDATA1},
+		{vm.PUSHDATA2, 2, 0, 1},
+		{vm.PUSHDATA4, 0xff, 0xff, 0xff, 0xff},
+		{vm.JMP, 0},
+		{vm.APPCALL, 1},
+		{vm.SYSCALL, 3, 'a'},
+	}
+	for _, script := range truncated {
+		_, err := Disassemble(script)
+		assert.Error(t, err, "%x", script)
+	}
+	text, err := Disassemble([]byte{vm.DUP, vm.PUSHBYTES1})
+	assert.Error(t, err)
+	assert.Equal(t, "DUP", text)
+
+	// Invalid interop service name.
+	_, err = Disassemble([]byte{vm.SYSCALL, 1, ' '})
+	assert.Error(t, err)
+}
+
+func TestAssemble(t *testing.T) {
+	// Aliases of opcodes.
+	script, err := Assemble("PUSH0 PUSHF PUSHT PUSH1 PUSH16 XSWAP\n\tCHECKSIG")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{vm.PUSH0, vm.PUSH0, vm.PUSH1, vm.PUSH1,
+		vm.PUSH16, vm.XSWAP, vm.CHECKSIG}, script)
+
+	// Shortest push opcodes.
+	script, err = Assemble("0x" + strings.Repeat("00", 75))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(vm.PUSHBYTES75), script[0])
+	script, err = Assemble("0x" + strings.Repeat("00", 76))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{vm.PUSHDATA1, 76}, script[:2])
+	script, err = Assemble("0x" + strings.Repeat("00", 256))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{vm.PUSHDATA2, 0, 1}, script[:3])
+	script, err = Assemble("0x")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{vm.PUSHDATA1, 0}, script)
+
+	invalid := []string{
+		"CHECK",
+		"0x0",
+		"0xzz",
+		"UNKNOWN_0x",
+		"UNKNOWN_0xffff",
+		"PUSHDATA1",
+		"PUSHDATA1 " + "0x" + strings.Repeat("00", 256),
+		"JMP 0x01",
+		"JMP 32768",
+		"APPCALL 0x01",
+		"SYSCALL a/b",
+		"PUSHBYTES1 0x01",
+	}
+	for _, text := range invalid {
+		_, err := Assemble(text)
+		assert.Error(t, err, text)
+	}
+}
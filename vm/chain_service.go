@@ -0,0 +1,87 @@
+package vm
+
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/types"
+)
+
+// ChainService provides the chain interop services backed by the chain.
+type ChainService struct {
+	chain interfaces.IChain
+}
+
+// NewChainService creates a GeneralService with the default interop services
+// and the chain interop services backed by the chain.
+func NewChainService(chain interfaces.IChain) *GeneralService {
+	is := NewGeneralService()
+	cs := &ChainService{chain: chain}
+	is.Register("System.Blockchain.GetHeight", cs.GetHeight)
+	is.Register("System.Blockchain.GetBlockTimestamp", cs.GetBlockTimestamp)
+	is.Register("System.Transaction.GetInputs", cs.GetInputs)
+	is.Register("System.Transaction.GetOutputs", cs.GetOutputs)
+	is.Register("System.Transaction.GetAttributes", cs.GetAttributes)
+	return is
+}
+
+// GetHeight pushes the height of the block the script is validated in, so
+// the result does not depend on when the script is executed.
+func (cs *ChainService) GetHeight(engine *ExecutionEngine) bool {
+	return pushData(engine, engine.height) == nil
+}
+
+// GetBlockTimestamp pops a block height and pushes the timestamp of the
+// block at the height. Only the blocks before the one the script is validated
+// in are available, the block at the validation height is not connected to
+// the chain yet while it is being validated.
+func (cs *ChainService) GetBlockTimestamp(engine *ExecutionEngine) bool {
+	if engine.evaluationStack.Count() < 1 {
+		return false
+	}
+	item := AssertStackItem(engine.evaluationStack.Pop())
+	if item == nil {
+		return false
+	}
+	height := item.GetBigInteger()
+	if height == nil || !height.IsUint64() ||
+		height.Uint64() >= uint64(engine.height) {
+		return false
+	}
+	timestamp, err := cs.chain.GetBlockTimestamp(uint32(height.Uint64()))
+	if err != nil {
+		return false
+	}
+	return pushData(engine, timestamp) == nil
+}
+
+// GetInputs pushes the serialized inputs of the data container as an array.
+func (cs *ChainService) GetInputs(engine *ExecutionEngine) bool {
+	return pushContainerItems(engine, cs.chain.GetTxInputs)
+}
+
+// GetOutputs pushes the serialized outputs of the data container as an
+// array.
+func (cs *ChainService) GetOutputs(engine *ExecutionEngine) bool {
+	return pushContainerItems(engine, cs.chain.GetTxOutputs)
+}
+
+// GetAttributes pushes the serialized attributes of the data container as an
+// array.
+func (cs *ChainService) GetAttributes(engine *ExecutionEngine) bool {
+	return pushContainerItems(engine, cs.chain.GetTxAttributes)
+}
+
+func pushContainerItems(engine *ExecutionEngine,
+	getItems func(interfaces.IDataContainer) ([][]byte, error)) bool {
+	if engine.dataContainer == nil {
+		return false
+	}
+	items, err := getItems(engine.dataContainer)
+	if err != nil {
+		return false
+	}
+	stackItems := make([]types.StackItem, 0, len(items))
+	for _, item := range items {
+		stackItems = append(stackItems, types.NewByteArray(item))
+	}
+	return pushData(engine, stackItems) == nil
+}
@@ -0,0 +1,93 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testContainer struct{}
+
+func (c *testContainer) GetData() []byte { return nil }
+
+type testChain struct {
+	timestamps []uint32
+	outputs    [][]byte
+}
+
+func (c *testChain) GetBlockTimestamp(height uint32) (uint32, error) {
+	return c.timestamps[height], nil
+}
+
+func (c *testChain) GetTxInputs(container interfaces.IDataContainer) ([][]byte, error) {
+	return nil, errors.New("no inputs")
+}
+
+func (c *testChain) GetTxOutputs(container interfaces.IDataContainer) ([][]byte, error) {
+	return c.outputs, nil
+}
+
+func (c *testChain) GetTxAttributes(container interfaces.IDataContainer) ([][]byte, error) {
+	return [][]byte{}, nil
+}
+
+func sysCall(method string) []byte {
+	return append([]byte{SYSCALL, byte(len(method))}, method...)
+}
+
+func executeScript(service *GeneralService, script []byte) *ExecutionEngine {
+	e := NewExecutionEngine(&testContainer{}, nil, MAXSTEPS, nil, service)
+	e.SetHeight(2)
+	e.LoadScript(script, false)
+	e.Execute()
+	return e
+}
+
+func TestChainService(t *testing.T) {
+	chain := &testChain{
+		timestamps: []uint32{100, 200, 300},
+		outputs:    [][]byte{{1}, {2, 3}},
+	}
+	service := NewChainService(chain)
+
+	// Chain services are not available by default, a failed SYSCALL pushes
+	// nothing.
+	e := executeScript(nil, sysCall("System.Blockchain.GetHeight"))
+	assert.Equal(t, 0, e.GetEvaluationStack().Count())
+
+	e = executeScript(service, sysCall("System.Blockchain.GetHeight"))
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, int64(2), AssertStackItem(e.GetEvaluationStack().Peek(0)).GetBigInteger().Int64())
+
+	e = executeScript(service, append([]byte{PUSH1}, sysCall("System.Blockchain.GetBlockTimestamp")...))
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, int64(200), AssertStackItem(e.GetEvaluationStack().Peek(0)).GetBigInteger().Int64())
+
+	// The block at the validation height and the ones after it are not
+	// available, even if the chain has them.
+	e = executeScript(service, append([]byte{PUSH2}, sysCall("System.Blockchain.GetBlockTimestamp")...))
+	assert.Equal(t, 0, e.GetEvaluationStack().Count())
+	e = executeScript(service, append([]byte{PUSH3}, sysCall("System.Blockchain.GetBlockTimestamp")...))
+	assert.Equal(t, 0, e.GetEvaluationStack().Count())
+
+	e = executeScript(service, sysCall("System.Transaction.GetOutputs"))
+	assert.Equal(t, HALT, e.GetState())
+	outputs := AssertStackItem(e.GetEvaluationStack().Peek(0)).GetArray()
+	assert.Equal(t, []types.StackItem{types.NewByteArray([]byte{1}),
+		types.NewByteArray([]byte{2, 3})}, outputs)
+
+	e = executeScript(service, sysCall("System.Transaction.GetInputs"))
+	assert.Equal(t, 0, e.GetEvaluationStack().Count())
+
+	// Custom services take effect.
+	service.Register("Test.Push", func(engine *ExecutionEngine) bool {
+		return pushData(engine, []byte("test")) == nil
+	})
+	e = executeScript(service, sysCall("Test.Push"))
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, []byte("test"), AssertStackItem(e.GetEvaluationStack().Peek(0)).GetByteArray())
+}
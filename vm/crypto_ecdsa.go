@@ -3,9 +3,14 @@ package vm
 import (
 	"errors"
 
+	"github.com/elastos/Elastos.ELA.SideChain/schnorr"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
+
 	"github.com/elastos/Elastos.ELA/crypto"
 )
 
+var _ interfaces.ISchnorrCrypto = (*CryptoECDsa)(nil)
+
 type CryptoECDsa struct {
 }
 
@@ -30,3 +35,15 @@ func (c *CryptoECDsa) VerifySignature(data []byte, signature []byte, pubkey []by
 	}
 	return nil
 }
+
+func (c *CryptoECDsa) VerifySchnorrSignature(data []byte, signature []byte, pubkey []byte) error {
+	return schnorr.Verify(pubkey, data, signature)
+}
+
+func (c *CryptoECDsa) BatchVerifySchnorrSignatures(data []byte, signatures [][]byte, pubkeys [][]byte) error {
+	messages := make([][]byte, len(signatures))
+	for i := range messages {
+		messages[i] = data
+	}
+	return schnorr.BatchVerify(pubkeys, messages, signatures)
+}
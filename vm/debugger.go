@@ -0,0 +1,170 @@
+package vm
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/types"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/utils"
+)
+
+// StepInfo is a snapshot of the execution engine after a step of a debug
+// session.
+type StepInfo struct {
+	// Step is the number of steps taken in the session.
+	Step int
+
+	// Executed tells if an instruction is executed by the step, a step
+	// entering or leaving a context, or stopping at a break point, does not
+	// execute any instruction.
+	Executed bool
+
+	// Position is the script position of the executed instruction.
+	Position int
+
+	// OpCode is the executed instruction.
+	OpCode OpCode
+
+	// OpName is the name of the executed instruction.
+	OpName string
+
+	// InstructionPointer is the script position of the next instruction
+	// of the running context, -1 if no context is running.
+	InstructionPointer int
+
+	// State is the state of the engine.
+	State VMState
+
+	// EvaluationStack and AltStack are the stack items from the top to the
+	// bottom, byte arrays are in hex and arrays in brackets.
+	EvaluationStack []string
+	AltStack        []string
+
+	// InvocationDepth is the number of contexts running or waiting to run.
+	InvocationDepth int
+}
+
+// DebugSession executes the scripts loaded into an execution engine step by
+// step, stopping at the break points of the contexts.
+type DebugSession struct {
+	engine *ExecutionEngine
+	steps  int
+}
+
+// NewDebugSession creates a debug session of the engine, the scripts should
+// be loaded into the engine before stepping.
+func NewDebugSession(engine *ExecutionEngine) *DebugSession {
+	return &DebugSession{engine: engine}
+}
+
+// Engine returns the execution engine of the session.
+func (s *DebugSession) Engine() *ExecutionEngine {
+	return s.engine
+}
+
+// Done returns if the execution is finished with HALT or FAULT state.
+func (s *DebugSession) Done() bool {
+	return s.engine.state&(HALT|FAULT) != 0
+}
+
+// AddBreakPoint adds a break point to the running context, or to the next
+// context to run.
+func (s *DebugSession) AddBreakPoint(position uint) {
+	s.engine.AddBreakPoint(position)
+}
+
+// RemoveBreakPoint removes a break point of the running context, or of the
+// next context to run.
+func (s *DebugSession) RemoveBreakPoint(position uint) bool {
+	return s.engine.RemoveBreakPoint(position)
+}
+
+// Step takes a step by StepInto and returns the snapshot after it.
+func (s *DebugSession) Step() *StepInfo {
+	e := s.engine
+	e.state = e.state & (^BREAK)
+
+	var script []byte
+	position := -1
+	if context := e.currentContext(); context != nil {
+		script = context.Script
+		position = context.OpReader.Position()
+	}
+	opCount := e.opCount
+
+	e.StepInto()
+	s.steps++
+
+	info := s.Snapshot()
+	if e.opCount > opCount && position >= 0 && position < len(script) {
+		info.Executed = true
+		info.Position = position
+		info.OpCode = OpCode(script[position])
+		info.OpName = OpName(info.OpCode)
+	}
+	return info
+}
+
+// Continue takes steps until the execution is finished or a break point is
+// reached, and returns the snapshots of all the steps.
+func (s *DebugSession) Continue() []*StepInfo {
+	var infos []*StepInfo
+	for !s.Done() {
+		info := s.Step()
+		infos = append(infos, info)
+		if info.State&BREAK == BREAK {
+			break
+		}
+	}
+	return infos
+}
+
+// Snapshot returns the current snapshot of the engine.
+func (s *DebugSession) Snapshot() *StepInfo {
+	e := s.engine
+	info := &StepInfo{
+		Step:               s.steps,
+		InstructionPointer: -1,
+		State:              e.state,
+		EvaluationStack:    stackStrings(e.evaluationStack),
+		AltStack:           stackStrings(e.altStack),
+		InvocationDepth:    e.invocationStack.Count(),
+	}
+	if e.running != nil {
+		info.InstructionPointer = e.running.OpReader.Position()
+		info.InvocationDepth++
+	}
+	return info
+}
+
+// OpName returns the name of the opcode.
+func OpName(opCode OpCode) string {
+	if opCode >= PUSHBYTES1 && opCode <= PUSHBYTES75 {
+		return "PUSHBYTES"
+	}
+	if name := OpExecList[opCode].Name; name != "" {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+func stackStrings(stack *utils.RandomAccessStack) []string {
+	items := make([]string, 0, stack.Count())
+	for i := 0; i < stack.Count(); i++ {
+		if item, ok := stack.Peek(i).(types.StackItem); ok {
+			items = append(items, stackItemString(item))
+		}
+	}
+	return items
+}
+
+func stackItemString(item types.StackItem) string {
+	if array, ok := item.(*types.Array); ok {
+		items := make([]string, 0, len(array.GetArray()))
+		for _, i := range array.GetArray() {
+			items = append(items, stackItemString(i))
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+	return hex.EncodeToString(item.GetByteArray())
+}
@@ -0,0 +1,136 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newDebugTestEngine() *ExecutionEngine {
+	e := NewExecutionEngine(nil, nil, MAXSTEPS, nil, nil)
+	// Code adds 2 to the number pushed by the parameter.
+	e.LoadScript([]byte{PUSH2, ADD}, false)
+	e.LoadScript([]byte{PUSH1}, true)
+	return e
+}
+
+func TestExecutionEngine_Execute(t *testing.T) {
+	e := newDebugTestEngine()
+	e.Execute()
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, 1, e.GetEvaluationStack().Count())
+	assert.Equal(t, int64(3), AssertStackItem(e.GetEvaluationStack().Peek(0)).GetBigInteger().Int64())
+
+	// Pushing only parameter.
+	e = NewExecutionEngine(nil, nil, MAXSTEPS, nil, nil)
+	e.LoadScript([]byte{PUSH2, ADD}, false)
+	e.LoadScript([]byte{PUSH1, PUSH1, ADD}, true)
+	e.Execute()
+	assert.Equal(t, FAULT, e.GetState())
+}
+
+func TestExecutionEngine_BreakPoint(t *testing.T) {
+	e := NewExecutionEngine(nil, nil, MAXSTEPS, nil, nil)
+	e.invocationStack.Push(NewExecutionContext([]byte{PUSH2, ADD}, false, []uint{1}))
+	e.LoadScript([]byte{PUSH1}, true)
+
+	// Break point at the start of the parameter context.
+	e.AddBreakPoint(0)
+	e.AddBreakPoint(0)
+	assert.False(t, e.RemoveBreakPoint(1))
+	e.Execute()
+	assert.Equal(t, BREAK, e.GetState())
+	assert.Equal(t, 0, e.GetEvaluationStack().Count())
+
+	// Break point before ADD of the code context.
+	e.Execute()
+	assert.Equal(t, BREAK, e.GetState())
+	assert.Equal(t, 2, e.GetEvaluationStack().Count())
+
+	assert.True(t, e.RemoveBreakPoint(1))
+	assert.False(t, e.RemoveBreakPoint(1))
+	e.Execute()
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, int64(3), AssertStackItem(e.GetEvaluationStack().Peek(0)).GetBigInteger().Int64())
+}
+
+func TestDebugSession(t *testing.T) {
+	e := newDebugTestEngine()
+	session := NewDebugSession(e)
+
+	expected := []struct {
+		executed bool
+		opCode   OpCode
+		position int
+		ip       int
+		stack    []string
+		depth    int
+	}{
+		{true, PUSH1, 0, 1, []string{"01"}, 2},
+		{false, 0, 0, -1, []string{"01"}, 1},
+		{true, PUSH2, 0, 1, []string{"02", "01"}, 1},
+		{true, ADD, 1, 2, []string{"03"}, 1},
+		{false, 0, 0, -1, []string{"03"}, 0},
+	}
+	for i, exp := range expected {
+		info := session.Step()
+		assert.Equal(t, i+1, info.Step)
+		assert.Equal(t, exp.executed, info.Executed, "step %d", i)
+		assert.Equal(t, exp.opCode, info.OpCode, "step %d", i)
+		assert.Equal(t, exp.position, info.Position, "step %d", i)
+		assert.Equal(t, exp.ip, info.InstructionPointer, "step %d", i)
+		assert.Equal(t, exp.stack, info.EvaluationStack, "step %d", i)
+		assert.Equal(t, exp.depth, info.InvocationDepth, "step %d", i)
+		assert.False(t, session.Done())
+	}
+	info := session.Step()
+	assert.True(t, session.Done())
+	assert.Equal(t, HALT, info.State)
+	assert.Equal(t, "ADD", OpName(ADD))
+
+	// Continue to a break point and then to the end.
+	session = NewDebugSession(newDebugTestEngine())
+	session.Step()
+	session.Step()
+	session.AddBreakPoint(1)
+	infos := session.Continue()
+	assert.Equal(t, 1, len(infos))
+	assert.Equal(t, BREAK, infos[0].State)
+	assert.Equal(t, 1, infos[0].InstructionPointer)
+	infos = session.Continue()
+	assert.Equal(t, HALT, infos[len(infos)-1].State)
+	assert.True(t, session.Done())
+}
+
+func TestExecutionEngine_Tracer(t *testing.T) {
+	e := newDebugTestEngine()
+	var traces []*OpTrace
+	e.SetTracer(func(trace *OpTrace) {
+		traces = append(traces, trace)
+	})
+	e.Execute()
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, 3, e.GetOpCount())
+	assert.Equal(t, []*OpTrace{
+		{Position: 0, OpCode: PUSH1, StackDepth: 1, Steps: 1, State: NONE},
+		{Position: 0, OpCode: PUSH2, StackDepth: 2, Steps: 2, State: NONE},
+		{Position: 1, OpCode: ADD, StackDepth: 1, Steps: 3, State: NONE},
+	}, traces)
+
+	// Only push opcodes are allowed in the parameter.
+	e = NewExecutionEngine(nil, nil, MAXSTEPS, nil, nil)
+	e.LoadScript([]byte{PUSH2, ADD}, false)
+	e.LoadScript([]byte{PUSH1, PUSH1, ADD}, true)
+	traces = nil
+	e.SetTracer(func(trace *OpTrace) {
+		traces = append(traces, trace)
+	})
+	e.Execute()
+	assert.Equal(t, FAULT, e.GetState())
+	last := traces[len(traces)-1]
+	assert.Equal(t, OpCode(ADD), last.OpCode)
+	assert.Equal(t, FAULT, last.State)
+	assert.Equal(t, errors.ErrPushOnly, last.Error)
+}
@@ -7,4 +7,14 @@ var (
 	ErrBadType  = errors.New("bad type")
 	ErrOverLen  = errors.New("the count over the size")
 	ErrFault    = errors.New("The exeution meet fault")
+
+	ErrPushOnly      = errors.New("only push opcodes are allowed in the script")
+	ErrMaxSteps      = errors.New("the number of executed opcodes over the limit")
+	ErrUnknownOpCode = errors.New("unknown opcode")
+	ErrLockTime      = errors.New("the lock time requirement is not satisfied")
+	ErrSequence      = errors.New("the sequence lock requirement is not satisfied")
+
+	ErrStackSize       = errors.New("the number of stack items over the limit")
+	ErrItemSize        = errors.New("the size of stack item over the limit")
+	ErrInvocationDepth = errors.New("the invocation depth over the limit")
 )
@@ -27,3 +27,13 @@ func (ec *ExecutionContext) NextInstruction() OpCode {
 func (ec *ExecutionContext) Clone() *ExecutionContext {
 	return NewExecutionContext(ec.Script, ec.PushOnly, ec.BreakPoints)
 }
+
+// hasBreakPoint returns if there is a break point at the script position.
+func (ec *ExecutionContext) hasBreakPoint(position int) bool {
+	for _, p := range ec.BreakPoints {
+		if int(p) == position {
+			return true
+		}
+	}
+	return false
+}
@@ -5,12 +5,33 @@ import (
 	_ "math/big"
 	_ "sort"
 
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
 	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
 	"github.com/elastos/Elastos.ELA.SideChain/vm/utils"
 )
 
 const MAXSTEPS int = 1200
 
+// ScriptFlags enables the opcodes activated at a height of the chain, the
+// opcodes are unknown to the engine if not enabled.
+type ScriptFlags uint32
+
+const (
+	// ScriptEnableTimeLock enables CHECKLOCKTIMEVERIFY and
+	// CHECKSEQUENCEVERIFY.
+	ScriptEnableTimeLock ScriptFlags = 1 << iota
+
+	// ScriptEnableHashLock enables RIPEMD160, and the hash locked contracts
+	// together with ScriptEnableTimeLock.
+	ScriptEnableHashLock
+
+	// ScriptEnableSchnorr enables CHECKSCHNORRSIG and CHECKMULTISCHNORRSIG.
+	ScriptEnableSchnorr
+
+	// ScriptEnableLimits enforces the limits of the engine, see Limits.
+	ScriptEnableLimits
+)
+
 func NewExecutionEngine(container interfaces.IDataContainer, crypto interfaces.ICrypto, maxSteps int, table interfaces.IScriptTable, service *GeneralService) *ExecutionEngine {
 	var engine ExecutionEngine
 
@@ -29,12 +50,12 @@ func NewExecutionEngine(container interfaces.IDataContainer, crypto interfaces.I
 	engine.opCode = 0
 
 	engine.maxSteps = maxSteps
+	engine.limits = DefaultLimits
 
-	if service != nil {
-		engine.service = service
+	if service == nil {
+		service = NewGeneralService()
 	}
-
-	engine.service = NewGeneralService()
+	engine.service = service
 
 	return &engine
 }
@@ -50,16 +71,43 @@ type ExecutionEngine struct {
 
 	maxSteps int
 
+	// limits bounds the memory used by the engine.
+	limits Limits
+
 	evaluationStack *utils.RandomAccessStack
 	altStack        *utils.RandomAccessStack
 	state           VMState
 
 	context *ExecutionContext
 
+	// running is the context popped from the invocation stack and being
+	// executed by StepInto.
+	running *ExecutionContext
+
+	// tracer is called after each executed opcode if set.
+	tracer Tracer
+
+	// flags enables the opcodes activated at a height of the chain.
+	flags ScriptFlags
+
+	// height is the height of the block the script is validated in.
+	height uint32
+
 	//current opcode
 	opCode OpCode
 }
 
+// SetFlags sets the opcodes enabled by the engine.
+func (e *ExecutionEngine) SetFlags(flags ScriptFlags) {
+	e.flags = flags
+}
+
+// SetHeight sets the height of the block the script is validated in, which
+// the chain interop services are bounded by.
+func (e *ExecutionEngine) SetHeight(height uint32) {
+	e.height = height
+}
+
 func (e *ExecutionEngine) GetState() VMState {
 	return e.state
 }
@@ -69,7 +117,11 @@ func (e *ExecutionEngine) GetEvaluationStack() *utils.RandomAccessStack {
 }
 
 func (e *ExecutionEngine) GetExecuteResult() bool {
-	return AssertStackItem(e.evaluationStack.Pop()).GetBoolean()
+	item := AssertStackItem(e.evaluationStack.Pop())
+	if item == nil {
+		return false
+	}
+	return item.GetBoolean()
 }
 
 func (e *ExecutionEngine) ExecutingScript() []byte {
@@ -113,41 +165,82 @@ func (e *ExecutionEngine) Execute() {
 	}
 }
 
+// StepInto executes the next instruction. The context on top of the
+// invocation stack is popped when it starts running, and the next context is
+// popped after the running one reaches its end. The BREAK state is set if the
+// next instruction of the running context is at one of its break points.
 func (e *ExecutionEngine) StepInto() {
-	if e.invocationStack.Count() == 0 {
-		e.state = VMState(e.state | HALT)
+	if e.running == nil {
+		if e.invocationStack.Count() == 0 {
+			e.state = VMState(e.state | HALT)
+		}
+		if e.state&HALT == HALT || e.state&FAULT == FAULT {
+			return
+		}
+		e.running = AssertExecutionContext(e.invocationStack.Pop())
+		if e.running.InstructionPointer >= len(e.running.Script) {
+			e.opCode = RET
+		}
+		if e.running.hasBreakPoint(e.running.OpReader.Position()) {
+			e.state = VMState(e.state | BREAK)
+			return
+		}
 	}
-	if e.state&HALT == HALT || e.state&FAULT == FAULT {
+
+	context := e.running
+	position := context.OpReader.Position()
+	opCode, err := context.OpReader.ReadByte()
+	if err == io.EOF && opCode == 0 {
+		e.running = nil
 		return
 	}
-	context := AssertExecutionContext(e.invocationStack.Pop())
-	if context.InstructionPointer >= len(context.Script) {
-		e.opCode = RET
+	e.opCount++
+	state, err := e.ExecuteOp(OpCode(opCode), context)
+	if e.tracer != nil {
+		e.trace(position, OpCode(opCode), state, err)
 	}
-	for {
-		opCode, err := context.OpReader.ReadByte()
-		if err == io.EOF && opCode == 0 {
-			return
-		}
-		e.opCount++
-		state, err := e.ExecuteOp(OpCode(opCode), context)
-		switch state {
-		case VMState(HALT):
-			e.state = VMState(e.state | HALT)
-			return
-		case VMState(FAULT):
-			e.state = VMState(e.state | FAULT)
-			return
+	switch state {
+	case VMState(HALT):
+		e.state = VMState(e.state | HALT)
+		e.running = nil
+		return
+	case VMState(FAULT):
+		e.state = VMState(e.state | FAULT)
+		e.running = nil
+		return
+	}
+
+	if context.hasBreakPoint(context.OpReader.Position()) {
+		e.state = VMState(e.state | BREAK)
+	}
+}
+
+// ExecuteOp executes the opcode and checks the limits of the engine. The
+// opcodes use the stack items without checking them, so an opcode panicking
+// on a malformed script faults the engine with errors.ErrFault.
+func (e *ExecutionEngine) ExecuteOp(opCode OpCode, context *ExecutionContext) (state VMState, err error) {
+	defer func() {
+		if recover() != nil {
+			state, err = FAULT, errors.ErrFault
 		}
+	}()
+
+	state, err = e.executeOp(opCode, context)
+	if err != nil {
+		return state, err
 	}
+	if err := e.checkLimits(); err != nil {
+		return FAULT, err
+	}
+	return NONE, nil
 }
 
-func (e *ExecutionEngine) ExecuteOp(opCode OpCode, context *ExecutionContext) (VMState, error) {
+func (e *ExecutionEngine) executeOp(opCode OpCode, context *ExecutionContext) (VMState, error) {
 	if opCode > PUSH16 && opCode != RET && context.PushOnly {
-		return FAULT, nil
+		return FAULT, errors.ErrPushOnly
 	}
 	if opCode > PUSH16 && e.opCount > e.maxSteps {
-		return FAULT, nil
+		return FAULT, errors.ErrMaxSteps
 	}
 	if opCode >= PUSHBYTES1 && opCode <= PUSHBYTES75 {
 		err := pushData(e, context.OpReader.ReadBytes(int(opCode)))
@@ -160,7 +253,7 @@ func (e *ExecutionEngine) ExecuteOp(opCode OpCode, context *ExecutionContext) (V
 	e.context = context
 	opExec := OpExecList[opCode]
 	if opExec.Exec == nil {
-		return FAULT, nil
+		return FAULT, errors.ErrUnknownOpCode
 	}
 	state, err := opExec.Exec(e)
 	if err != nil {
@@ -194,13 +287,45 @@ func (e *ExecutionEngine) StepOver() {
 	}
 }
 
+// AddBreakPoint adds a break point at the script position of the running
+// context, or of the context on top of the invocation stack if no context is
+// running yet.
 func (e *ExecutionEngine) AddBreakPoint(position uint) {
-	//b := e.context.BreakPoints
-	//b = append(b, position)
+	context := e.currentContext()
+	if context == nil {
+		return
+	}
+	if !context.hasBreakPoint(int(position)) {
+		context.BreakPoints = append(context.BreakPoints, position)
+	}
 }
 
+// RemoveBreakPoint removes the break point at the script position of the
+// running context, or of the context on top of the invocation stack if no
+// context is running yet. It returns false if there is no such break point.
 func (e *ExecutionEngine) RemoveBreakPoint(position uint) bool {
-	//if e.invocationStack.Count() == 0 { return false }
-	//b := e.context.BreakPoints
-	return true
+	context := e.currentContext()
+	if context == nil {
+		return false
+	}
+	for i, p := range context.BreakPoints {
+		if p == position {
+			context.BreakPoints = append(context.BreakPoints[:i:i],
+				context.BreakPoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// currentContext returns the running context, or the context on top of the
+// invocation stack if no context is running yet.
+func (e *ExecutionEngine) currentContext() *ExecutionContext {
+	if e.running != nil {
+		return e.running
+	}
+	if e.invocationStack.Count() == 0 {
+		return nil
+	}
+	return AssertExecutionContext(e.invocationStack.Peek(0))
 }
@@ -1,5 +1,11 @@
 package vm
 
+import (
+	"math/big"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+)
+
 func opBigInt(e *ExecutionEngine) (VMState, error) {
 	if e.evaluationStack.Count() < 1 {
 		return FAULT, nil
@@ -42,6 +48,18 @@ func opBigIntZip(e *ExecutionEngine) (VMState, error) {
 	}
 	x2 := AssertStackItem(e.evaluationStack.Pop()).GetBigInteger()
 	x1 := AssertStackItem(e.evaluationStack.Pop()).GetBigInteger()
+	// Check the size of the result before it is computed.
+	switch e.opCode {
+	case MUL:
+		if !e.checkItemSize(len(x1.Bytes()) + len(x2.Bytes())) {
+			return FAULT, errors.ErrItemSize
+		}
+	case SHL:
+		bits := new(big.Int).Add(x2, big.NewInt(int64(x1.BitLen())))
+		if x2.Sign() < 0 || !e.checkBigIntSize(bits) {
+			return FAULT, errors.ErrItemSize
+		}
+	}
 	err := pushData(e, BigIntZip(x1, x2, e.opCode))
 	if err != nil {
 		return FAULT, err
@@ -5,6 +5,10 @@ import (
 	"crypto/sha256"
 	"errors"
 	"hash"
+
+	vmerrors "github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+
+	"golang.org/x/crypto/ripemd160"
 )
 
 func opHash(e *ExecutionEngine) (VMState, error) {
@@ -19,6 +23,15 @@ func opHash(e *ExecutionEngine) (VMState, error) {
 	return NONE, nil
 }
 
+// opRipemd160 is opHash with RIPEMD-160, which is enabled by
+// ScriptEnableHashLock.
+func opRipemd160(e *ExecutionEngine) (VMState, error) {
+	if e.flags&ScriptEnableHashLock == 0 {
+		return FAULT, vmerrors.ErrUnknownOpCode
+	}
+	return opHash(e)
+}
+
 func opCheckSig(e *ExecutionEngine) (VMState, error) {
 	if e.dataContainer == nil {
 		return FAULT, nil
@@ -107,6 +120,10 @@ func Hash(b []byte, e *ExecutionEngine) []byte {
 	var sh hash.Hash
 	var bt []byte
 	switch e.opCode {
+	case RIPEMD160:
+		sh = ripemd160.New()
+		sh.Write(b)
+		bt = sh.Sum(nil)
 	case SHA1:
 		sh = sha1.New()
 		sh.Write(b)
@@ -72,7 +72,13 @@ func opSysCall(e *ExecutionEngine) (VMState, error) {
 	if e.service == nil {
 		return FAULT, nil
 	}
-	success := e.service.Invoke(e.context.OpReader.ReadVarString(), e)
+	// Same as ReadVarString, but the length is checked before reading since
+	// the reader pads the bytes after the end of script with zeros.
+	length := int(e.context.OpReader.ReadVarInt(0x7fffffc7))
+	if !e.checkItemSize(length) {
+		return FAULT, errors.ErrItemSize
+	}
+	success := e.service.Invoke(string(e.context.OpReader.ReadBytes(length)), e)
 	if success {
 		return NONE, nil
 	} else {
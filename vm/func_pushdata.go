@@ -1,5 +1,9 @@
 package vm
 
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+)
+
 func opPushData(e *ExecutionEngine) (VMState, error) {
 	data, err := getPushData(e)
 	if err != nil {
@@ -27,7 +31,13 @@ func getPushData(e *ExecutionEngine) (interface{}, error) {
 	case PUSHDATA2:
 		data = e.context.OpReader.ReadBytes(int(e.context.OpReader.ReadUint16()))
 	case PUSHDATA4:
-		data = e.context.OpReader.ReadBytes(int(e.context.OpReader.ReadInt32()))
+		// The reader pads the bytes after the end of script with zeros, so
+		// the length is checked before reading.
+		length := int(e.context.OpReader.ReadInt32())
+		if !e.checkItemSize(length) {
+			return nil, errors.ErrItemSize
+		}
+		data = e.context.OpReader.ReadBytes(length)
 	case PUSHM1, PUSH1, PUSH2, PUSH3, PUSH4, PUSH5, PUSH6, PUSH7, PUSH8, PUSH9, PUSH10, PUSH11, PUSH12, PUSH13, PUSH14, PUSH15, PUSH16:
 		data = int8(e.opCode - PUSH1 + 1)
 	}
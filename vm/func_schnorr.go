@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"errors"
+
+	vmerrors "github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
+)
+
+// schnorrCrypto returns the crypto verifying Schnorr signatures, the opcodes
+// are unknown to the engine if ScriptEnableSchnorr is not set.
+func schnorrCrypto(e *ExecutionEngine) (interfaces.ISchnorrCrypto, error) {
+	if e.flags&ScriptEnableSchnorr == 0 {
+		return nil, vmerrors.ErrUnknownOpCode
+	}
+	crypto, ok := e.crypto.(interfaces.ISchnorrCrypto)
+	if !ok {
+		return nil, errors.New("crypto does not support schnorr signatures")
+	}
+	if e.dataContainer == nil {
+		return nil, errors.New("data container is nil")
+	}
+	return crypto, nil
+}
+
+// opCheckSchnorrSig is CHECKSIG with a Schnorr signature.
+func opCheckSchnorrSig(e *ExecutionEngine) (VMState, error) {
+	crypto, err := schnorrCrypto(e)
+	if err != nil {
+		return FAULT, err
+	}
+	if e.evaluationStack.Count() < 2 {
+		return FAULT, errors.New("element count is not enough")
+	}
+	pubkey := AssertStackItem(e.evaluationStack.Pop()).GetByteArray()
+	signature := AssertStackItem(e.evaluationStack.Pop()).GetByteArray()
+	err = crypto.VerifySchnorrSignature(e.dataContainer.GetData(), signature, pubkey)
+	if err := pushData(e, err == nil); err != nil {
+		return FAULT, err
+	}
+	return NONE, nil
+}
+
+// opCheckMultiSchnorrSig pops n, n public keys, m and then n signatures,
+// each public key is paired with the signature pushed at the same position
+// and an empty item or the item of PUSH0 means the key does not sign. True
+// is pushed if at least m signatures are given and all of them are valid,
+// which are verified as a batch.
+func opCheckMultiSchnorrSig(e *ExecutionEngine) (VMState, error) {
+	crypto, err := schnorrCrypto(e)
+	if err != nil {
+		return FAULT, err
+	}
+	if e.evaluationStack.Count() < 4 {
+		return FAULT, errors.New("element count is not enough")
+	}
+	// n is bounded by the stack before the arithmetic on it, which can not
+	// overflow then.
+	count := AssertStackItem(e.evaluationStack.Pop()).GetBigInteger()
+	if !count.IsInt64() || count.Int64() < 1 ||
+		count.Int64() > int64(e.evaluationStack.Count()) {
+		return FAULT, errors.New("invalid n in multisig")
+	}
+	n := int(count.Int64())
+	if e.evaluationStack.Count() < 2*n+1 {
+		return FAULT, errors.New("invalid element count")
+	}
+	e.opCount += n
+	if e.opCount > e.maxSteps {
+		return FAULT, vmerrors.ErrMaxSteps
+	}
+
+	pubkeys := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		pubkeys[i] = AssertStackItem(e.evaluationStack.Pop()).GetByteArray()
+	}
+	m := int(AssertStackItem(e.evaluationStack.Pop()).GetBigInteger().Int64())
+	if m < 1 || m > n {
+		return FAULT, errors.New("invalid m in multisig")
+	}
+	if e.evaluationStack.Count() != n {
+		return FAULT, errors.New("number of signatures is different with n")
+	}
+
+	signatures := make([][]byte, 0, n)
+	signers := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		signature := AssertStackItem(e.evaluationStack.Pop()).GetByteArray()
+		if isEmptySignature(signature) {
+			continue
+		}
+		signatures = append(signatures, signature)
+		signers = append(signers, pubkeys[i])
+	}
+
+	success := len(signatures) >= m && crypto.BatchVerifySchnorrSignatures(
+		e.dataContainer.GetData(), signatures, signers) == nil
+	if err := pushData(e, success); err != nil {
+		return FAULT, err
+	}
+	return NONE, nil
+}
+
+// isEmptySignature returns if the item is pushed in place of the signature
+// of a key not signing, which is empty or the item of PUSH0.
+func isEmptySignature(signature []byte) bool {
+	return len(signature) == 0 || len(signature) == 1 && signature[0] == 0
+}
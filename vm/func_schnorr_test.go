@@ -0,0 +1,182 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/schnorr"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
+
+	"github.com/elastos/Elastos.ELA/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+type testDataContainer []byte
+
+func (c testDataContainer) GetData() []byte { return c }
+
+type schnorrSigner struct {
+	privateKey []byte
+	publicKey  []byte
+}
+
+func newSchnorrSigners(t *testing.T, n int) []*schnorrSigner {
+	signers := make([]*schnorrSigner, 0, n)
+	for i := 0; i < n; i++ {
+		privateKey, publicKey, err := crypto.GenerateKeyPair()
+		assert.NoError(t, err)
+		pk, err := publicKey.EncodePoint(true)
+		assert.NoError(t, err)
+		signers = append(signers, &schnorrSigner{privateKey, pk})
+	}
+	return signers
+}
+
+func (s *schnorrSigner) sign(t *testing.T, data []byte) []byte {
+	signature, err := schnorr.Sign(s.privateKey, data)
+	assert.NoError(t, err)
+	return signature
+}
+
+func pushBytes(buf *bytes.Buffer, data []byte) {
+	buf.WriteByte(byte(len(data)))
+	buf.Write(data)
+}
+
+func executeSchnorr(crypto interfaces.ICrypto, flags ScriptFlags,
+	code, parameter []byte) (*ExecutionEngine, error) {
+	e := NewExecutionEngine(testDataContainer("transaction"), crypto,
+		MAXSTEPS, nil, nil)
+	e.SetFlags(flags)
+	var err error
+	e.SetTracer(func(trace *OpTrace) {
+		if trace.Error != nil {
+			err = trace.Error
+		}
+	})
+	e.LoadScript(code, false)
+	e.LoadScript(parameter, true)
+	e.Execute()
+	return e, err
+}
+
+func assertResult(t *testing.T, e *ExecutionEngine, result bool) {
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, 1, e.GetEvaluationStack().Count())
+	assert.Equal(t, result, e.GetExecuteResult())
+}
+
+func TestCheckSchnorrSig(t *testing.T) {
+	signers := newSchnorrSigners(t, 2)
+	data := []byte("transaction")
+
+	code := new(bytes.Buffer)
+	pushBytes(code, signers[0].publicKey)
+	code.WriteByte(CHECKSCHNORRSIG)
+	parameter := new(bytes.Buffer)
+	pushBytes(parameter, signers[0].sign(t, data))
+
+	e, err := executeSchnorr(new(CryptoECDsa), ScriptEnableSchnorr,
+		code.Bytes(), parameter.Bytes())
+	assert.NoError(t, err)
+	assertResult(t, e, true)
+
+	// Signature of another key.
+	parameter.Reset()
+	pushBytes(parameter, signers[1].sign(t, data))
+	e, err = executeSchnorr(new(CryptoECDsa), ScriptEnableSchnorr,
+		code.Bytes(), parameter.Bytes())
+	assert.NoError(t, err)
+	assertResult(t, e, false)
+
+	// Not enabled.
+	e, err = executeSchnorr(new(CryptoECDsa), ScriptEnableTimeLock,
+		code.Bytes(), parameter.Bytes())
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrUnknownOpCode, err)
+
+	// Crypto without Schnorr signatures.
+	e, err = executeSchnorr(nil, ScriptEnableSchnorr, code.Bytes(),
+		parameter.Bytes())
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Error(t, err)
+}
+
+func TestCheckMultiSchnorrSig(t *testing.T) {
+	signers := newSchnorrSigners(t, 3)
+	data := []byte("transaction")
+
+	// 2 of 3 multisig.
+	code := new(bytes.Buffer)
+	code.WriteByte(PUSH2)
+	for _, s := range signers {
+		pushBytes(code, s.publicKey)
+	}
+	code.Write([]byte{PUSH3, CHECKMULTISCHNORRSIG})
+
+	parameter := func(signatures ...[]byte) []byte {
+		buf := new(bytes.Buffer)
+		for _, signature := range signatures {
+			if len(signature) == 0 {
+				buf.WriteByte(PUSH0)
+				continue
+			}
+			pushBytes(buf, signature)
+		}
+		return buf.Bytes()
+	}
+	sig0 := signers[0].sign(t, data)
+	sig1 := signers[1].sign(t, data)
+	sig2 := signers[2].sign(t, data)
+
+	tests := []struct {
+		name      string
+		parameter []byte
+		result    bool
+		fault     bool
+	}{
+		{"all signed", parameter(sig0, sig1, sig2), true, false},
+		{"first and last signed", parameter(sig0, nil, sig2), true, false},
+		{"last two signed", parameter(nil, sig1, sig2), true, false},
+		{"one signed", parameter(nil, nil, sig2), false, false},
+		{"none signed", parameter(nil, nil, nil), false, false},
+		{"wrong order", parameter(sig1, sig0, nil), false, false},
+		{"invalid signature", parameter(sig0, sig1[1:], nil), false, false},
+		{"too few items", parameter(sig0, sig1), false, true},
+		{"too many items", parameter(sig0, sig1, sig2, sig2), false, true},
+	}
+	for _, test := range tests {
+		e, err := executeSchnorr(new(CryptoECDsa), ScriptEnableSchnorr,
+			code.Bytes(), test.parameter)
+		if test.fault {
+			assert.Equal(t, FAULT, e.GetState(), test.name)
+			assert.Error(t, err, test.name)
+			continue
+		}
+		assert.NoError(t, err, test.name)
+		assertResult(t, e, test.result)
+	}
+
+	// Not enabled.
+	e, err := executeSchnorr(new(CryptoECDsa), 0, code.Bytes(),
+		parameter(sig0, sig1, sig2))
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrUnknownOpCode, err)
+
+	// An n of 2^63-1 overflows 2*n+1 and the op count unless it is bounded by
+	// the stack first.
+	huge := new(bytes.Buffer)
+	huge.WriteByte(PUSH2)
+	for _, s := range signers {
+		pushBytes(huge, s.publicKey)
+	}
+	pushBytes(huge, []byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	huge.WriteByte(CHECKMULTISCHNORRSIG)
+	e, err = executeSchnorr(new(CryptoECDsa), ScriptEnableSchnorr,
+		huge.Bytes(), parameter(sig0, sig1, sig2))
+	assert.Equal(t, FAULT, e.GetState())
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid n in multisig", err.Error())
+	}
+}
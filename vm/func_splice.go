@@ -1,5 +1,9 @@
 package vm
 
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+)
+
 func opCat(e *ExecutionEngine) (VMState, error) {
 	if e.evaluationStack.Count() < 2 {
 		return FAULT, nil
@@ -11,6 +15,9 @@ func opCat(e *ExecutionEngine) (VMState, error) {
 	if len(b1) != len(b2) {
 		return FAULT, nil
 	}
+	if !e.checkItemSize(len(b1) + len(b2)) {
+		return FAULT, errors.ErrItemSize
+	}
 	r := ByteArrZip(b1, b2, CAT)
 	pushData(e, r)
 	return NONE, nil
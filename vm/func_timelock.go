@@ -0,0 +1,94 @@
+package vm
+
+import (
+	"math"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/interfaces"
+)
+
+const (
+	// sequenceLockDisableFlag, sequenceRelativeLockFlag and sequenceLockMask
+	// are the relative lock fields of the input sequence, see
+	// types.RelativeLock.
+	sequenceLockDisableFlag  uint32 = 1 << 31
+	sequenceRelativeLockFlag uint32 = 1 << 30
+	sequenceLockMask         uint32 = 0x0000ffff
+
+	// sequenceFinal is the input sequence of a transaction whose lock time
+	// is not enforced if all inputs have it, see
+	// blockchain.CheckTransactionFinalize.
+	sequenceFinal uint32 = math.MaxUint16
+)
+
+// opCheckLockTimeVerify fails if the lock time of the transaction is less
+// than the top stack item, which is left on the stack. Since a block can
+// only contain transactions with lock time less than its height, the script
+// can not be spent before the height given by the item.
+func opCheckLockTimeVerify(e *ExecutionEngine) (VMState, error) {
+	if e.flags&ScriptEnableTimeLock == 0 {
+		return FAULT, errors.ErrUnknownOpCode
+	}
+	container, lockTime, err := timeLockOperand(e, math.MaxUint32)
+	if err != nil {
+		return FAULT, err
+	}
+	if container.GetLockTime() < lockTime {
+		return FAULT, errors.ErrLockTime
+	}
+
+	// The lock time is not enforced if all inputs are final.
+	for _, sequence := range container.GetInputSequences() {
+		if sequence != sequenceFinal {
+			return NONE, nil
+		}
+	}
+	return FAULT, errors.ErrLockTime
+}
+
+// opCheckSequenceVerify fails if the relative lock of any input of the
+// transaction is disabled or less than the top stack item, which is left on
+// the stack.
+func opCheckSequenceVerify(e *ExecutionEngine) (VMState, error) {
+	if e.flags&ScriptEnableTimeLock == 0 {
+		return FAULT, errors.ErrUnknownOpCode
+	}
+	container, blocks, err := timeLockOperand(e, sequenceLockMask)
+	if err != nil {
+		return FAULT, err
+	}
+
+	sequences := container.GetInputSequences()
+	if len(sequences) == 0 {
+		return FAULT, errors.ErrSequence
+	}
+	for _, sequence := range sequences {
+		if sequence&sequenceLockDisableFlag != 0 ||
+			sequence&sequenceRelativeLockFlag == 0 ||
+			sequence&sequenceLockMask < blocks {
+			return FAULT, errors.ErrSequence
+		}
+	}
+	return NONE, nil
+}
+
+// timeLockOperand returns the time lock container of the engine and the top
+// stack item, which must be an integer between zero and max.
+func timeLockOperand(e *ExecutionEngine, max uint32) (interfaces.ITimeLockContainer, uint32, error) {
+	container, ok := e.dataContainer.(interfaces.ITimeLockContainer)
+	if !ok {
+		return nil, 0, errors.ErrBadType
+	}
+	if e.evaluationStack.Count() < 1 {
+		return nil, 0, errors.ErrOverLen
+	}
+	item := AssertStackItem(e.evaluationStack.Peek(0))
+	if item == nil {
+		return nil, 0, errors.ErrBadType
+	}
+	value := item.GetBigInteger()
+	if value == nil || !value.IsUint64() || value.Uint64() > uint64(max) {
+		return nil, 0, errors.ErrBadValue
+	}
+	return container, uint32(value.Uint64()), nil
+}
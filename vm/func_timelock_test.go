@@ -0,0 +1,120 @@
+package vm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTimeLockContainer struct {
+	lockTime  uint32
+	sequences []uint32
+}
+
+func (c *testTimeLockContainer) GetData() []byte { return nil }
+
+func (c *testTimeLockContainer) GetLockTime() uint32 { return c.lockTime }
+
+func (c *testTimeLockContainer) GetInputSequences() []uint32 { return c.sequences }
+
+func executeTimeLock(container *testTimeLockContainer, flags ScriptFlags,
+	script []byte) (*ExecutionEngine, error) {
+	e := NewExecutionEngine(container, nil, MAXSTEPS, nil, nil)
+	e.SetFlags(flags)
+	var err error
+	e.SetTracer(func(trace *OpTrace) {
+		if trace.Error != nil {
+			err = trace.Error
+		}
+	})
+	e.LoadScript(script, false)
+	e.Execute()
+	return e, err
+}
+
+func TestCheckLockTimeVerify(t *testing.T) {
+	// PUSH 100, CHECKLOCKTIMEVERIFY
+	script := []byte{PUSHBYTES1, 100, CHECKLOCKTIMEVERIFY}
+	container := &testTimeLockContainer{lockTime: 100, sequences: []uint32{0}}
+
+	// Unknown opcode before activation.
+	e, err := executeTimeLock(container, 0, script)
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrUnknownOpCode, err)
+
+	e, err = executeTimeLock(container, ScriptEnableTimeLock, script)
+	assert.NoError(t, err)
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, 1, e.GetEvaluationStack().Count())
+
+	// Lock time less than the top item.
+	container.lockTime = 99
+	e, err = executeTimeLock(container, ScriptEnableTimeLock, script)
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrLockTime, err)
+
+	// Lock time not enforced when all inputs are final.
+	container.lockTime = 100
+	container.sequences = []uint32{math.MaxUint16, math.MaxUint16}
+	e, err = executeTimeLock(container, ScriptEnableTimeLock, script)
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrLockTime, err)
+
+	// Negative lock time.
+	e, err = executeTimeLock(container, ScriptEnableTimeLock,
+		[]byte{PUSHM1, CHECKLOCKTIMEVERIFY})
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrBadValue, err)
+
+	// Empty stack.
+	e, err = executeTimeLock(container, ScriptEnableTimeLock,
+		[]byte{CHECKLOCKTIMEVERIFY})
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrOverLen, err)
+}
+
+func TestCheckSequenceVerify(t *testing.T) {
+	// PUSH 10, CHECKSEQUENCEVERIFY
+	script := []byte{PUSHBYTES1, 10, CHECKSEQUENCEVERIFY}
+	relativeLock := func(blocks uint32) uint32 {
+		return sequenceRelativeLockFlag | blocks
+	}
+	container := &testTimeLockContainer{
+		sequences: []uint32{relativeLock(10), relativeLock(20)},
+	}
+
+	e, err := executeTimeLock(container, 0, script)
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrUnknownOpCode, err)
+
+	e, err = executeTimeLock(container, ScriptEnableTimeLock, script)
+	assert.NoError(t, err)
+	assert.Equal(t, HALT, e.GetState())
+
+	// Relative lock of an input less than the top item.
+	container.sequences = []uint32{relativeLock(10), relativeLock(9)}
+	e, err = executeTimeLock(container, ScriptEnableTimeLock, script)
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrSequence, err)
+
+	// Relative lock disabled.
+	container.sequences = []uint32{relativeLock(10) | sequenceLockDisableFlag}
+	e, err = executeTimeLock(container, ScriptEnableTimeLock, script)
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrSequence, err)
+
+	container.sequences = []uint32{math.MaxUint32 - 1}
+	e, err = executeTimeLock(container, ScriptEnableTimeLock, script)
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrSequence, err)
+
+	// Number of blocks over the relative lock mask.
+	container.sequences = []uint32{relativeLock(sequenceLockMask)}
+	e, err = executeTimeLock(container, ScriptEnableTimeLock,
+		[]byte{0x03, 0x01, 0x00, 0x00, CHECKSEQUENCEVERIFY})
+	assert.Equal(t, FAULT, e.GetState())
+	assert.Equal(t, errors.ErrBadValue, err)
+}
@@ -0,0 +1,15 @@
+//go:build go1.18
+// +build go1.18
+
+package vm
+
+import "testing"
+
+func FuzzExecutionEngine(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed[0], seed[1])
+	}
+	f.Fuzz(func(t *testing.T, code, parameter []byte) {
+		executeFuzz(t, code, parameter)
+	})
+}
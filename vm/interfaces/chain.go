@@ -0,0 +1,14 @@
+package interfaces
+
+// IChain provides the chain state to the chain interop services.
+type IChain interface {
+	// GetBlockTimestamp returns the timestamp of the best chain block at
+	// the height.
+	GetBlockTimestamp(height uint32) (uint32, error)
+
+	// GetTxInputs, GetTxOutputs and GetTxAttributes return the serialized
+	// inputs, outputs and attributes of the transaction data container.
+	GetTxInputs(container IDataContainer) ([][]byte, error)
+	GetTxOutputs(container IDataContainer) ([][]byte, error)
+	GetTxAttributes(container IDataContainer) ([][]byte, error)
+}
@@ -7,3 +7,15 @@ type ICrypto interface {
 
 	VerifySignature(data []byte, signature []byte, pubkey []byte) error
 }
+
+// ISchnorrCrypto is implemented by the crypto of the execution engine to
+// verify the Schnorr signatures of CHECKSCHNORRSIG and CHECKMULTISCHNORRSIG.
+type ISchnorrCrypto interface {
+	ICrypto
+
+	VerifySchnorrSignature(data []byte, signature []byte, pubkey []byte) error
+
+	// BatchVerifySchnorrSignatures verifies the signatures of the data by the
+	// public keys of the same index at once.
+	BatchVerifySchnorrSignatures(data []byte, signatures [][]byte, pubkeys [][]byte) error
+}
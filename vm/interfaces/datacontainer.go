@@ -3,3 +3,15 @@ package interfaces
 type IDataContainer interface {
 	GetData() []byte
 }
+
+// ITimeLockContainer is a data container providing the time lock fields of a
+// transaction to the time lock opcodes.
+type ITimeLockContainer interface {
+	IDataContainer
+
+	// GetLockTime returns the lock time of the transaction.
+	GetLockTime() uint32
+
+	// GetInputSequences returns the sequences of the transaction inputs.
+	GetInputSequences() []uint32
+}
@@ -0,0 +1,105 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+	"github.com/elastos/Elastos.ELA.SideChain/vm/types"
+)
+
+// Limits bounds the memory used by the execution engine, a limit not greater
+// than zero means no limit. The limits are only enforced if the engine is
+// flagged with ScriptEnableLimits, since they reject the scripts exceeding
+// them which are valid otherwise.
+type Limits struct {
+	// MaxStackSize is the max number of items in the evaluation stack and
+	// the alt stack together.
+	MaxStackSize int
+
+	// MaxItemSize is the max byte size of a stack item, the size of an
+	// array is the total size of its elements.
+	MaxItemSize int
+
+	// MaxInvocationDepth is the max number of contexts in the invocation
+	// stack.
+	MaxInvocationDepth int
+}
+
+// DefaultLimits is the limits of the execution engines created by
+// NewExecutionEngine, which are far beyond the scripts of the standard
+// contracts.
+var DefaultLimits = Limits{
+	MaxStackSize:       2048,
+	MaxItemSize:        1024 * 1024,
+	MaxInvocationDepth: 1024,
+}
+
+// SetLimits sets the limits enforced by the engine if it is flagged with
+// ScriptEnableLimits.
+func (e *ExecutionEngine) SetLimits(limits Limits) {
+	e.limits = limits
+}
+
+// isLimited returns if the engine enforces the limits.
+func (e *ExecutionEngine) isLimited() bool {
+	return e.flags&ScriptEnableLimits != 0
+}
+
+// checkLimits checks the stacks after an opcode is executed. The size of the
+// top item only is checked, since the other items are checked when they are
+// pushed.
+func (e *ExecutionEngine) checkLimits() error {
+	if !e.isLimited() {
+		return nil
+	}
+	if e.limits.MaxInvocationDepth > 0 &&
+		e.invocationStack.Count() > e.limits.MaxInvocationDepth {
+		return errors.ErrInvocationDepth
+	}
+	if e.limits.MaxStackSize > 0 && e.evaluationStack.Count()+
+		e.altStack.Count() > e.limits.MaxStackSize {
+		return errors.ErrStackSize
+	}
+	if e.evaluationStack.Count() > 0 {
+		item := AssertStackItem(e.evaluationStack.Peek(0))
+		if !e.checkItemSize(itemSize(item)) {
+			return errors.ErrItemSize
+		}
+	}
+	return nil
+}
+
+// checkItemSize returns if the size is allowed for a stack item.
+func (e *ExecutionEngine) checkItemSize(size int) bool {
+	return !e.isLimited() || e.limits.MaxItemSize <= 0 ||
+		size <= e.limits.MaxItemSize
+}
+
+// checkBigIntSize returns if an integer of the bits is allowed for a stack
+// item.
+func (e *ExecutionEngine) checkBigIntSize(bits *big.Int) bool {
+	if !e.isLimited() || e.limits.MaxItemSize <= 0 {
+		return true
+	}
+	return bits.Sign() >= 0 &&
+		bits.Cmp(big.NewInt(int64(e.limits.MaxItemSize)*8)) <= 0
+}
+
+// itemSize returns the byte size of the stack item.
+func itemSize(item types.StackItem) int {
+	switch i := item.(type) {
+	case *types.ByteArray:
+		return len(i.GetByteArray())
+	case *types.Integer:
+		return (i.GetBigInteger().BitLen() + 7) / 8
+	case *types.Boolean:
+		return 1
+	case *types.Array:
+		var size int
+		for _, element := range i.GetArray() {
+			size += itemSize(element)
+		}
+		return size
+	}
+	return 0
+}
@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fuzzLimits are small limits reached by short scripts.
+var fuzzLimits = Limits{
+	MaxStackSize:       64,
+	MaxItemSize:        256,
+	MaxInvocationDepth: 8,
+}
+
+// executeFuzz executes the script and checks the limits are never exceeded.
+func executeFuzz(t *testing.T, code, parameter []byte) *ExecutionEngine {
+	e := NewExecutionEngine(testDataContainer("transaction"), new(CryptoECDsa),
+		MAXSTEPS, nil, nil)
+	e.SetFlags(ScriptEnableTimeLock | ScriptEnableHashLock | ScriptEnableSchnorr |
+		ScriptEnableLimits)
+	e.SetLimits(fuzzLimits)
+	e.SetTracer(func(trace *OpTrace) {
+		if trace.State == FAULT {
+			return
+		}
+		if e.invocationStack.Count() > fuzzLimits.MaxInvocationDepth {
+			t.Fatalf("invocation depth %d over the limit", e.invocationStack.Count())
+		}
+		if trace.StackDepth+e.altStack.Count() > fuzzLimits.MaxStackSize {
+			t.Fatalf("stack size %d over the limit", trace.StackDepth+e.altStack.Count())
+		}
+		for i := 0; i < e.evaluationStack.Count(); i++ {
+			size := itemSize(AssertStackItem(e.evaluationStack.Peek(i)))
+			if size > fuzzLimits.MaxItemSize {
+				t.Fatalf("item size %d over the limit", size)
+			}
+		}
+	})
+	e.LoadScript(code, false)
+	e.LoadScript(parameter, true)
+	e.Execute()
+	if e.GetState() != HALT && e.GetState() != FAULT {
+		t.Fatalf("unexpected state %s", e.GetState())
+	}
+	return e
+}
+
+// fuzzSeeds are the scripts reaching the limits.
+var fuzzSeeds = [][2][]byte{
+	// Doubling the stack.
+	{[]byte{DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP,
+		DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP,
+		DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP,
+		DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP,
+		DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP, DUP},
+		[]byte{PUSH1}},
+	// Growing item by CAT.
+	{[]byte{DUP, CAT, DUP, CAT, DUP, CAT, DUP, CAT, DUP, CAT, DUP, CAT,
+		DUP, CAT, DUP, CAT, DUP, CAT}, []byte{PUSHBYTES1, 1}},
+	// Growing integer by SHL and MUL.
+	{[]byte{PUSHBYTES1, 0xff, PUSHBYTES1, 0x7f, SHL, DUP, MUL, DUP, MUL,
+		DUP, MUL, DUP, MUL}, []byte{PUSH1}},
+	{[]byte{PUSHM1, SHL}, []byte{PUSH1}},
+	// Large data push.
+	{[]byte{PUSHDATA4, 0x7f, 0xff, 0xff, 0xff}, nil},
+	{[]byte{SYSCALL, 0xfe, 0, 0x10, 0, 0}, nil},
+	// Nested calls.
+	{bytes.Repeat([]byte{CALL, 0, 0}, 9), bytes.Repeat([]byte{PUSH1}, 9)},
+	// Division by zero.
+	{[]byte{PUSH0, DIV}, []byte{PUSH1}},
+	{[]byte{PUSH0, MOD}, []byte{PUSH1}},
+	// Packing all items.
+	{[]byte{DEPTH, PACK, UNPACK, PACK}, []byte{PUSH1, PUSH2, PUSH3}},
+}
+
+func TestExecutionEngine_Limits(t *testing.T) {
+	tests := []struct {
+		code      []byte
+		parameter []byte
+		err       error
+	}{
+		{fuzzSeeds[0][0], fuzzSeeds[0][1], errors.ErrStackSize},
+		{fuzzSeeds[1][0], fuzzSeeds[1][1], errors.ErrItemSize},
+		{fuzzSeeds[2][0], fuzzSeeds[2][1], errors.ErrItemSize},
+		{fuzzSeeds[3][0], fuzzSeeds[3][1], errors.ErrItemSize},
+		{fuzzSeeds[4][0], fuzzSeeds[4][1], errors.ErrItemSize},
+		{fuzzSeeds[5][0], fuzzSeeds[5][1], errors.ErrItemSize},
+		{fuzzSeeds[6][0], fuzzSeeds[6][1], errors.ErrInvocationDepth},
+	}
+	for i, test := range tests {
+		var err error
+		e := NewExecutionEngine(nil, nil, MAXSTEPS, nil, NewGeneralService())
+		e.SetFlags(ScriptEnableLimits)
+		e.SetLimits(fuzzLimits)
+		e.SetTracer(func(trace *OpTrace) {
+			if trace.Error != nil {
+				err = trace.Error
+			}
+		})
+		e.LoadScript(test.code, false)
+		e.LoadScript(test.parameter, true)
+		e.Execute()
+		assert.Equal(t, FAULT, e.GetState(), "test %d", i)
+		assert.Equal(t, test.err, err, "test %d", i)
+	}
+
+	// No limits.
+	e := NewExecutionEngine(nil, nil, MAXSTEPS, nil, nil)
+	e.SetFlags(ScriptEnableLimits)
+	e.SetLimits(Limits{})
+	e.LoadScript(fuzzSeeds[0][0], false)
+	e.LoadScript(fuzzSeeds[0][1], true)
+	e.Execute()
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, 66, e.GetEvaluationStack().Count())
+
+	// The limits are not enforced without ScriptEnableLimits.
+	e = NewExecutionEngine(nil, nil, MAXSTEPS, nil, nil)
+	e.SetLimits(fuzzLimits)
+	e.LoadScript(fuzzSeeds[0][0], false)
+	e.LoadScript(fuzzSeeds[0][1], true)
+	e.Execute()
+	assert.Equal(t, HALT, e.GetState())
+	assert.Equal(t, 66, e.GetEvaluationStack().Count())
+}
+
+// TestExecutionEngine_RandomScripts executes random scripts, which are mostly
+// made of valid opcodes to go further than the first bytes.
+func TestExecutionEngine_RandomScripts(t *testing.T) {
+	var opCodes []byte
+	for i, op := range OpExecList {
+		// NOP sleeps, and the jumps are covered by the fuzz test.
+		if op.Exec == nil || i == NOP || i >= JMP && i <= CALL {
+			continue
+		}
+		opCodes = append(opCodes, byte(i))
+	}
+
+	r := rand.New(rand.NewSource(0))
+	random := func(n int) []byte {
+		script := make([]byte, r.Intn(n))
+		for i := range script {
+			if r.Intn(8) == 0 {
+				script[i] = byte(r.Intn(256))
+			} else {
+				script[i] = opCodes[r.Intn(len(opCodes))]
+			}
+		}
+		return script
+	}
+	for i := 0; i < 5000; i++ {
+		parameter := make([]byte, 0)
+		for j := r.Intn(4); j > 0; j-- {
+			parameter = append(parameter, byte(PUSH1+r.Intn(16)))
+		}
+		code := random(64)
+		executeFuzz(t, code, parameter)
+	}
+}
@@ -104,7 +104,7 @@ const (
 	WITHIN      = 0xA5 // Returns 1 if x is within the specified range (left-inclusive), 0 otherwise.
 
 	// Crypto
-	//RIPEMD160 = 0xA6 // The input is hashed using RIPEMD-160.
+	RIPEMD160     = 0xA6 // The input is hashed using RIPEMD-160.
 	SHA1          = 0xA7 // The input is hashed using SHA-1.
 	SHA256        = 0xA8 // The input is hashed using SHA-256.
 	HASH160       = 0xA9
@@ -113,6 +113,14 @@ const (
 	CHECKREGID    = 0xAD
 	CHECKMULTISIG = 0xAE // For each signature and public key pair CHECKSIG is executed. If more public keys than signatures are listed some key/sig pairs can fail. All signatures need to match a public key. If all signatures are valid 1 is returned 0 otherwise. Due to a bug one extra unused value is removed from the stack.
 
+	// Schnorr
+	CHECKSCHNORRSIG      = 0xAF // Same as CHECKSIG, but the signature is a Schnorr signature.
+	CHECKMULTISCHNORRSIG = 0xB0 // Each of the n public keys is paired with the Schnorr signature or PUSH0 pushed at the same position, 1 is returned if at least m signatures are given and all of them are valid, 0 otherwise.
+
+	// TimeLock
+	CHECKLOCKTIMEVERIFY = 0xB1 // Marks transaction as invalid if the top stack item is greater than the transaction's lock time, or the lock time is not enforced.
+	CHECKSEQUENCEVERIFY = 0xB2 // Marks transaction as invalid if the relative lock of any input is disabled or less than the top stack item.
+
 	// Array
 	ARRAYSIZE = 0xC0
 	PACK      = 0xC1
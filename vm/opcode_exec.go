@@ -103,6 +103,7 @@ var (
 		WITHIN:      {WITHIN, "WITHIN", opWithIn},
 
 		//Crypto
+		RIPEMD160:     {RIPEMD160, "RIPEMD160", opRipemd160},
 		SHA1:          {SHA1, "SHA1", opHash},
 		SHA256:        {SHA256, "SHA256", opHash},
 		HASH160:       {HASH160, "HASH160", opHash},
@@ -111,6 +112,14 @@ var (
 		CHECKREGID:    {CHECKREGID, "CHECKREGID", opCheckSig},
 		CHECKMULTISIG: {CHECKMULTISIG, "CHECKMULTISIG", opCheckMultiSig},
 
+		//Schnorr
+		CHECKSCHNORRSIG:      {CHECKSCHNORRSIG, "CHECKSCHNORRSIG", opCheckSchnorrSig},
+		CHECKMULTISCHNORRSIG: {CHECKMULTISCHNORRSIG, "CHECKMULTISCHNORRSIG", opCheckMultiSchnorrSig},
+
+		//TimeLock
+		CHECKLOCKTIMEVERIFY: {CHECKLOCKTIMEVERIFY, "CHECKLOCKTIMEVERIFY", opCheckLockTimeVerify},
+		CHECKSEQUENCEVERIFY: {CHECKSEQUENCEVERIFY, "CHECKSEQUENCEVERIFY", opCheckSequenceVerify},
+
 		//Array
 		ARRAYSIZE: {ARRAYSIZE, "ARRAYSIZE", opArraySize},
 		PACK:      {PACK, "PACK", opPack},
@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/vm/errors"
+)
+
+// OpTrace describes an opcode executed by the execution engine.
+type OpTrace struct {
+	// Position is the script position of the opcode.
+	Position int
+
+	// OpCode is the executed opcode.
+	OpCode OpCode
+
+	// StackDepth is the number of items in the evaluation stack after the
+	// opcode is executed.
+	StackDepth int
+
+	// Steps is the number of opcodes counted against the max steps of the
+	// engine, including the executed one.
+	Steps int
+
+	// State is HALT or FAULT if the opcode stops the execution, NONE
+	// otherwise.
+	State VMState
+
+	// Error is the reason of the execution fault.
+	Error error
+}
+
+// Tracer is called by the execution engine after each executed opcode.
+type Tracer func(trace *OpTrace)
+
+// SetTracer sets the tracer called after each executed opcode, a nil tracer
+// disables tracing.
+func (e *ExecutionEngine) SetTracer(tracer Tracer) {
+	e.tracer = tracer
+}
+
+// GetOpCount returns the number of opcodes counted against the max steps of
+// the engine.
+func (e *ExecutionEngine) GetOpCount() int {
+	return e.opCount
+}
+
+func (e *ExecutionEngine) trace(position int, opCode OpCode, state VMState, err error) {
+	if state == FAULT && err == nil {
+		err = errors.ErrFault
+	}
+	e.tracer(&OpTrace{
+		Position:   position,
+		OpCode:     opCode,
+		StackDepth: e.evaluationStack.Count(),
+		Steps:      e.opCount,
+		State:      state,
+		Error:      err,
+	})
+}
@@ -1,5 +1,7 @@
 package vm
 
+import "strings"
+
 type VMState byte
 
 const (
@@ -10,3 +12,26 @@ const (
 
 	INSUFFICIENT_RESOURCE VMState = 1 << 4
 )
+
+var vmStateStrings = []struct {
+	state VMState
+	name  string
+}{
+	{HALT, "HALT"},
+	{FAULT, "FAULT"},
+	{BREAK, "BREAK"},
+	{INSUFFICIENT_RESOURCE, "INSUFFICIENT_RESOURCE"},
+}
+
+func (s VMState) String() string {
+	if s == NONE {
+		return "NONE"
+	}
+	var names []string
+	for _, v := range vmStateStrings {
+		if s&v.state == v.state {
+			names = append(names, v.name)
+		}
+	}
+	return strings.Join(names, "|")
+}